@@ -0,0 +1,116 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import "fmt"
+
+// An Arity determines how the arguments are divided between the repeated
+// argument and the optional ones in repeat mode (see SetVariadic and
+// SetRepeated). Required arguments always get a value, whether they come
+// before or after the repeated argument.
+type Arity int
+
+const (
+	// Lazy gives values to the optional arguments, in order, before the
+	// repeated argument gets any. For example, with SetVariadic(Int,
+	// Int.Default("1"), String), the arguments "5 2 a b" give 2 to the second
+	// int. It is the default.
+	Lazy Arity = iota
+	// Greedy gives all the arguments that are not required to the repeated
+	// argument, so optional arguments always take their defaults. With the
+	// example above, "5 2 a b" gives 1 to the second int and passes "2" to the
+	// repeated argument.
+	Greedy
+)
+
+// SetArity sets how the arguments are divided between the repeated argument
+// and the optional ones. It is Lazy by default.
+//
+// Deprecated: Use Program.SetArity instead.
+func SetArity(a Arity) {
+	std.SetArity(a)
+}
+
+// SetArity is like the package-level SetArity, but for p.
+func (p *Program) SetArity(a Arity) {
+	p.arity = a
+}
+
+// SetRepeated makes the argument at index i of the ones passed to SetParsers
+// or SetVariadic the one that is repeated, taking all the arguments that the
+// others do not. The arguments after it get the last values. For example,
+// after SetParsers(ExistingFile, String) and SetRepeated(0), the program takes
+// any number of files followed by a string, like "cp src... dest". It panics
+// if i is out of range.
+//
+// Deprecated: Use Program.SetRepeated instead.
+func SetRepeated(i int) {
+	std.SetRepeated(i)
+}
+
+// SetRepeated is like the package-level SetRepeated, but for p.
+func (p *Program) SetRepeated(i int) {
+	if i < 0 || i >= len(p.parsers) {
+		panic(fmt.Sprintf("parse: SetRepeated: index %d out of range", i))
+	}
+	p.repeat, p.restIndex = true, i
+}
+
+// A slot is the place of one value passed to fn among the declared arguments.
+type slot struct {
+	decl int // index in parsers
+	arg  int // index of the argument, or -1 to use the default
+}
+
+// arrange assigns n arguments to the declared arguments, returning the slots
+// of the values to pass to fn in order. It fails if n is not a valid number of
+// arguments.
+func (p *Program) arrange(n int) ([]slot, error) {
+	min := p.minArgs()
+	if n < min {
+		return nil, errTooFew
+	}
+	slots := make([]slot, 0, n+len(p.parsers))
+	if !p.repeat {
+		if n > len(p.parsers) {
+			return nil, errTooMany
+		}
+		for i := range p.parsers {
+			if i < n {
+				slots = append(slots, slot{i, i})
+			} else {
+				slots = append(slots, slot{i, -1})
+			}
+		}
+		return slots, nil
+	}
+	// Decide how many optional arguments get values, and the repeated
+	// argument gets the rest.
+	optional := 0
+	if p.arity == Lazy {
+		for i, q := range p.parsers {
+			if i != p.restIndex && q.info().hasDefault && min+optional < n {
+				optional++
+			}
+		}
+	}
+	rest := n - min - optional
+	next := 0
+	for i, q := range p.parsers {
+		count := 1
+		switch {
+		case i == p.restIndex:
+			count = rest
+		case q.info().hasDefault && optional == 0:
+			slots = append(slots, slot{i, -1})
+			continue
+		case q.info().hasDefault:
+			optional--
+		}
+		for ; count > 0; count-- {
+			slots = append(slots, slot{i, next})
+			next++
+		}
+	}
+	return slots, nil
+}
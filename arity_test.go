@@ -0,0 +1,69 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"reflect"
+	"testing"
+)
+
+var arityTests = []struct {
+	rest   int
+	arity  Arity
+	args   []string
+	values []interface{}
+	err    error
+}{
+	{2, Lazy, []string{"5"}, []interface{}{5, 1}, nil},
+	{2, Lazy, []string{"5", "2", "a", "b"}, []interface{}{5, 2, "a", "b"}, nil},
+	{2, Greedy, []string{"5", "2", "a"}, []interface{}{5, 1, "2", "a"}, nil},
+	{2, Greedy, []string{}, nil, errTooFew},
+	{0, Lazy, []string{"a", "b", "7", "8"}, []interface{}{"a", "b", 7, 8}, nil},
+	{0, Lazy, []string{"7", "8"}, []interface{}{7, 8}, nil},
+	{0, Greedy, []string{"a", "b", "7", "8"},
+		[]interface{}{"a", "b", "7", 8, 1}, nil},
+	{1, Lazy, []string{"3", "a", "b", "4"},
+		[]interface{}{3, "a", "b", 4}, nil},
+	{1, Greedy, []string{"3", "a", "b", "4"},
+		[]interface{}{3, "a", "b", "4", 1}, nil},
+}
+
+func TestArity(t *testing.T) {
+	defer func() {
+		SetEveryParser(Parser{})
+		SetArity(Lazy)
+	}()
+	for i, test := range arityTests {
+		switch test.rest {
+		case 0:
+			SetParsers(String, Int, Int.Default("1"))
+		case 1:
+			SetParsers(Int, String, Int.Default("1"))
+		case 2:
+			SetParsers(Int, Int.Default("1"), String)
+		}
+		SetRepeated(test.rest)
+		SetArity(test.arity)
+		values, err := Parse(test.args)
+		if err != test.err || !reflect.DeepEqual(values, test.values) {
+			t.Errorf("%d. Parse(%q) with %v = %v, %v\nexpected %v, %v", i,
+				test.args, test.arity, values, err, test.values, test.err)
+		}
+	}
+}
+
+func TestRepeatedUsage(t *testing.T) {
+	defer func() {
+		SetEveryParser(Parser{})
+		SetNames()
+	}()
+	SetParsers(ExistingFile, String)
+	SetNames("src", "dest")
+	SetRepeated(0)
+	if s := std.usageArgs(); s != "[src ...] dest" {
+		t.Errorf("usage arguments are %q, expected %q", s, "[src ...] dest")
+	}
+	if err := Validate(); err != nil {
+		t.Errorf("Validate returned %v", err)
+	}
+}
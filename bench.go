@@ -0,0 +1,113 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"runtime"
+	"time"
+)
+
+// A benchResult holds the measurements taken by benchmark.
+type benchResult struct {
+	lines    int           // number of records processed
+	failed   int           // number of records that failed
+	skipped  int           // number of records that fn skipped
+	bytes    int           // size of the input
+	elapsed  time.Duration // time spent processing
+	allocs   uint64        // number of heap allocations
+	allocMem uint64        // bytes allocated on the heap
+	exact    bool          // whether to leave out timing (see SetDeterministic)
+}
+
+func (r benchResult) String() string {
+	if r.exact {
+		return fmt.Sprintf("%d lines (%d failed, %d skipped), %d bytes\n",
+			r.lines, r.failed, r.skipped, r.bytes)
+	}
+	secs := r.elapsed.Seconds()
+	perLine := func(n uint64) float64 {
+		if r.lines == 0 {
+			return 0
+		}
+		return float64(n) / float64(r.lines)
+	}
+	return fmt.Sprintf("%d lines (%d failed, %d skipped), %d bytes in %v\n"+
+		"%.0f lines/s, %.2f MB/s\n"+
+		"%d allocs (%.1f per line), %d bytes allocated (%.1f per line)\n",
+		r.lines, r.failed, r.skipped, r.bytes, r.elapsed,
+		float64(r.lines)/secs, float64(r.bytes)/secs/1e6,
+		r.allocs, perLine(r.allocs), r.allocMem, perLine(r.allocMem))
+}
+
+// runBench reads all the input with readInputs and then prints the result of
+// benchmark to the diagnostics stream. Reading happens before the measurement
+// starts, so that only the processing is measured.
+func (r *run) runBench(fn func([]interface{}), args []string) error {
+	data, err := r.readInputs(args)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(r.diagnostics, r.benchmark(fn, data))
+	return nil
+}
+
+// readInputs reads all the input from the files or URLs named in args, or from
+// standard input if there are none, and concatenates it.
+func (r *run) readInputs(args []string) ([]byte, error) {
+	if len(args) == 0 {
+		args = []string{"-"}
+		if r.inputURL != "" {
+			args[0] = r.inputURL
+		}
+	}
+	var data []byte
+	for _, name := range args {
+		rc, err := r.openInput(name)
+		if err != nil {
+			return nil, err
+		}
+		b, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b...)
+	}
+	return data, nil
+}
+
+// benchmark processes data like lines of standard input, discarding the output
+// of fn and any error messages, and measures how long it takes and how much
+// memory it allocates. Every record is processed, even if some fail.
+func (r *run) benchmark(fn func([]interface{}), data []byte) benchResult {
+	defer func(w io.Writer) { r.output = w }(r.output)
+	r.output = io.Discard
+	l := log.New(io.Discard, "", 0)
+	res := benchResult{bytes: len(data), exact: r.deterministic}
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	records := r.newRecordReader(bytes.NewReader(data))
+	for {
+		rec, err := records.next()
+		if err != nil {
+			break
+		}
+		res.lines++
+		skipped := r.counts.skipped.Load()
+		if !r.mapRecord(fn, rec, res.lines, l) {
+			res.failed++
+		}
+		res.skipped += int(r.counts.skipped.Load() - skipped)
+	}
+	res.elapsed = time.Since(start)
+	runtime.ReadMemStats(&after)
+	res.allocs = after.Mallocs - before.Mallocs
+	res.allocMem = after.TotalAlloc - before.TotalAlloc
+	return res
+}
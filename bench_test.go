@@ -0,0 +1,39 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBenchmark(t *testing.T) {
+	defer SetEveryParser(Parser{})
+	SetEveryParser(Int)
+	calls := 0
+	fn := func(args []interface{}) {
+		calls++
+		fmt.Fprintln(Output(), args...)
+	}
+	r := std.newRun().benchmark(fn, []byte("1 2\nx\n3\n"))
+	if r.lines != 3 || r.failed != 1 || r.bytes != 8 || calls != 2 {
+		t.Errorf("benchmark returned %+v after %d calls", r, calls)
+	}
+	if r.allocs == 0 || r.elapsed <= 0 {
+		t.Errorf("benchmark did not measure anything: %+v", r)
+	}
+}
+
+func TestBenchResultString(t *testing.T) {
+	r := benchResult{lines: 4, failed: 1, bytes: 2000000, elapsed: time.Second,
+		allocs: 10, allocMem: 100}
+	s := r.String()
+	for _, part := range []string{"4 lines (1 failed, 0 skipped)", "4 lines/s",
+		"2.00 MB/s", "10 allocs (2.5 per line)", "(25.0 per line)"} {
+		if !strings.Contains(s, part) {
+			t.Errorf("%q does not contain %q", s, part)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import "io"
+
+// Command returns a function that runs p with fn using Invoke, for programs
+// that use a framework such as cobra or urfave/cli for their flags and
+// subcommands, but want parse to handle the positional arguments, including
+// reading them from standard input when there are none. The framework passes
+// the positional arguments that remain after its flags, and the streams of the
+// command. For example, with cobra:
+//
+//	run := p.Command(fn)
+//	cmd := &cobra.Command{
+//		Use:  "sum numbers...",
+//		Args: func(_ *cobra.Command, args []string) error {
+//			return p.CheckArgs(args)
+//		},
+//		RunE: func(c *cobra.Command, args []string) error {
+//			return run(args, c.InOrStdin(), c.OutOrStdout(),
+//				c.ErrOrStderr())
+//		},
+//		SilenceErrors: true,
+//	}
+//
+// and with urfave/cli:
+//
+//	Action: func(c *cli.Context) error {
+//		return run(c.Args().Slice(), os.Stdin, c.App.Writer, c.App.ErrWriter)
+//	},
+//
+// The function returns an ExitError if the program failed, after parse has
+// printed the errors, so the framework should not print it again.
+func (p *Program) Command(fn func([]interface{})) func(args []string,
+	stdin io.Reader, stdout, stderr io.Writer) error {
+	return func(args []string, stdin io.Reader, stdout,
+		stderr io.Writer) error {
+		_, err := Invoke(p, fn, args, stdin, stdout, stderr)
+		return err
+	}
+}
+
+// CheckArgs returns an error if args cannot be parsed by p, for frameworks that
+// validate positional arguments before running a command, such as cobra with
+// its Args field. It accepts the same arguments as Command: built-in flags at
+// the beginning are allowed (but not carried out), and arguments that make
+// the command read its input instead, such as none at all or "-" for standard
+// input, are always accepted.
+func (p *Program) CheckArgs(args []string) error {
+	q := *p
+	args = q.stripFlags(args)
+	switch {
+	case len(args) == 0, len(args) == 1 && args[0] == "-",
+		q.filesMode, len(args) == 1 && (args[0] == "-h" || args[0] == "--help"):
+		return nil
+	}
+	_, err := q.Parse(args)
+	return err
+}
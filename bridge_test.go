@@ -0,0 +1,49 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCommand(t *testing.T) {
+	p := New()
+	p.SetName("sum")
+	p.SetEveryParser(Int)
+	p.SetBuiltinFlags(true)
+	run := p.Command(func(args []interface{}) {
+		sum := 0
+		for _, n := range AssertInts(args) {
+			sum += n
+		}
+		fmt.Fprintln(Output(), sum)
+	})
+	var out, diag bytes.Buffer
+	if err := run([]string{"1", "2"}, nil, &out, &diag); err != nil {
+		t.Errorf("run with arguments returned %v", err)
+	}
+	err := run(nil, strings.NewReader("3 4\nx\n"), &out, &diag)
+	if err == nil || err.Error() != "exit status 1" {
+		t.Errorf("run with bad input returned %v", err)
+	}
+	expected := "sum: \"x\" is not a whole number\n"
+	if out.String() != "3\n7\n" || diag.String() != expected {
+		t.Errorf("run wrote %q and %q", out.String(), diag.String())
+	}
+	if err := p.CheckArgs([]string{"1", "x"}); err == nil {
+		t.Error("CheckArgs accepted an invalid argument")
+	}
+	for _, args := range [][]string{nil, {"-"}, {"-k", "1"}, {"--", "1"}} {
+		if err := p.CheckArgs(args); err != nil {
+			t.Errorf("CheckArgs rejected %q: %v", args, err)
+		}
+	}
+	std.keepGoing = true
+	if err := p.CheckArgs([]string{"--fail-fast", "1"}); err != nil ||
+		!std.keepGoing {
+		t.Errorf("CheckArgs returned %v and carried out --fail-fast", err)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+// A Record is one invocation of the program, as produced by Chan.
+type Record struct {
+	Args []interface{} // the parsed arguments
+	Name string        // the file or URL it came from, or empty
+	Line int           // its line number, or zero for the command line
+}
+
+// Chan starts reading the program's input in a new goroutine and returns a
+// channel of parsed records and a channel of errors, so that a long-running
+// program can select on them along with tickers, signals, and other events. It
+// reads from the same places as Main and Collect, and like Collect, it never
+// prints anything or exits. The records channel holds up to buffer records.
+//
+// The errors for lines that fail are *LineError values, after which reading
+// continues with the next line. Other errors, such as for a file that cannot
+// be opened, end the input. Both channels are closed at the end of the input.
+// The caller must keep receiving from both of them until then, or else the
+// goroutine blocks forever. For example:
+//
+//	records, errs := parse.Chan(16)
+//	for records != nil || errs != nil {
+//		select {
+//		case rec, ok := <-records:
+//			if !ok {
+//				records = nil
+//				continue
+//			}
+//			...
+//		case err, ok := <-errs:
+//			if !ok {
+//				errs = nil
+//				continue
+//			}
+//			log.Println(err)
+//		}
+//	}
+//
+// Deprecated: Use Program.Chan instead.
+func Chan(buffer int) (<-chan Record, <-chan error) {
+	return std.Chan(buffer)
+}
+
+// Chan is like the package-level Chan, but it reads the input of p.
+func (p *Program) Chan(buffer int) (<-chan Record, <-chan error) {
+	records := make(chan Record, buffer)
+	errs := make(chan error, 1)
+	r := p.newRun()
+	args := r.stripFlags(append(r.envArgs(), r.commandLine()...))
+	names := r.inputNames(args)
+	go func() {
+		defer close(records)
+		defer close(errs)
+		if names == nil {
+			if parsed, err := r.parseArgs(args); err != nil {
+				errs <- err
+			} else {
+				records <- Record{Args: parsed}
+			}
+			return
+		}
+		r.eachRecord(names, func(name string, n, _ int, parsed []interface{},
+			err error) bool {
+			if err != nil {
+				errs <- err
+			} else {
+				records <- Record{parsed, name, n}
+			}
+			return true
+		})
+	}()
+	return records, errs
+}
@@ -0,0 +1,53 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestChan(t *testing.T) {
+	defer func(args []string) {
+		os.Args = args
+		SetEveryParser(Parser{})
+		SetFilesMode(false)
+	}(os.Args)
+	name := filepath.Join(t.TempDir(), "input")
+	os.WriteFile(name, []byte("1 2\nx\n3\n"), 0600)
+	SetEveryParser(Int)
+	SetFilesMode(true)
+	os.Args = []string{"prog", name, filepath.Join(t.TempDir(), "missing")}
+	var got []Record
+	var errs []error
+	records, errc := Chan(0)
+	for records != nil || errc != nil {
+		select {
+		case rec, ok := <-records:
+			if !ok {
+				records = nil
+				continue
+			}
+			got = append(got, rec)
+		case err, ok := <-errc:
+			if !ok {
+				errc = nil
+				continue
+			}
+			errs = append(errs, err)
+		}
+	}
+	expected := []Record{{[]interface{}{1, 2}, name, 1},
+		{[]interface{}{3}, name, 3}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Chan produced %v\nexpected %v", got, expected)
+	}
+	if len(errs) != 2 || errs[0].(*LineError).Line != 2 {
+		t.Fatalf("Chan produced errors %v", errs)
+	}
+	if _, ok := errs[1].(*LineError); ok {
+		t.Errorf("error for a missing file is a LineError: %v", errs[1])
+	}
+}
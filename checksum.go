@@ -0,0 +1,111 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"sync"
+)
+
+// An InputChecksum identifies the data that the program consumed from one of
+// its inputs.
+type InputChecksum struct {
+	Name   string   // the file name or URL, or "-" for standard input
+	SHA256 [32]byte // the SHA-256 hash of the bytes that were read
+	Bytes  int64    // the number of bytes that were read
+	Lines  int64    // the number of newlines among them
+}
+
+func (c InputChecksum) String() string {
+	return fmt.Sprintf("%x  %s (%d bytes, %d lines)", c.SHA256, c.Name,
+		c.Bytes, c.Lines)
+}
+
+// SetChecksums sets whether the program computes a SHA-256 hash of each input
+// it reads, so that batch runs can verify afterwards exactly which version of
+// the data they processed. When it is on, the hash of each input is printed to
+// the diagnostics stream at the end of the input, in the format of sha256sum
+// followed by the byte and line counts, and Checksums returns them. The hash
+// covers only the bytes that were actually read, which can be fewer than the
+// whole input if processing stopped early. Since a file that is split (see
+// SetSplitFiles) is not read in order, it turns splitting off. It is false by
+// default.
+//
+// Deprecated: Use Program.SetChecksums instead.
+func SetChecksums(on bool) {
+	std.SetChecksums(on)
+}
+
+// SetChecksums is like the package-level SetChecksums, but for p.
+func (p *Program) SetChecksums(on bool) {
+	p.checksums = on
+}
+
+// A checksumLog holds the checksums of the inputs that a program has read. It
+// is shared by the copies of the program made for each run.
+type checksumLog struct {
+	sync.Mutex
+	list []InputChecksum
+}
+
+// Checksums returns the checksums of the inputs that have been read so far, in
+// the order they were finished. It returns nil unless SetChecksums is on.
+func Checksums() []InputChecksum {
+	return active().Checksums()
+}
+
+// Checksums is like the package-level Checksums, but for p.
+func (p *Program) Checksums() []InputChecksum {
+	p.sums.Lock()
+	defer p.sums.Unlock()
+	return append([]InputChecksum(nil), p.sums.list...)
+}
+
+// A checksumReader hashes and counts the bytes read from an io.Reader.
+type checksumReader struct {
+	io.Reader
+	hash  hash.Hash
+	bytes int64
+	lines int64
+}
+
+func (r *checksumReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.hash.Write(p[:n])
+	r.bytes += int64(n)
+	r.lines += int64(bytes.Count(p[:n], []byte("\n")))
+	return n, err
+}
+
+// watchInput returns a reader for the input called name that computes its
+// checksum if checksums is on, and a function to call when the input has been
+// processed. A memory-mapped file is hashed all at once instead, so that its
+// mapping can still be used directly.
+func (p *Program) watchInput(name string, r io.Reader) (io.Reader, func()) {
+	if !p.checksums {
+		return r, func() {}
+	}
+	if m, ok := r.(*mapping); ok {
+		return r, func() {
+			p.addChecksum(InputChecksum{name, sha256.Sum256(m.data),
+				int64(len(m.data)), int64(bytes.Count(m.data, []byte("\n")))})
+		}
+	}
+	cr := &checksumReader{Reader: r, hash: sha256.New()}
+	return cr, func() {
+		c := InputChecksum{Name: name, Bytes: cr.bytes, Lines: cr.lines}
+		cr.hash.Sum(c.SHA256[:0])
+		p.addChecksum(c)
+	}
+}
+
+// addChecksum adds c to the checksums of p.
+func (p *Program) addChecksum(c InputChecksum) {
+	p.sums.Lock()
+	defer p.sums.Unlock()
+	p.sums.list = append(p.sums.list, c)
+}
@@ -0,0 +1,39 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"crypto/sha256"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestChecksums(t *testing.T) {
+	defer func(w io.Writer) {
+		SetChecksums(false)
+		std.sums.list = nil
+		std.output = w
+	}(std.output)
+	std.output = io.Discard
+	SetChecksums(true)
+	data := "a b\nc\nd"
+	path := filepath.Join(t.TempDir(), "input")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, mmap := range []bool{false, true} {
+		std.sums.list = nil
+		SetMmap(mmap)
+		if !std.newRun().mapInput(func([]interface{}) {}, path) {
+			t.Fatalf("mapInput(%q) failed", path)
+		}
+		expected := []InputChecksum{{path, sha256.Sum256([]byte(data)), 7, 2}}
+		if got := Checksums(); len(got) != 1 || got[0] != expected[0] {
+			t.Errorf("mmap %v: Checksums() = %v, expected %v", mmap, got,
+				expected)
+		}
+	}
+	SetMmap(false)
+}
@@ -0,0 +1,192 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A LineError records the failure of a single line of input.
+type LineError struct {
+	Name string // name of the file or URL, or empty for standard input
+	Line int    // line number, starting from one
+	Err  error  // the error for the line, which may be a MultiError
+}
+
+func (e *LineError) Error() string {
+	prefix := fmt.Sprintf("line %d: ", e.Line)
+	if e.Name != "" {
+		prefix = fmt.Sprintf("%s:%d: ", e.Name, e.Line)
+	}
+	return prefix + strings.ReplaceAll(e.Err.Error(), "\n", "\n"+prefix)
+}
+
+// LineErrors collects the errors from all the lines of input that failed, in
+// the order they were read.
+type LineErrors []*LineError
+
+// Error returns the messages of all the errors, one per line.
+func (l LineErrors) Error() string {
+	msgs := make([]string, len(l))
+	for i, err := range l {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Collect is an alternative to Main for programs that need all of their input
+// before processing any of it, such as to sort, deduplicate, or summarize it.
+// Rather than calling a function for each invocation, it returns the arguments
+// of all of them. It reads its input from the same places as Main: the files
+// named on the command line in files mode, the URL given to SetInputURL, or the
+// lines of standard input when it is piped or redirected. Otherwise, the
+// command-line arguments are the only invocation.
+//
+// Collect does not print anything or exit. If some lines fail, it returns the
+// others along with a LineErrors, unless SetKeepGoing(false) was called, in
+// which case it stops at the first failure. Failed lines are still copied to
+// the rejects stream (see SetStreams). If the command-line arguments fail, the
+// error is the one returned by Parse.
+//
+// Deprecated: Use Program.Collect instead.
+func Collect() ([][]interface{}, error) {
+	return std.Collect()
+}
+
+// Collect is like the package-level Collect, but it reads the input of p.
+func (p *Program) Collect() ([][]interface{}, error) {
+	var all [][]interface{}
+	err := p.collect(func(parsed []interface{}, _ int) {
+		all = append(all, parsed)
+	})
+	return all, err
+}
+
+// collect does the work of Collect, passing each invocation that succeeds to
+// add along with its number of arguments.
+func (p *Program) collect(add func(parsed []interface{}, args int)) error {
+	r := p.newRun()
+	args := r.stripFlags(append(r.envArgs(), r.commandLine()...))
+	names := r.inputNames(args)
+	if names == nil {
+		parsed, err := r.parseArgs(args)
+		if err != nil {
+			return err
+		}
+		add(parsed, len(args))
+		return nil
+	}
+	var errs LineErrors
+	var fatal error
+	r.eachRecord(names, func(_ string, _, args int, parsed []interface{},
+		err error) bool {
+		if lerr, ok := err.(*LineError); ok {
+			errs = append(errs, lerr)
+			return r.keepGoing
+		}
+		if err != nil {
+			fatal = err
+			return false
+		}
+		add(parsed, args)
+		return true
+	})
+	switch {
+	case fatal != nil:
+		return fatal
+	case errs != nil:
+		return errs
+	}
+	return nil
+}
+
+// parseArgs parses the command-line arguments when they are the only
+// invocation. Unlike Parse, it requires at least one argument with
+// SetEveryParser.
+func (p *Program) parseArgs(args []string) ([]interface{}, error) {
+	if p.repeat && len(args) == 0 {
+		return nil, errTooFew
+	}
+	return p.Parse(args)
+}
+
+// inputNames returns the names of the files or URLs that Main would read its
+// input from given args, where "-" stands for standard input, and sets the
+// source of r accordingly. It returns nil if args are the only invocation.
+func (r *run) inputNames(args []string) []string {
+	switch {
+	case r.filesMode && len(args) > 0:
+		r.source = FileSource
+		return args
+	case len(args) == 0 && r.inputURL != "":
+		r.source = URLSource
+		return []string{r.inputURL}
+	case len(args) == 1 && args[0] == "-",
+		len(args) == 0 && !r.stdinIsTerminal():
+		r.source = r.stdinSource()
+		return []string{"-"}
+	}
+	return nil
+}
+
+// eachInput parses each record from the files or URLs named in names and
+// passes the result to yield, stopping if it returns false. The error for a
+// record that fails is a *LineError, in which case the record is also copied
+// to the rejects stream. Errors for opening or reading the input are passed to
+// yield as they are, and they end the input.
+func (r *run) eachInput(names []string,
+	yield func([]interface{}, error) bool) {
+	r.eachRecord(names, func(_ string, _, _ int, parsed []interface{},
+		err error) bool {
+		return yield(parsed, err)
+	})
+}
+
+// eachRecord is like eachInput, but it also passes the name of the input, the
+// line number of the record, and its number of arguments to yield. The name is
+// empty for standard input.
+func (r *run) eachRecord(names []string, yield func(name string, n, args int,
+	parsed []interface{}, err error) bool) {
+	for _, name := range names {
+		rc, err := r.openInput(name)
+		if err != nil {
+			yield(name, 0, 0, nil, err)
+			return
+		}
+		if name == "-" {
+			name = ""
+		}
+		ok := r.eachReaderRecord(rc, name, yield)
+		rc.Close()
+		if !ok {
+			return
+		}
+	}
+}
+
+// eachReaderRecord does the work of eachRecord for the input in called name. It
+// returns false if the input should end.
+func (r *run) eachReaderRecord(in io.Reader, name string, yield func(
+	name string, n, args int, parsed []interface{}, err error) bool) bool {
+	records := r.newRecordReader(in)
+	for n := 1; ; n++ {
+		rec, err := records.next()
+		if err == io.EOF {
+			return true
+		}
+		if err != nil {
+			yield(name, n, 0, nil, err)
+			return false
+		}
+		parsed, err := r.parseRecord(rec)
+		if err != nil {
+			r.writeReject(rec)
+			err = &LineError{name, n, err}
+		}
+		if !yield(name, n, len(rec.tokens), parsed, err) {
+			return false
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCollectArgs(t *testing.T) {
+	defer func(args []string) {
+		os.Args = args
+		SetEveryParser(Parser{})
+	}(os.Args)
+	SetParsers(Int, Int)
+	os.Args = []string{"prog", "1", "2"}
+	all, err := Collect()
+	if expected := [][]interface{}{{1, 2}}; err != nil ||
+		!reflect.DeepEqual(all, expected) {
+		t.Errorf("Collect returned %v, %v\nexpected %v", all, err, expected)
+	}
+	os.Args = []string{"prog", "1", "x"}
+	if _, err := Collect(); err == nil ||
+		err.Error() != `"x" is not a whole number` {
+		t.Errorf("Collect returned error %v", err)
+	}
+}
+
+func TestCollectFiles(t *testing.T) {
+	defer func(args []string) {
+		os.Args = args
+		SetEveryParser(Parser{})
+		SetFilesMode(false)
+		SetKeepGoing(true)
+	}(os.Args)
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a")
+	b := filepath.Join(dir, "b")
+	os.WriteFile(a, []byte("3 1\nx 2\n"), 0600)
+	os.WriteFile(b, []byte("2\n1 y\n5\n"), 0600)
+	SetEveryParser(Int)
+	SetFilesMode(true)
+	os.Args = []string{"prog", a, b}
+	all, err := Collect()
+	expected := [][]interface{}{{3, 1}, {2}, {5}}
+	if !reflect.DeepEqual(all, expected) {
+		t.Errorf("Collect returned %v\nexpected %v", all, expected)
+	}
+	msg := a + `:2: "x" is not a whole number` + "\n" +
+		b + `:2: "y" is not a whole number`
+	if err == nil || err.Error() != msg {
+		t.Errorf("Collect returned error %v\nexpected %s", err, msg)
+	}
+	if errs, ok := err.(LineErrors); !ok || len(errs) != 2 ||
+		errs[1].Line != 2 || errs[1].Name != b {
+		t.Errorf("wrong LineErrors %#v", err)
+	}
+	SetKeepGoing(false)
+	all, err = Collect()
+	if len(all) != 1 || len(err.(LineErrors)) != 1 {
+		t.Errorf("Collect did not stop at the first failure: %v, %v", all,
+			err)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"context"
+	"io"
+	"os"
+)
+
+// MainContext is like Main, but it stops processing the input when ctx is
+// cancelled, as if fn had called Stop: the line that fn is processing is
+// finished, no more lines are read, and no more files are started. Unlike
+// Main, it returns when it stops, along with the number of lines it processed,
+// so that a program fed a long stream can shut down cleanly. The error is
+// ctx.Err(), which is nil if the input ended before ctx was cancelled. As with
+// Main, if any lines fail, MainContext exits with a nonzero status instead of
+// returning.
+//
+// A read from standard input or a file that is blocked waiting for data is
+// abandoned when ctx is cancelled, except when standard input is a terminal,
+// in which case the program stops once the user finishes the current line.
+//
+// Deprecated: Use Program.MainContext instead.
+func MainContext(ctx context.Context, fn func([]interface{})) (int, error) {
+	return std.MainContext(ctx, fn)
+}
+
+// MainContext is like the package-level MainContext, but it runs p.
+func (p *Program) MainContext(ctx context.Context,
+	fn func([]interface{})) (int, error) {
+	r := p.newRun()
+	r.ctx = ctx
+	r.main(fn)
+	return int(r.counts.lines.Load()), ctx.Err()
+}
+
+// stopping returns true if fn has called Stop, the context of r is cancelled,
+// or a signal has interrupted Run or Invoke.
+func (r *run) stopping() bool {
+	return r.stopped.Load() || r.ctx != nil && r.ctx.Err() != nil ||
+		r.signalled.Load() != 0
+}
+
+// withContext returns in, changed to end when the context of r is cancelled if
+// there is one. Terminals are left alone, and so is input that interruptible
+// has already changed.
+func (r *run) withContext(in io.Reader) io.Reader {
+	if r.ctx == nil || r.source == InteractiveSource {
+		return in
+	}
+	if _, ok := in.(*contextReader); ok {
+		return in
+	}
+	return interruptible(r.ctx, in)
+}
+
+// interruptible returns in, changed to end when ctx is cancelled. Memory-mapped
+// and regular files are left alone, since reading them does not wait for data
+// that might never come.
+func interruptible(ctx context.Context, in io.Reader) io.Reader {
+	switch in := in.(type) {
+	case *mapping:
+		return in
+	case *os.File:
+		if info, err := in.Stat(); err == nil && info.Mode().IsRegular() {
+			return in
+		}
+	}
+	return &contextReader{ctx: ctx, r: in}
+}
+
+// A contextReader reads from r until ctx is cancelled, and then returns io.EOF
+// without waiting for a read that is in progress to finish.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+	buf []byte
+}
+
+// A readResult is the result of a call to Read.
+type readResult struct {
+	n   int
+	err error
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if c.ctx.Err() != nil {
+		return 0, io.EOF
+	}
+	if len(c.buf) < len(p) {
+		c.buf = make([]byte, len(p))
+	}
+	// Read into a separate buffer, since the read might finish after Read has
+	// returned and the caller has reused p.
+	buf := c.buf[:len(p)]
+	result := make(chan readResult, 1)
+	go func() {
+		n, err := c.r.Read(buf)
+		result <- readResult{n, err}
+	}()
+	select {
+	case res := <-result:
+		return copy(p, buf[:res.n]), res.err
+	case <-c.ctx.Done():
+		c.buf = nil
+		return 0, io.EOF
+	}
+}
+
+// contextRecords reads records from source until ctx is cancelled.
+type contextRecords struct {
+	ctx    context.Context
+	source recordReader
+}
+
+func (r contextRecords) next() (record, error) {
+	rec, err := r.source.next()
+	if r.ctx.Err() != nil {
+		return record{}, io.EOF
+	}
+	return rec, err
+}
@@ -0,0 +1,59 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestMainContext(t *testing.T) {
+	defer func() {
+		SetArgs(nil)
+		SetInput(nil)
+		SetOutput(os.Stdout)
+		SetEveryParser(Parser{})
+	}()
+	SetEveryParser(Int)
+	r, w := io.Pipe()
+	defer w.Close()
+	go w.Write([]byte("1\n2\n3\n"))
+	ctx, cancel := context.WithCancel(context.Background())
+	var out bytes.Buffer
+	fn := func(args []interface{}) {
+		fmt.Fprintln(Output(), args...)
+		if args[0] == 2 {
+			cancel()
+		}
+	}
+	SetArgs([]string{})
+	SetInput(r)
+	SetOutput(&out)
+	// The writer never closes the pipe, so the input only ends by cancelling.
+	n, err := MainContext(ctx, fn)
+	if n != 2 || err != context.Canceled || out.String() != "1\n2\n" {
+		t.Errorf("MainContext returned %d, %v and wrote %q\n"+
+			"expected 2, %v, and %q", n, err, out.String(), context.Canceled,
+			"1\n2\n")
+	}
+}
+
+func TestMainContextEnd(t *testing.T) {
+	defer func() {
+		SetArgs(nil)
+		SetInput(nil)
+		SetOutput(os.Stdout)
+	}()
+	SetArgs([]string{})
+	SetInput(strings.NewReader("a\nb\n"))
+	SetOutput(io.Discard)
+	n, err := MainContext(context.Background(), func([]interface{}) {})
+	if n != 2 || err != nil {
+		t.Errorf("MainContext returned %d, %v; expected 2, nil", n, err)
+	}
+}
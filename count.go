@@ -0,0 +1,83 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// A Count is the number of times a value was given to the repeated argument.
+type Count struct {
+	Value interface{} // the parsed value
+	N     int         // the number of times it occurred
+}
+
+// CountRest is like Collect, but rather than returning the arguments of each
+// invocation, it tallies the values given to the repeated argument (see
+// SetVariadic and SetRepeated) across all of them, like "sort | uniq -c". The
+// other arguments are parsed but otherwise ignored. For example, with
+// SetEveryParser(Lower(Parser{})), it counts the words in its input regardless
+// of case. The counts are ordered from the most to the least frequent, and
+// values that occur equally often are in the order they first appeared.
+//
+// Values are distinct if they are not equal with ==, or for types that cannot
+// be compared, if they print differently with the %#v verb. Errors are handled
+// as in Collect, and the counts include the lines that did not fail. CountRest
+// panics if there is no repeated argument.
+//
+// Deprecated: Use Program.CountRest instead.
+func CountRest() ([]Count, error) {
+	return std.CountRest()
+}
+
+// CountRest is like the package-level CountRest, but for p.
+func (p *Program) CountRest() ([]Count, error) {
+	if !p.repeat {
+		panic("parse: CountRest: no repeated argument")
+	}
+	var counts []Count
+	index := make(map[interface{}]int)
+	err := p.collect(func(parsed []interface{}, args int) {
+		i := p.restIndex
+		for _, v := range parsed[i : i+p.restCount(args)] {
+			key := countKey(v)
+			i, ok := index[key]
+			if !ok {
+				i = len(counts)
+				index[key] = i
+				counts = append(counts, Count{Value: v})
+			}
+			counts[i].N++
+		}
+	})
+	sort.SliceStable(counts, func(i, j int) bool {
+		return counts[i].N > counts[j].N
+	})
+	return counts, err
+}
+
+// restCount returns the number of values that the repeated argument receives
+// from an invocation with the given number of arguments, which are the ones
+// that arrange assigns to it. Derived values (see Derive) and the extra values
+// of a record (see RawExtra and HeaderWithRest) come after all of them.
+func (p *Program) restCount(args int) int {
+	slots, _ := p.arrange(args)
+	n := 0
+	for _, s := range slots {
+		if s.decl == p.restIndex {
+			n++
+		}
+	}
+	return n
+}
+
+// countKey returns the key that identifies v among the values counted by
+// CountRest.
+func countKey(v interface{}) interface{} {
+	if v != nil && !reflect.TypeOf(v).Comparable() {
+		return fmt.Sprintf("%#v", v)
+	}
+	return v
+}
@@ -0,0 +1,91 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var countRestTests = []struct {
+	parsers []Parser
+	rest    int
+	input   string
+	counts  []Count
+	failed  bool
+}{
+	{
+		[]Parser{Lower(Parser{})}, 0, "b a\nA c B\nb\n",
+		[]Count{{"b", 3}, {"a", 2}, {"c", 1}}, false,
+	},
+	{
+		[]Parser{String, Int}, 1, "x 1 2\ny 2\nz 3 2 1\n",
+		[]Count{{2, 3}, {1, 2}, {3, 1}}, false,
+	},
+	{
+		[]Parser{Int, String}, 0, "1 2 x\n3 y\n2 z\n",
+		[]Count{{2, 2}, {1, 1}, {3, 1}}, false,
+	},
+	{
+		[]Parser{Int}, 0, "1 1\nx\n2\n",
+		[]Count{{1, 2}, {2, 1}}, true,
+	},
+}
+
+func TestCountRest(t *testing.T) {
+	defer func() {
+		SetArgs(nil)
+		SetInput(nil)
+		SetEveryParser(Parser{})
+	}()
+	SetArgs([]string{})
+	for i, test := range countRestTests {
+		SetParsers(test.parsers...)
+		SetRepeated(test.rest)
+		SetInput(strings.NewReader(test.input))
+		counts, err := CountRest()
+		if !reflect.DeepEqual(counts, test.counts) {
+			t.Errorf("%d. CountRest returned %v, expected %v", i, counts,
+				test.counts)
+		}
+		if failed := err != nil; failed != test.failed {
+			t.Errorf("%d. CountRest returned error %v", i, err)
+		}
+	}
+}
+
+func TestCountRestNotRepeated(t *testing.T) {
+	defer SetEveryParser(Parser{})
+	defer func() {
+		if recover() == nil {
+			t.Error("CountRest did not panic without a repeated argument")
+		}
+	}()
+	SetParsers(Int)
+	CountRest()
+}
+
+func TestCountRestExtras(t *testing.T) {
+	defer func() {
+		SetArgs(nil)
+		SetInput(nil)
+		SetEveryParser(Parser{})
+		ClearDerived()
+		SetLineLimit(0, DropExtra)
+	}()
+	SetArgs([]string{})
+	SetParsers(String, Int)
+	SetRepeated(1)
+	Derive("first", func(args []interface{}) (interface{}, error) {
+		return args[1], nil
+	})
+	SetLineLimit(3, RawExtra)
+	SetInput(strings.NewReader("x 1 2\ny 2\nz 3 2 1 0\n"))
+	counts, err := CountRest()
+	expected := []Count{{2, 3}, {1, 1}, {3, 1}}
+	if err != nil || !reflect.DeepEqual(counts, expected) {
+		t.Errorf("CountRest returned %v, %v; expected %v", counts, err,
+			expected)
+	}
+}
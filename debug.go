@@ -0,0 +1,126 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// debugChecks determines whether the invariants of parsers are checked.
+var debugChecks = false
+
+// SetDebugChecks sets whether parse checks, while the program runs, that
+// parsers follow the rules that the rest of the package relies on: that a
+// Parser never returns both a value and an error, and that a predicate passed
+// to Restrict never modifies the value it is checking, which it can do through
+// a pointer, slice, or map. A violation is reported as an error wrapping
+// ErrInvariant for the argument that revealed it. The checks catch subtle bugs
+// in custom parsers early, but they cost time and memory, so they are meant
+// for development and testing. They are off by default. Since parsers can be
+// shared between programs, the setting applies to every Program.
+func SetDebugChecks(on bool) {
+	debugChecks = on
+}
+
+// ErrInvariant is wrapped by the errors reported by the checks enabled with
+// SetDebugChecks.
+var ErrInvariant = errors.New("parser invariant violated")
+
+// checkResult returns an error if x and err are both non-nil.
+func checkResult(x interface{}, err error) error {
+	if x != nil && err != nil {
+		return fmt.Errorf("%w: returned both %#v and error %q", ErrInvariant,
+			x, err)
+	}
+	return nil
+}
+
+// checkPredicate calls pred with x and returns an error if pred modified x.
+// Values that are never deeply equal to themselves, such as those containing
+// functions, are not checked.
+func checkPredicate(pred func(interface{}) error, x interface{}) error {
+	if x == nil {
+		return pred(x)
+	}
+	before := deepCopy(reflect.ValueOf(x), make(map[visit]reflect.Value))
+	comparable := reflect.DeepEqual(before.Interface(), x)
+	err := pred(x)
+	if comparable && !reflect.DeepEqual(before.Interface(), x) {
+		return fmt.Errorf("%w: Restrict predicate changed %#v to %#v",
+			ErrInvariant, before.Interface(), x)
+	}
+	return err
+}
+
+// A visit is a pointer, slice, or map that deepCopy has already copied. Like
+// reflect.DeepEqual, it remembers them so that cyclic values terminate.
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+// deepCopy returns a copy of v that shares no pointers, slices, or maps with
+// it, except through unexported struct fields, which are copied shallowly. The
+// copies of the pointers, slices, and maps visited so far are kept in seen, so
+// that values referring to themselves have copies that do too.
+func deepCopy(v reflect.Value, seen map[visit]reflect.Value) reflect.Value {
+	if !v.IsValid() {
+		return v
+	}
+	c := reflect.New(v.Type()).Elem()
+	var key visit
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map:
+		if v.IsNil() {
+			return c
+		}
+		key = visit{v.Pointer(), v.Type(), 0}
+		if v.Kind() == reflect.Slice {
+			key.len = v.Len()
+		}
+		if done, ok := seen[key]; ok {
+			return done
+		}
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		p := reflect.New(v.Type().Elem())
+		seen[key] = p
+		p.Elem().Set(deepCopy(v.Elem(), seen))
+		c.Set(p)
+	case reflect.Slice:
+		c.Set(reflect.MakeSlice(v.Type(), v.Len(), v.Len()))
+		seen[key] = c
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i), seen))
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i), seen))
+		}
+	case reflect.Map:
+		c.Set(reflect.MakeMapWithSize(v.Type(), v.Len()))
+		seen[key] = c
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), deepCopy(iter.Value(), seen))
+		}
+	case reflect.Struct:
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(deepCopy(v.Field(i), seen))
+			}
+		}
+	case reflect.Interface:
+		if !v.IsNil() {
+			c.Set(deepCopy(v.Elem(), seen))
+		}
+	default:
+		c.Set(v)
+	}
+	return c
+}
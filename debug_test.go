@@ -0,0 +1,85 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fields is a Parser that returns the fields of the string, for testing
+// predicates that modify slices.
+var fields = NewParser(func(s string) (interface{}, error) {
+	return strings.Fields(s), nil
+})
+
+var debugTests = []struct {
+	p   Parser
+	s   string
+	msg string
+}{
+	{NewParser(func(s string) (interface{}, error) {
+		return 1, errors.New("bad")
+	}), "x", `parser invariant violated: returned both 1 and error "bad"`},
+	{fields.Restrict(func(x interface{}) error {
+		x.([]string)[0] = "z"
+		return nil
+	}), "a b", `parser invariant violated: Restrict predicate changed ` +
+		`[]string{"a", "b"} to []string{"z", "b"}`},
+	{fields.Restrict(func(x interface{}) error {
+		return errors.New("too short")
+	}), "a", "too short"},
+	{fields.Restrict(func(x interface{}) error { return nil }), "a", ""},
+	{Int.Restrict(positive), "-1", "cannot be negative"},
+}
+
+func TestDebugChecks(t *testing.T) {
+	defer SetDebugChecks(false)
+	SetDebugChecks(true)
+	for i, test := range debugTests {
+		_, err := callParser(test.p, test.s)
+		if err == nil && test.msg != "" ||
+			err != nil && err.Error() != test.msg {
+			t.Errorf("%d. parsing %q returned error %v, expected %q", i,
+				test.s, err, test.msg)
+		}
+		if test.msg != "" && strings.HasPrefix(test.msg, "parser invariant") &&
+			!errors.Is(err, ErrInvariant) {
+			t.Errorf("%d. error %v does not wrap ErrInvariant", i, err)
+		}
+	}
+}
+
+// A ring is a value that refers to itself, for testing that the debug checks
+// terminate.
+type ring struct {
+	Next  *ring
+	Items []interface{}
+	N     int
+}
+
+func TestDebugChecksCycle(t *testing.T) {
+	defer SetDebugChecks(false)
+	SetDebugChecks(true)
+	cyclic := NewParser(func(s string) (interface{}, error) {
+		r := &ring{N: len(s)}
+		r.Next = r
+		r.Items = []interface{}{r, map[string]*ring{"r": r}}
+		return r, nil
+	})
+	_, err := callParser(cyclic.Restrict(func(x interface{}) error {
+		return nil
+	}), "abc")
+	if err != nil {
+		t.Errorf("unchanged cyclic value returned error %v", err)
+	}
+	_, err = callParser(cyclic.Restrict(func(x interface{}) error {
+		x.(*ring).Next.N++
+		return nil
+	}), "abc")
+	if !errors.Is(err, ErrInvariant) {
+		t.Errorf("changed cyclic value returned error %v, expected one "+
+			"wrapping ErrInvariant", err)
+	}
+}
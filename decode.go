@@ -0,0 +1,354 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// SetGobRecords sets the input format to Gob, where the input is a stream of
+// values written by a gob.Encoder. Each value is decoded into a new value of
+// the same type as prototype, which is usually a struct, and then turned into
+// tokens to parse. A struct provides the arguments by name: each argument is
+// taken from the field with the same name (see SetNames), ignoring case, or
+// from the field with a `parse:"name"` tag. A map with string keys provides
+// them by name in the same way, and a slice or array provides them in order.
+// Strings are used as they are, and other values are formatted with fmt, or as
+// JSON text if they are slices, maps, or structs.
+//
+// Deprecated: Use Program.SetGobRecords instead.
+func SetGobRecords(prototype interface{}) {
+	std.SetGobRecords(prototype)
+}
+
+// SetGobRecords is like the package-level SetGobRecords, but for p.
+func (p *Program) SetGobRecords(prototype interface{}) {
+	p.gobType = reflect.TypeOf(prototype)
+	for p.gobType.Kind() == reflect.Ptr {
+		p.gobType = p.gobType.Elem()
+	}
+	p.inputFormat = Gob
+}
+
+// gobRecords reads records in the Gob format.
+type gobRecords struct {
+	p       *Program
+	decoder *gob.Decoder
+}
+
+func (r gobRecords) next() (record, error) {
+	v := reflect.New(r.p.gobType)
+	if err := r.decoder.Decode(v.Interface()); err != nil {
+		return record{}, err
+	}
+	return r.p.limitFields(r.p.valueFields(v.Elem()), " "), nil
+}
+
+// msgpackRecords reads records in the MessagePack format.
+type msgpackRecords struct {
+	p      *Program
+	reader *bufio.Reader
+}
+
+func (r msgpackRecords) next() (record, error) {
+	if _, err := r.reader.Peek(1); err != nil {
+		return record{}, err
+	}
+	v, err := readMsgpack(r.reader, 0)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return record{}, fmt.Errorf("invalid MessagePack: %s", err)
+	}
+	return r.p.limitFields(r.p.valueFields(reflect.ValueOf(v)), " "), nil
+}
+
+// valueFields converts a decoded value to tokens. Slices and arrays provide
+// them in order, and structs and maps provide them by argument name. In repeat
+// mode, a struct provides all its exported fields, and the entry of a map named
+// after the repeated argument can be a slice of values.
+func (p *Program) valueFields(v reflect.Value) []string {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return []string{}
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if isBytes(v.Type()) {
+			break
+		}
+		return elemFields(v)
+	case reflect.Struct:
+		if p.repeat {
+			var fields []string
+			for i := 0; i < v.NumField(); i++ {
+				if v.Type().Field(i).PkgPath == "" {
+					fields = append(fields, valueField(v.Field(i)))
+				}
+			}
+			return fields
+		}
+		return p.namedFields(func(name string) (reflect.Value, bool) {
+			return structField(v, name)
+		})
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			break
+		}
+		lookup := func(name string) (reflect.Value, bool) {
+			e := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+			return e, e.IsValid()
+		}
+		return p.namedFields(lookup)
+	}
+	return []string{valueField(v)}
+}
+
+// elemFields converts the elements of a slice or array to tokens.
+func elemFields(v reflect.Value) []string {
+	fields := make([]string, v.Len())
+	for i := range fields {
+		fields[i] = valueField(v.Index(i))
+	}
+	return fields
+}
+
+// namedFields converts the values named after the arguments to tokens, in
+// order, stopping at the first one that lookup does not find. In repeat mode,
+// the value for the repeated argument can be a slice.
+func (p *Program) namedFields(lookup func(string) (reflect.Value,
+	bool)) []string {
+	var fields []string
+	if p.repeat {
+		fields = []string{}
+	}
+	for i := range p.parsers {
+		v, ok := lookup(p.argName(i))
+		if !ok {
+			break
+		}
+		for v.Kind() == reflect.Interface && !v.IsNil() {
+			v = v.Elem()
+		}
+		if p.isRest(i) && v.Kind() == reflect.Slice && !isBytes(v.Type()) {
+			fields = append(fields, elemFields(v)...)
+			continue
+		}
+		fields = append(fields, valueField(v))
+	}
+	return fields
+}
+
+// structField finds the exported field of the struct v for the argument name.
+func structField(v reflect.Value, name string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		if f.Tag.Get("parse") == name || strings.EqualFold(f.Name, name) {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// valueField converts a single decoded value to a token. Strings and byte
+// slices are used as they are, nil becomes an empty string, composite values
+// become JSON text, and other values are formatted with fmt.
+func valueField(v reflect.Value) string {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Invalid:
+		return ""
+	case reflect.String:
+		return v.String()
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+		if v.Kind() == reflect.Slice && isBytes(v.Type()) {
+			return string(v.Bytes())
+		}
+		if b, err := json.Marshal(v.Interface()); err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// isBytes returns true if t is a slice or array of bytes.
+func isBytes(t reflect.Type) bool {
+	return t.Elem().Kind() == reflect.Uint8
+}
+
+// readMsgpack reads a single MessagePack value from r. It returns nil, bool,
+// int64, uint64, float64, string, []byte, []interface{}, or, for maps,
+// map[string]interface{} with keys formatted by valueField. Extension types
+// are not supported. The depth is the number of arrays and maps that the value
+// is nested in, which cannot exceed maxMsgpackDepth.
+func readMsgpack(r *bufio.Reader, depth int) (interface{}, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c >= 0x80 && c <= 0x8f:
+		return readMsgpackMap(r, int(c&0x0f), depth)
+	case c >= 0x90 && c <= 0x9f:
+		return readMsgpackArray(r, int(c&0x0f), depth)
+	case c >= 0xa0 && c <= 0xbf:
+		b, err := readMsgpackBytes(r, uint64(c&0x1f))
+		return string(b), err
+	}
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := readMsgpackUint(r, 1<<(c-0xc4))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackBytes(r, n)
+	case 0xca:
+		n, err := readMsgpackUint(r, 4)
+		return float64(math.Float32frombits(uint32(n))), err
+	case 0xcb:
+		n, err := readMsgpackUint(r, 8)
+		return math.Float64frombits(n), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		return readMsgpackUint(r, 1<<(c-0xcc))
+	case 0xd0:
+		n, err := readMsgpackUint(r, 1)
+		return int64(int8(n)), err
+	case 0xd1:
+		n, err := readMsgpackUint(r, 2)
+		return int64(int16(n)), err
+	case 0xd2:
+		n, err := readMsgpackUint(r, 4)
+		return int64(int32(n)), err
+	case 0xd3:
+		n, err := readMsgpackUint(r, 8)
+		return int64(n), err
+	case 0xd9, 0xda, 0xdb:
+		n, err := readMsgpackUint(r, 1<<(c-0xd9))
+		if err != nil {
+			return nil, err
+		}
+		b, err := readMsgpackBytes(r, n)
+		return string(b), err
+	case 0xdc, 0xdd:
+		n, err := readMsgpackUint(r, 2<<(c-0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackArray(r, int(n), depth)
+	case 0xde, 0xdf:
+		n, err := readMsgpackUint(r, 2<<(c-0xde))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackMap(r, int(n), depth)
+	}
+	return nil, fmt.Errorf("unsupported type byte 0x%02x", c)
+}
+
+// readMsgpackUint reads a big-endian unsigned integer of size bytes.
+func readMsgpackUint(r *bufio.Reader, size int) (uint64, error) {
+	var buf [8]byte
+	if _, err := io.ReadFull(r, buf[8-size:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(buf[:]), nil
+}
+
+// maxMsgpackDepth is the deepest that arrays and maps can be nested in a
+// MessagePack record.
+const maxMsgpackDepth = 100
+
+// msgpackChunk is the most that is allocated for a string, binary value, or
+// array before its contents have been read, since the size in the header
+// cannot be trusted.
+const msgpackChunk = 4096
+
+// readMsgpackBytes reads n bytes of a string or binary value.
+func readMsgpackBytes(r *bufio.Reader, n uint64) ([]byte, error) {
+	if n > maxRecordSize {
+		return nil, fmt.Errorf("value too large (%d bytes)", n)
+	}
+	b := make([]byte, 0, min(n, msgpackChunk))
+	for uint64(len(b)) < n {
+		chunk := min(n-uint64(len(b)), msgpackChunk)
+		b = append(b, make([]byte, chunk)...)
+		if _, err := io.ReadFull(r, b[uint64(len(b))-chunk:]); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// readMsgpackArray reads the n elements of an array nested depth deep.
+func readMsgpackArray(r *bufio.Reader, n, depth int) ([]interface{}, error) {
+	if n > maxRecordSize {
+		return nil, fmt.Errorf("array too large (%d elements)", n)
+	}
+	if depth >= maxMsgpackDepth {
+		return nil, errors.New("arrays and maps nested too deeply")
+	}
+	a := make([]interface{}, 0, min(n, msgpackChunk))
+	for i := 0; i < n; i++ {
+		v, err := readMsgpack(r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, v)
+	}
+	return a, nil
+}
+
+// readMsgpackMap reads the n entries of a map nested depth deep.
+func readMsgpackMap(r *bufio.Reader,
+	n, depth int) (map[string]interface{}, error) {
+	if n > maxRecordSize {
+		return nil, fmt.Errorf("map too large (%d entries)", n)
+	}
+	if depth >= maxMsgpackDepth {
+		return nil, errors.New("arrays and maps nested too deeply")
+	}
+	m := make(map[string]interface{})
+	for i := 0; i < n; i++ {
+		k, err := readMsgpack(r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		v, err := readMsgpack(r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		m[valueField(reflect.ValueOf(k))] = v
+	}
+	return m, nil
+}
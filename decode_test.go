@@ -0,0 +1,107 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bytes"
+	"encoding/gob"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// readRecords reads all the tokens from r, failing the test on errors.
+func readRecords(t *testing.T, r recordReader) [][]string {
+	var got [][]string
+	for {
+		rec, err := r.next()
+		if err == io.EOF {
+			return got
+		}
+		if err != nil {
+			t.Fatalf("next() returned %v", err)
+		}
+		got = append(got, rec.tokens)
+	}
+}
+
+type gobPoint struct {
+	Y     float64
+	X     int
+	Label string `parse:"name"`
+	Tags  []string
+}
+
+func TestGobRecords(t *testing.T) {
+	defer func() {
+		SetInputFormat(Shell)
+		SetEveryParser(Parser{})
+		SetNames()
+	}()
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	enc.Encode(gobPoint{1.5, 2, "a b", []string{"t"}})
+	enc.Encode(gobPoint{X: -1})
+	data := buf.Bytes()
+
+	SetGobRecords(&gobPoint{})
+	SetParsers(Parser{}, Parser{}, Parser{})
+	SetNames("x", "y", "name")
+	got := readRecords(t, newRecordReader(bytes.NewReader(data)))
+	expected := [][]string{{"2", "1.5", "a b"}, {"-1", "0", ""}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("SetParsers: got %q, expected %q", got, expected)
+	}
+
+	SetEveryParser(Parser{})
+	got = readRecords(t, newRecordReader(bytes.NewReader(data)))
+	expected = [][]string{{"1.5", "2", "a b", `["t"]`}, {"0", "-1", "", "null"}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("SetEveryParser: got %q, expected %q", got, expected)
+	}
+}
+
+var msgpackTests = []struct {
+	input   string
+	records [][]string
+}{
+	{"\x93\x01\xa2ab\xc0", [][]string{{"1", "ab", ""}}},
+	{"\x92\xc3\xff\x91\xcd\x01\x00", [][]string{{"true", "-1"}, {"256"}}},
+	{"\x92\xcb\x3f\xf8\x00\x00\x00\x00\x00\x00\x92\x01\x02",
+		[][]string{{"1.5", "[1,2]"}}},
+	{"\x82\xa1y\x02\xa1x\xd0\xfe", [][]string{{"-2", "2"}}},
+	{"\xc4\x02hi", [][]string{{"hi"}}},
+}
+
+func TestMsgpackRecords(t *testing.T) {
+	defer func() {
+		SetInputFormat(Shell)
+		SetEveryParser(Parser{})
+		SetNames()
+	}()
+	SetInputFormat(MessagePack)
+	SetParsers(Parser{}, Parser{}, Parser{})
+	SetNames("x", "y")
+	for i, test := range msgpackTests {
+		got := readRecords(t, newRecordReader(strings.NewReader(test.input)))
+		if !reflect.DeepEqual(got, test.records) {
+			t.Errorf("%d. reading %q\nreturned %q\nexpected %q", i,
+				test.input, got, test.records)
+		}
+	}
+}
+
+func TestMsgpackInvalid(t *testing.T) {
+	defer SetInputFormat(Shell)
+	SetInputFormat(MessagePack)
+	inputs := []string{"\x92\x01", "\xc1", "\xd4\x01\x02",
+		"\xdd\x00\xff\xff\xff\x01", "\xdf\x00\xff\xff\xff",
+		"\xdb\x03\xff\xff\xffab", strings.Repeat("\x91", 1000) + "\x01"}
+	for _, input := range inputs {
+		r := newRecordReader(strings.NewReader(input))
+		if _, err := r.next(); err == nil || err == io.EOF {
+			t.Errorf("reading %q returned %v, expected an error", input, err)
+		}
+	}
+}
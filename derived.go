@@ -0,0 +1,82 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import "fmt"
+
+// A derivedArg is a computed argument added by Derive.
+type derivedArg struct {
+	name string
+	fn   func(args []interface{}) (interface{}, error)
+}
+
+// Derive adds a computed argument called name, whose value fn computes from the
+// parsed arguments. It is appended to the arguments passed to fn, after the
+// parsed ones and any computed arguments added before it, which fn can use as
+// well. This lets a program validate combinations of arguments in one place,
+// such as a width and a height whose product must not exceed a limit:
+//
+//	parse.SetParsers(parse.Float64, parse.Float64)
+//	parse.SetNames("width", "height")
+//	parse.Derive("area", func(args []interface{}) (interface{}, error) {
+//		area := args[0].(float64) * args[1].(float64)
+//		if area > 100 {
+//			return nil, errors.New("is larger than 100")
+//		}
+//		return area, nil
+//	})
+//
+// If fn returns an error, the invocation fails like one with a parse error,
+// with a DerivedError. Computed arguments are only computed when all the
+// arguments parse successfully, and they come before the rest of a line kept
+// by RawExtra (see SetLineLimit).
+//
+// Deprecated: Use Program.Derive instead.
+func Derive(name string, fn func(args []interface{}) (interface{}, error)) {
+	std.Derive(name, fn)
+}
+
+// Derive is like the package-level Derive, but for p.
+func (p *Program) Derive(name string,
+	fn func(args []interface{}) (interface{}, error)) {
+	p.derived = append(p.derived, derivedArg{name, fn})
+}
+
+// ClearDerived removes all the computed arguments added by Derive.
+//
+// Deprecated: Use Program.ClearDerived instead.
+func ClearDerived() {
+	std.ClearDerived()
+}
+
+// ClearDerived is like the package-level ClearDerived, but for p.
+func (p *Program) ClearDerived() {
+	p.derived = nil
+}
+
+// A DerivedError is the error for a computed argument (see Derive) that could
+// not be computed.
+type DerivedError struct {
+	Name string // the name given to Derive
+	Err  error  // the error returned by its function
+}
+
+func (e *DerivedError) Error() string {
+	return fmt.Sprintf("%s %v", e.Name, e.Err)
+}
+
+func (e *DerivedError) Unwrap() error {
+	return e.Err
+}
+
+// addDerived appends the computed arguments to parsed.
+func (p *Program) addDerived(parsed []interface{}) ([]interface{}, error) {
+	for _, d := range p.derived {
+		v, err := d.fn(parsed)
+		if err != nil {
+			return nil, &DerivedError{d.name, err}
+		}
+		parsed = append(parsed, v)
+	}
+	return parsed, nil
+}
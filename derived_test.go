@@ -0,0 +1,49 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var derivedTests = []struct {
+	args     []string
+	expected []interface{}
+	err      string
+}{
+	{[]string{"2", "3"}, []interface{}{2.0, 3.0, 6.0, "small"}, ""},
+	{[]string{"20", "30"}, nil, "area is larger than 100"},
+	{[]string{"x", "3"}, nil, `"x" is not a number`},
+}
+
+func TestDerive(t *testing.T) {
+	defer func() {
+		SetEveryParser(Parser{})
+		ClearDerived()
+	}()
+	SetParsers(Float64, Float64)
+	Derive("area", func(args []interface{}) (interface{}, error) {
+		area := args[0].(float64) * args[1].(float64)
+		if area > 100 {
+			return nil, errors.New("is larger than 100")
+		}
+		return area, nil
+	})
+	Derive("size", func(args []interface{}) (interface{}, error) {
+		if args[2].(float64) < 10 {
+			return "small", nil
+		}
+		return "large", nil
+	})
+	for i, test := range derivedTests {
+		parsed, err := Parse(test.args)
+		if !reflect.DeepEqual(parsed, test.expected) ||
+			err == nil && test.err != "" ||
+			err != nil && err.Error() != test.err {
+			t.Errorf("%d. Parse(%q) = %v, %v; expected %v, %q", i, test.args,
+				parsed, err, test.expected, test.err)
+		}
+	}
+}
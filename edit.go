@@ -0,0 +1,436 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxHistory is the number of lines the line editor loads from its history.
+const maxHistory = 1000
+
+// SetLineEditing enables or disables line editing for arguments that the user
+// types into a terminal. It is disabled by default. The editor supports the
+// usual Emacs-style key bindings: the arrow keys, Home, End, and Ctrl-A,
+// Ctrl-E, Ctrl-B, Ctrl-F, Ctrl-K, and Ctrl-U move the cursor and delete text,
+// the up and down arrows or Ctrl-P and Ctrl-N go through previous lines, and
+// Ctrl-R searches backwards through them. Tab completes the current argument
+// using the Suggest method of its Parser. Ctrl-C discards the current line and
+// Ctrl-D on an empty line ends the input.
+//
+// The history is saved in a file named after the program in the user's home
+// directory, such as ~/.calc_history, unless SetHistoryFile is used.
+//
+// Deprecated: Use Program.SetLineEditing instead.
+func SetLineEditing(on bool) {
+	std.SetLineEditing(on)
+}
+
+// SetLineEditing is like the package-level SetLineEditing, but for p.
+func (p *Program) SetLineEditing(on bool) {
+	p.lineEditing = on
+}
+
+// SetHistoryFile sets the file where the line editor loads and saves lines. If
+// path is empty, the history is not saved between runs.
+//
+// Deprecated: Use Program.SetHistoryFile instead.
+func SetHistoryFile(path string) {
+	std.SetHistoryFile(path)
+}
+
+// SetHistoryFile is like the package-level SetHistoryFile, but for p.
+func (p *Program) SetHistoryFile(path string) {
+	p.historyFile = path
+	p.historySet = true
+}
+
+// historyPath returns the path of the history file, or the empty string if the
+// history should not be saved.
+func (p *Program) historyPath() string {
+	if p.historySet {
+		return p.historyFile
+	}
+	home := os.Getenv("HOME")
+	if home == "" {
+		return ""
+	}
+	return filepath.Join(home, "."+p.name+"_history")
+}
+
+// Key codes used by the line editor.
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyCtrlG     = 7
+	keyBackspace = 8
+	keyTab       = 9
+	keyCtrlK     = 11
+	keyEnter     = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlR     = 18
+	keyCtrlU     = 21
+	keyEscape    = 27
+	keyDelete    = 127
+)
+
+// lineEditor is an io.Reader that reads lines from a terminal in raw mode,
+// letting the user edit each line before it is returned. It shows the same
+// prompts as promptReader.
+type lineEditor struct {
+	in      *bufio.Reader
+	out     io.Writer
+	raw     func() (restore func(), err error)  // puts the terminal in raw mode
+	suggest func(i int, prefix string) []string // completes argument i
+	state   promptReader                        // tracks continuation lines
+	history []string
+	file    string // where to append history, or "" for none
+	pending []byte // the rest of the last line, not yet read
+}
+
+// newLineEditor returns a lineEditor that reads from in, which is standard
+// input, and writes to the diagnostics stream of p.
+func (p *Program) newLineEditor(in io.Reader) *lineEditor {
+	e := &lineEditor{
+		in:  bufio.NewReader(in),
+		out: p.diagnostics,
+		raw: rawMode,
+		suggest: func(i int, prefix string) []string {
+			return p.parserAt(i).Suggest(prefix)
+		},
+		state: promptReader{prompt: p.prompt, contPrompt: p.contPrompt},
+		file:  p.historyPath(),
+	}
+	e.loadHistory()
+	return e
+}
+
+// loadHistory reads the most recent lines from the history file.
+func (e *lineEditor) loadHistory() {
+	if e.file == "" {
+		return
+	}
+	f, err := os.Open(e.file)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		e.history = append(e.history, scanner.Text())
+	}
+	if len(e.history) > maxHistory {
+		e.history = e.history[len(e.history)-maxHistory:]
+	}
+}
+
+// addHistory adds line to the history and appends it to the history file.
+func (e *lineEditor) addHistory(line string) {
+	if strings.TrimSpace(line) == "" ||
+		len(e.history) > 0 && e.history[len(e.history)-1] == line {
+		return
+	}
+	e.history = append(e.history, line)
+	if e.file == "" {
+		return
+	}
+	f, err := os.OpenFile(e.file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return
+	}
+	fmt.Fprintln(f, line)
+	f.Close()
+}
+
+func (e *lineEditor) Read(data []byte) (n int, err error) {
+	if len(e.pending) == 0 {
+		line, err := e.readLine(e.state.currentPrompt())
+		if err != nil {
+			return 0, err
+		}
+		e.addHistory(line)
+		e.pending = []byte(line + "\n")
+		e.state.update(e.pending)
+	}
+	n = copy(data, e.pending)
+	e.pending = e.pending[n:]
+	return n, nil
+}
+
+// readLine reads and edits a single line after printing prompt. It returns
+// io.EOF if the user presses Ctrl-D on an empty line.
+func (e *lineEditor) readLine(prompt string) (string, error) {
+	if e.raw != nil {
+		restore, err := e.raw()
+		if err == nil {
+			defer restore()
+		}
+	}
+	var buf []rune
+	pos := 0
+	hist := len(e.history) // index into history; len means the new line
+	saved := ""            // the new line, saved while browsing history
+	redraw := func() {
+		fmt.Fprintf(e.out, "\r%s%s\x1b[K", prompt, string(buf))
+		if back := len(buf) - pos; back > 0 {
+			fmt.Fprintf(e.out, "\x1b[%dD", back)
+		}
+	}
+	setLine := func(s string) {
+		buf = []rune(s)
+		pos = len(buf)
+	}
+	redraw()
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			if err == io.EOF && len(buf) > 0 {
+				break
+			}
+			io.WriteString(e.out, "\r\n")
+			return "", err
+		}
+		if r == keyEscape {
+			r = e.readEscape()
+		}
+		switch r {
+		case keyEnter, '\n':
+			io.WriteString(e.out, "\r\n")
+			return string(buf), nil
+		case keyCtrlC:
+			io.WriteString(e.out, "^C\r\n")
+			buf, pos, hist = nil, 0, len(e.history)
+		case keyCtrlD:
+			if len(buf) == 0 {
+				io.WriteString(e.out, "\r\n")
+				return "", io.EOF
+			}
+			if pos < len(buf) {
+				buf = append(buf[:pos], buf[pos+1:]...)
+			}
+		case keyBackspace, keyDelete:
+			if pos > 0 {
+				buf = append(buf[:pos-1], buf[pos:]...)
+				pos--
+			}
+		case keyCtrlA:
+			pos = 0
+		case keyCtrlE:
+			pos = len(buf)
+		case keyCtrlB:
+			if pos > 0 {
+				pos--
+			}
+		case keyCtrlF:
+			if pos < len(buf) {
+				pos++
+			}
+		case keyCtrlK:
+			buf = buf[:pos]
+		case keyCtrlU:
+			buf = buf[pos:]
+			pos = 0
+		case keyCtrlP:
+			if hist > 0 {
+				if hist == len(e.history) {
+					saved = string(buf)
+				}
+				hist--
+				setLine(e.history[hist])
+			}
+		case keyCtrlN:
+			if hist < len(e.history) {
+				hist++
+				if hist == len(e.history) {
+					setLine(saved)
+				} else {
+					setLine(e.history[hist])
+				}
+			}
+		case keyTab:
+			buf, pos = e.complete(buf, pos)
+		case keyCtrlR:
+			if line, ok := e.search(); ok {
+				setLine(line)
+				hist = len(e.history)
+			}
+		default:
+			if r >= ' ' {
+				buf = append(buf, 0)
+				copy(buf[pos+1:], buf[pos:])
+				buf[pos] = r
+				pos++
+			}
+		}
+		redraw()
+	}
+	io.WriteString(e.out, "\r\n")
+	return string(buf), nil
+}
+
+// complete completes the argument that ends at the cursor using the editor's
+// suggest function, returning the new line and cursor position. If there are
+// several suggestions, it completes their common prefix, or if there is none,
+// it prints them all.
+func (e *lineEditor) complete(buf []rune, pos int) ([]rune, int) {
+	if e.suggest == nil {
+		return buf, pos
+	}
+	i, prefix, raw := currentArg(string(buf[:pos]))
+	suggestions := e.suggest(i, prefix)
+	var insert string
+	switch len(suggestions) {
+	case 0:
+		return buf, pos
+	case 1:
+		insert = escapeArg(suggestions[0])
+		if !strings.HasSuffix(insert, string(filepath.Separator)) {
+			insert += " "
+		}
+	default:
+		common := commonPrefix(suggestions)
+		if len(common) <= len(prefix) {
+			fmt.Fprintf(e.out, "\r\n%s\r\n", strings.Join(suggestions, "  "))
+			return buf, pos
+		}
+		insert = escapeArg(common)
+	}
+	start := pos - raw
+	line := string(buf[:start]) + insert + string(buf[pos:])
+	return []rune(line), start + len([]rune(insert))
+}
+
+// currentArg determines which argument ends at the end of line. It returns the
+// index of the argument, the argument after tokenization, and its length in
+// runes before tokenization.
+func currentArg(line string) (i int, arg string, raw int) {
+	tokens := tokenize([]byte(line))
+	// If appending a character would start a new token, then the line ends
+	// with a separator, and the argument has not been started yet.
+	if len(tokens) == 0 || len(tokenize([]byte(line+"x"))) > len(tokens) {
+		return len(tokens), "", 0
+	}
+	i = len(tokens) - 1
+	_, rest := tokenizeN([]byte(line), i)
+	return i, string(tokens[i]), len([]rune(string(rest)))
+}
+
+// escapeArg escapes the characters in s that tokenize treats specially.
+func escapeArg(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		if c == '\\' || c == '\'' || c == '"' || unicode.IsSpace(c) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// commonPrefix returns the longest common prefix of list.
+func commonPrefix(list []string) string {
+	prefix := list[0]
+	for _, s := range list[1:] {
+		for !strings.HasPrefix(s, prefix) {
+			_, size := utf8.DecodeLastRuneInString(prefix)
+			prefix = prefix[:len(prefix)-size]
+		}
+	}
+	return prefix
+}
+
+// readEscape reads the rest of an escape sequence after the escape key and
+// returns the equivalent control key, or 0 if the sequence is not recognized.
+func (e *lineEditor) readEscape() rune {
+	if r, _, _ := e.in.ReadRune(); r != '[' && r != 'O' {
+		return 0
+	}
+	r, _, _ := e.in.ReadRune()
+	switch r {
+	case 'A':
+		return keyCtrlP
+	case 'B':
+		return keyCtrlN
+	case 'C':
+		return keyCtrlF
+	case 'D':
+		return keyCtrlB
+	case 'H':
+		return keyCtrlA
+	case 'F':
+		return keyCtrlE
+	case '3':
+		if r, _, _ := e.in.ReadRune(); r == '~' {
+			return keyCtrlD
+		}
+	}
+	return 0
+}
+
+// search performs an incremental reverse search through the history, like
+// Ctrl-R in bash. It returns the chosen line and true, or false if the search
+// was cancelled with Ctrl-G or Ctrl-C.
+func (e *lineEditor) search() (string, bool) {
+	var query []rune
+	match := len(e.history)
+	// find searches for the query starting from the line before index i.
+	find := func(i int) {
+		for i--; i >= 0; i-- {
+			if strings.Contains(e.history[i], string(query)) {
+				match = i
+				return
+			}
+		}
+	}
+	for {
+		line := ""
+		if match < len(e.history) {
+			line = e.history[match]
+		}
+		fmt.Fprintf(e.out, "\r(reverse-i-search)`%s': %s\x1b[K",
+			string(query), line)
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return line, true
+		}
+		switch {
+		case r == keyCtrlG || r == keyCtrlC:
+			return "", false
+		case r == keyCtrlR:
+			find(match)
+		case r == keyBackspace || r == keyDelete:
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+				match = len(e.history)
+				find(match)
+			}
+		case r >= ' ':
+			query = append(query, r)
+			// The current match is kept if it still contains the query.
+			if match < len(e.history) {
+				find(match + 1)
+			} else {
+				find(match)
+			}
+		default:
+			// Any other key accepts the match. Enter is put back so that it
+			// also submits the line.
+			if r == keyEnter || r == '\n' {
+				e.in.UnreadRune()
+			}
+			return line, true
+		}
+	}
+}
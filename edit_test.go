@@ -0,0 +1,100 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+)
+
+var editTests = []struct {
+	history []string
+	keys    string
+	line    string
+	err     error
+}{
+	{nil, "abc\r", "abc", nil},
+	{nil, "abc\x02\x02X\r", "aXbc", nil},
+	{nil, "abc\x1b[D\x1b[DX\x1b[FY\r", "aXbcY", nil},
+	{nil, "ab\x7f\x7f\x7fc\r", "c", nil},
+	{nil, "hello\x01\x0b\r", "", nil},
+	{nil, "hello\x02\x02\x15\r", "lo", nil},
+	{nil, "héllo\x02\x02\x02\x02\x04\r", "hllo", nil},
+	{nil, "\x04", "", io.EOF},
+	{nil, "abc", "abc", nil},
+	{nil, "abc\x03xyz\r", "xyz", nil},
+	{[]string{"one", "two"}, "\x10\r", "two", nil},
+	{[]string{"one", "two"}, "\x1b[A\x1b[A!\r", "one!", nil},
+	{[]string{"one", "two"}, "new\x10\x10\x0e\x0e\r", "new", nil},
+	{[]string{"apple", "banana", "apricot"}, "\x12ap\r", "apricot", nil},
+	{[]string{"apple", "banana", "apricot"}, "\x12ap\x12\r", "apple", nil},
+	{[]string{"apple", "banana", "apricot"}, "\x12an\x06!\r", "banana!", nil},
+	{[]string{"apple", "banana", "apricot"}, "x\x12ap\x07\r", "x", nil},
+}
+
+func TestLineEditor(t *testing.T) {
+	for i, test := range editTests {
+		e := &lineEditor{
+			in:      bufio.NewReader(strings.NewReader(test.keys)),
+			out:     io.Discard,
+			history: test.history,
+		}
+		line, err := e.readLine("> ")
+		if line != test.line || err != test.err {
+			t.Errorf("%d. typed %q\nreturned %q and %v\nexpected %q and %v",
+				i, test.keys, line, err, test.line, test.err)
+		}
+	}
+}
+
+var currentArgTests = []struct {
+	line string
+	i    int
+	arg  string
+	raw  int
+}{
+	{"", 0, "", 0},
+	{"ab", 0, "ab", 2},
+	{"ab ", 1, "", 0},
+	{`ab c\ d`, 1, "c d", 4},
+	{`ab 'c d`, 1, "c d", 4},
+	{`ab\ `, 0, "ab ", 4},
+}
+
+func TestCurrentArg(t *testing.T) {
+	for i, test := range currentArgTests {
+		n, arg, raw := currentArg(test.line)
+		if n != test.i || arg != test.arg || raw != test.raw {
+			t.Errorf("%d. currentArg(%#q)\nreturned %d, %q, %d\n"+
+				"expected %d, %q, %d", i, test.line, n, arg, raw, test.i,
+				test.arg, test.raw)
+		}
+	}
+}
+
+func TestComplete(t *testing.T) {
+	defer SetEveryParser(Parser{})
+	SetParsers(Choice("start", "stop", "status"), Choice("a b", "c"))
+	tests := []struct{ keys, line string }{
+		{"sta\t\r", "sta"},
+		{"star\t\r", "start "},
+		{"sto\tx\r", "stop x"},
+		{"start a\t\r", `start a\ b `},
+		{"x\x01s\t\r", "stx"},
+	}
+	for i, test := range tests {
+		e := &lineEditor{
+			in:  bufio.NewReader(strings.NewReader(test.keys)),
+			out: io.Discard,
+			suggest: func(i int, prefix string) []string {
+				return std.parserAt(i).Suggest(prefix)
+			},
+		}
+		if line, _ := e.readLine(""); line != test.line {
+			t.Errorf("%d. typed %q\nreturned %q\nexpected %q", i, test.keys,
+				line, test.line)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"os"
+	"sync"
+)
+
+// exitHandlers are the functions registered with OnExit.
+var (
+	exitMutex    sync.Mutex
+	exitHandlers []func(code int)
+)
+
+// osExit is os.Exit, replaced in tests.
+var osExit = os.Exit
+
+// OnExit registers fn to be called with the exit status before parse ends the
+// program, as it does when the arguments are invalid or a line of input fails.
+// This gives programs a chance to flush caches, close databases, or report
+// telemetry, which deferred calls in fn cannot do since os.Exit skips them.
+// Handlers are called in the reverse order of registration, like deferred
+// calls. They are not called when Main returns normally. Since they concern
+// the whole process, they are shared by every Program.
+func OnExit(fn func(code int)) {
+	exitMutex.Lock()
+	defer exitMutex.Unlock()
+	exitHandlers = append(exitHandlers, fn)
+}
+
+// exit writes the report of r (see SetReport) and then exits the program with
+// the given status. When the program is run by Run or Invoke, it only ends the
+// run.
+func (r *run) exit(code int) {
+	if r.returning {
+		panic(invokeExit(code))
+	}
+	if err := r.writeReport(code); err != nil {
+		r.log.Println(err)
+	}
+	exit(code)
+}
+
+// exit calls the handlers registered with OnExit, removes the temporary
+// directory (see TempDir), releases the lock (see Exclusive), and then exits
+// the program with the given status.
+func exit(code int) {
+	exitMutex.Lock()
+	handlers := exitHandlers
+	exitHandlers = nil
+	exitMutex.Unlock()
+	for i := len(handlers) - 1; i >= 0; i-- {
+		handlers[i](code)
+	}
+	removeTempDir()
+	releaseLock()
+	osExit(code)
+}
+
+// fatal logs err and exits with the runtime status (see SetExitCodes), like
+// log.Fatal, but it calls the handlers registered with OnExit first.
+func (r *run) fatal(err error) {
+	r.noteError("", err)
+	r.log.Println(err)
+	r.exit(r.exitRuntime)
+}
+
+// SetExitCodes sets the exit statuses that the program uses for the three
+// kinds of failure, which are all 1 by default: usage is for the wrong number
+// of command-line arguments, when the usage message is printed; parse is for
+// arguments or lines of input that fail to parse; and runtime is for errors
+// passed to Fail (see HandleErrors), errors reading the input, and other
+// errors that end the program. When both parse and runtime errors occur, the
+// parse status is used. Tools that follow the conventions of sysexits.h can
+// call SetExitCodes(64, 65, 1) to exit with EX_USAGE and EX_DATAERR. The
+// status for contradictory argument declarations (see Validate) is always 70.
+//
+// Deprecated: Use Program.SetExitCodes instead.
+func SetExitCodes(usage, parse, runtime int) {
+	std.SetExitCodes(usage, parse, runtime)
+}
+
+// SetExitCodes is like the package-level SetExitCodes, but for p.
+func (p *Program) SetExitCodes(usage, parse, runtime int) {
+	p.exitUsage, p.exitParse, p.exitRuntime = usage, parse, runtime
+}
+
+// failureCode returns the exit status for a run that has failed, which depends
+// on whether an argument or line of input failed to parse.
+func (r *run) failureCode() int {
+	if r.parseFailed.Load() {
+		return r.exitParse
+	}
+	return r.exitRuntime
+}
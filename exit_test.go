@@ -0,0 +1,105 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// exitCode is panicked by the replacement for os.Exit in tests.
+type exitCode int
+
+// catchExit calls f and returns the status that it tried to exit with, or -1
+// if it did not try to exit.
+func catchExit(f func()) (code int) {
+	defer func(old func(int)) { osExit = old }(osExit)
+	osExit = func(code int) { panic(exitCode(code)) }
+	defer func() {
+		if r := recover(); r != nil {
+			code = int(r.(exitCode))
+		}
+	}()
+	f()
+	return -1
+}
+
+func TestOnExit(t *testing.T) {
+	var calls []string
+	OnExit(func(code int) { calls = append(calls, "first") })
+	OnExit(func(code int) {
+		calls = append(calls, "second")
+		if code != 3 {
+			t.Errorf("handler got status %d, expected 3", code)
+		}
+	})
+	if code := catchExit(func() { exit(3) }); code != 3 {
+		t.Errorf("exited with %d, expected 3", code)
+	}
+	if expected := []string{"second", "first"}; !reflect.DeepEqual(calls,
+		expected) {
+		t.Errorf("handlers were called as %v, expected %v", calls, expected)
+	}
+}
+
+func TestOnExitFatal(t *testing.T) {
+	defer log.SetOutput(os.Stderr)
+	var b bytes.Buffer
+	log.SetOutput(&b)
+	called := false
+	OnExit(func(code int) { called = code == 1 })
+	fatal := func() { std.newRun().fatal(errors.New("boom")) }
+	if code := catchExit(fatal); code != 1 {
+		t.Errorf("exited with %d, expected 1", code)
+	}
+	if !called || !bytes.Contains(b.Bytes(), []byte("boom")) {
+		t.Errorf("handler called: %v, log: %q", called, b.String())
+	}
+}
+
+var exitCodeTests = []struct {
+	args  []string
+	input string
+	code  int
+}{
+	{[]string{"1", "2"}, "", -1},
+	{[]string{"1"}, "", 64},
+	{[]string{"1", "x"}, "", 65},
+	{[]string{"1", "0"}, "", 1},
+	{[]string{}, "1 0\n", 1},
+	{[]string{}, "1 0\n1 x\n2 0\n", 65},
+}
+
+func TestExitCodes(t *testing.T) {
+	defer func(k bool) {
+		SetArgs(nil)
+		SetInput(nil)
+		SetErrorOutput(os.Stderr)
+		SetExitCodes(1, 1, 1)
+		SetEveryParser(Parser{})
+		SetKeepGoing(k)
+	}(std.keepGoing)
+	SetParsers(Int, Int)
+	SetExitCodes(64, 65, 1)
+	SetErrorOutput(nil)
+	SetKeepGoing(true)
+	fn := HandleErrors(func(args []interface{}) error {
+		if args[1] == 0 {
+			return errors.New("division by zero")
+		}
+		return nil
+	})
+	for i, test := range exitCodeTests {
+		SetArgs(test.args)
+		SetInput(strings.NewReader(test.input))
+		if code := catchExit(func() { Main(fn) }); code != test.code {
+			t.Errorf("%d. Main with %q and %q exited with %d, expected %d",
+				i, test.args, test.input, code, test.code)
+		}
+	}
+}
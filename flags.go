@@ -0,0 +1,216 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// builtinFlags maps the names of the flags that parse handles itself to the
+// functions that carry them out. They give every program that uses parse the
+// same control over its behavior without any extra code, once it turns them
+// on with SetBuiltinFlags.
+var builtinFlags = map[string]func(p *Program){
+	"-k":                   func(p *Program) { p.keepGoing = true },
+	"--keep-going":         func(p *Program) { p.keepGoing = true },
+	"--fail-fast":          func(p *Program) { p.keepGoing = false },
+	"--input-format=shell": func(p *Program) { p.inputFormat = Shell },
+	"--input-format=csv":   func(p *Program) { p.inputFormat = CSV },
+	"--input-format=tsv":   func(p *Program) { p.inputFormat = TSV },
+	"--input-format=json":  func(p *Program) { p.inputFormat = JSONLines },
+	"--input-format=auto":  func(p *Program) { p.inputFormat = Auto },
+	// Hidden flags, which are not meant to be used directly by people.
+	"--schema=json": func(p *Program) { p.schemaMode = true },
+	"--bench":       func(p *Program) { p.benchMode = true },
+	"--types":       func(p *Program) { p.typesMode = true },
+	"--minimize":    func(p *Program) { p.minimizeMode = true },
+}
+
+// builtinValueFlags is like builtinFlags, but for flags that take a value after
+// an equals sign, such as "--jobs=4". The functions return false if the value
+// is invalid, in which case the flag is not recognized.
+var builtinValueFlags = map[string]func(p *Program, v string) bool{
+	"--jobs": func(p *Program, v string) bool {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return false
+		}
+		p.jobsFlag = n
+		return true
+	},
+	"--reference": func(p *Program, v string) bool {
+		tokens := tokenize([]byte(v)).strings()
+		if len(tokens) == 0 {
+			return false
+		}
+		p.reference = tokens
+		return true
+	},
+}
+
+// stripFlags carries out the built-in flags at the beginning of args, changing
+// the settings of p, and returns the remaining arguments. Flags are only
+// recognized before the first argument that is not one, and "--" explicitly
+// ends them, so that arguments such as "-k" can still be passed to the program
+// as "-- -k". Runs call it on their own copy of the program's settings, so
+// that the flags only apply to that run.
+func (p *Program) stripFlags(args []string) []string {
+	for i, arg := range args {
+		if arg == "--" {
+			return args[i+1:]
+		}
+		if f, ok := builtinFlags[arg]; ok && p.builtinFlagsOn {
+			f(p)
+			continue
+		}
+		if l, ok := verbosityFlags[arg]; ok && p.verbosityFlagsOn {
+			p.verbosity = l
+			continue
+		}
+		if r, ok := reductionFlags[arg]; ok && p.numeric() {
+			p.reduction = r
+			continue
+		}
+		name, value, _ := strings.Cut(arg, "=")
+		f, ok := builtinValueFlags[name]
+		if !ok || !p.builtinFlagsOn || !f(p, value) {
+			return args[i:]
+		}
+	}
+	return nil
+}
+
+// SetKeepGoing sets the default for whether the program keeps processing lines
+// of standard input after one of them fails to parse. It is true by default.
+// The user can override it with the built-in flags "-k" or "--keep-going" and
+// "--fail-fast" (see SetBuiltinFlags).
+//
+// Deprecated: Use Program.SetKeepGoing instead.
+func SetKeepGoing(b bool) {
+	std.SetKeepGoing(b)
+}
+
+// SetKeepGoing is like the package-level SetKeepGoing, but for p.
+func (p *Program) SetKeepGoing(b bool) {
+	p.keepGoing = b
+}
+
+// A Level is a verbosity level, which controls how much parse prints about the
+// lines that it reads from standard input.
+type Level int
+
+const (
+	// Quiet suppresses error messages for individual lines. The exit status
+	// still indicates whether any of them failed.
+	Quiet Level = iota - 1
+	// Normal prints an error message for each line that fails.
+	Normal
+	// Verbose also prints each line's arguments before they are parsed, and
+	// a count of the lines that were processed, failed, and skipped (see
+	// Skip) at the end of the input.
+	Verbose
+)
+
+// Verbosity returns the verbosity level chosen by the user with the built-in
+// flags "-q" or "--quiet" and "-v" or "--verbose" (see SetVerbosityFlags). It
+// is Normal by default. Programs can use it to adjust how much they print
+// themselves. Called from fn, it returns the level for the run that called fn.
+func Verbosity() Level {
+	return active().verbosity
+}
+
+// verbosityFlags maps the built-in flags that set the verbosity level to it.
+var verbosityFlags = map[string]Level{
+	"-q":        Quiet,
+	"--quiet":   Quiet,
+	"-v":        Verbose,
+	"--verbose": Verbose,
+}
+
+// SetVerbosityFlags sets whether the built-in flags "-q" or "--quiet" and "-v"
+// or "--verbose" are recognized, letting the user choose the verbosity level
+// (see Verbosity). It is false by default, so that programs that take
+// arguments like "-v" receive them.
+//
+// Deprecated: Use Program.SetVerbosityFlags instead.
+func SetVerbosityFlags(on bool) {
+	std.SetVerbosityFlags(on)
+}
+
+// SetVerbosityFlags is like the package-level SetVerbosityFlags, but for p.
+func (p *Program) SetVerbosityFlags(on bool) {
+	p.verbosityFlagsOn = on
+}
+
+// SetBuiltinFlags sets whether the built-in flags other than the verbosity
+// flags (see SetVerbosityFlags) are recognized: "-k" or "--keep-going" and
+// "--fail-fast" (see SetKeepGoing), "--input-format" (see SetInputFormat),
+// "--jobs" (see SetJobs), "--reference", which compares the output with that
+// of a command given as its value, and the hidden flags used by tools, such as
+// "--schema=json" (see Schema). It is false by default, so that programs that
+// take arguments like "-k" receive them, and so that the user cannot make a
+// program run a command that it never meant to run.
+//
+// Deprecated: Use Program.SetBuiltinFlags instead.
+func SetBuiltinFlags(on bool) {
+	std.SetBuiltinFlags(on)
+}
+
+// SetBuiltinFlags is like the package-level SetBuiltinFlags, but for p.
+func (p *Program) SetBuiltinFlags(on bool) {
+	p.builtinFlagsOn = on
+}
+
+// SetArgs makes the program use args as its command-line arguments instead of
+// os.Args, not including the program name, so that tests can run the whole of
+// Main in-process. The built-in flags and the environment variable named after
+// the program still apply. If args is nil, which is the default, os.Args is
+// used.
+//
+// Deprecated: Use Program.SetArgs instead.
+func SetArgs(args []string) {
+	std.SetArgs(args)
+}
+
+// SetArgs is like the package-level SetArgs, but for p.
+func (p *Program) SetArgs(args []string) {
+	if args != nil {
+		args = append([]string{}, args...)
+	}
+	p.commandLineOverride = args
+}
+
+// commandLine returns the command-line arguments, without the program name.
+func (p *Program) commandLine() []string {
+	if p.commandLineOverride != nil {
+		return p.commandLineOverride
+	}
+	return os.Args[1:]
+}
+
+// optsVar returns the name of the environment variable that holds default
+// arguments for the program. It is the program name in upper case followed by
+// "_OPTS", with characters other than letters and digits replaced by
+// underscores. For example, it is SLEEP_OPTS for a program named sleep.
+func (p *Program) optsVar() string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, p.name)
+	return name + "_OPTS"
+}
+
+// envArgs returns the tokens in the environment variable named by optsVar,
+// tokenized in the same way as lines of standard input.
+func (p *Program) envArgs() []string {
+	opts := os.Getenv(p.optsVar())
+	if opts == "" {
+		return nil
+	}
+	return tokenize([]byte(opts)).strings()
+}
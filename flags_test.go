@@ -0,0 +1,101 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+var stripFlagsTests = []struct {
+	args      []string
+	rest      []string
+	keepGoing bool
+}{
+	{nil, nil, true},
+	{[]string{"a", "-k"}, []string{"a", "-k"}, true},
+	{[]string{"--fail-fast"}, nil, false},
+	{[]string{"--fail-fast", "-k", "1", "2"}, []string{"1", "2"}, true},
+	{[]string{"--fail-fast", "--", "-k"}, []string{"-k"}, false},
+	{[]string{"-k", "-", "--"}, []string{"-", "--"}, true},
+}
+
+func TestStripFlags(t *testing.T) {
+	defer SetKeepGoing(true)
+	defer SetBuiltinFlags(false)
+	rest := std.stripFlags([]string{"--fail-fast", "--jobs=2"})
+	if len(rest) != 2 || !std.keepGoing || std.jobsFlag != 0 {
+		t.Errorf("built-in flags were recognized without SetBuiltinFlags")
+	}
+	SetBuiltinFlags(true)
+	for i, test := range stripFlagsTests {
+		SetKeepGoing(true)
+		rest = std.stripFlags(test.args)
+		if len(rest) == 0 && len(test.rest) == 0 {
+			rest = test.rest
+		}
+		if !reflect.DeepEqual(rest, test.rest) ||
+			std.keepGoing != test.keepGoing {
+			t.Errorf("%d. stripFlags(%q)\nreturned %q with keepGoing = %t\n"+
+				"expected %q with keepGoing = %t", i, test.args, rest,
+				std.keepGoing, test.rest, test.keepGoing)
+		}
+	}
+}
+
+func TestVerbosityFlags(t *testing.T) {
+	defer func() { std.verbosity = Normal }()
+	defer SetVerbosityFlags(false)
+	if rest := std.stripFlags([]string{"-v"}); len(rest) != 1 ||
+		Verbosity() != Normal {
+		t.Errorf("-v was recognized without SetVerbosityFlags")
+	}
+	SetVerbosityFlags(true)
+	rest := std.stripFlags([]string{"-v", "--quiet", "x"})
+	if Verbosity() != Quiet || !reflect.DeepEqual(rest, []string{"x"}) {
+		t.Errorf("after -v --quiet: Verbosity() = %d, rest = %q", Verbosity(),
+			rest)
+	}
+	std.stripFlags([]string{"--verbose"})
+	if Verbosity() != Verbose {
+		t.Errorf("after --verbose: Verbosity() = %d", Verbosity())
+	}
+}
+
+func TestJobsFlag(t *testing.T) {
+	defer func() {
+		SetJobs(1)
+		std.jobsFlag = 0
+		SetBuiltinFlags(false)
+	}()
+	SetBuiltinFlags(true)
+	rest := std.stripFlags([]string{"--jobs=4", "--jobs=x", "y"})
+	if std.jobsFlag != 4 ||
+		!reflect.DeepEqual(rest, []string{"--jobs=x", "y"}) {
+		t.Errorf("after --jobs=4 --jobs=x: jobs = %d, rest = %q", std.jobsFlag,
+			rest)
+	}
+	if std.jobLimit() != 1 {
+		t.Errorf("--jobs=4 raised the limit to %d", std.jobLimit())
+	}
+	SetJobs(8)
+	if std.jobLimit() != 4 {
+		t.Errorf("--jobs=4 with SetJobs(8) gave the limit %d", std.jobLimit())
+	}
+}
+
+func TestEnvArgs(t *testing.T) {
+	defer func(name string) { std.name = name }(std.name)
+	std.name = "my-tool.v2"
+	if v := std.optsVar(); v != "MY_TOOL_V2_OPTS" {
+		t.Fatalf("optsVar() = %q\nexpected %q", v, "MY_TOOL_V2_OPTS")
+	}
+	os.Setenv("MY_TOOL_V2_OPTS", `-k 'a b' c\ d`)
+	defer os.Unsetenv("MY_TOOL_V2_OPTS")
+	args := std.envArgs()
+	if expected := []string{"-k", "a b", "c d"}; !reflect.DeepEqual(args,
+		expected) {
+		t.Errorf("envArgs() = %q\nexpected %q", args, expected)
+	}
+}
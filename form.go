@@ -0,0 +1,181 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+)
+
+// A FormFormat is a format in which GenerateForm can describe the arguments.
+type FormFormat int
+
+const (
+	// HTMLForm is an HTML form with one field for each argument.
+	HTMLForm FormFormat = iota
+	// JSONSchema is a JSON Schema for an object with one property for each
+	// argument.
+	JSONSchema
+)
+
+// GenerateForm writes a description of the program's arguments to w in the
+// given format, based on CurrentSchema. It makes it easy to wrap a simple web
+// front-end around a program that uses parse. The fields of the HTML form and
+// the properties of the JSON Schema are named after the arguments, and in
+// repeat mode the repeated argument has one field that takes values separated
+// by spaces.
+//
+// Deprecated: Use Program.GenerateForm instead.
+func GenerateForm(w io.Writer, format FormFormat) error {
+	return std.GenerateForm(w, format)
+}
+
+// GenerateForm is like the package-level GenerateForm, but for p.
+func (p *Program) GenerateForm(w io.Writer, format FormFormat) error {
+	s := p.CurrentSchema()
+	switch format {
+	case HTMLForm:
+		return formTemplate.Execute(w, formFields(s))
+	case JSONSchema:
+		data, err := json.MarshalIndent(p.jsonSchema(s), "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(data, '\n'))
+		return err
+	}
+	return fmt.Errorf("parse: unknown form format %d", format)
+}
+
+// A formField holds the information needed to show an argument in an HTML form.
+type formField struct {
+	Name        string
+	InputType   string // the type attribute of the input element
+	Step        string // the step attribute, for numbers
+	Placeholder string
+	Value       string
+	Required    bool
+	Choices     []formChoice // if not nil, a select element is used
+	Description string
+}
+
+// A formChoice is an option of a select element in an HTML form.
+type formChoice struct {
+	Value    string
+	Selected bool
+}
+
+// formFields converts the arguments in s to fields of an HTML form.
+func formFields(s Schema) []formField {
+	fields := make([]formField, len(s.Arguments))
+	for i, arg := range s.Arguments {
+		rest, only := arg.Repeated, len(s.Arguments) == 1
+		f := formField{
+			Name:        arg.Name,
+			InputType:   "text",
+			Placeholder: arg.Example,
+			Required:    arg.Default == nil && (!rest || only),
+			Description: arg.Description,
+		}
+		if arg.Default != nil {
+			f.Value = *arg.Default
+		}
+		if !rest && arg.Type == "int" {
+			f.InputType = "number"
+		}
+		if !rest && arg.Type == "float64" {
+			f.InputType, f.Step = "number", "any"
+		}
+		if !rest && arg.Choices != nil {
+			f.Choices = make([]formChoice, len(arg.Choices))
+			for j, c := range arg.Choices {
+				f.Choices[j] = formChoice{c, c == f.Value}
+			}
+		}
+		fields[i] = f
+	}
+	return fields
+}
+
+// formTemplate is the template for HTMLForm.
+var formTemplate = template.Must(template.New("form").Parse(`<form method="get">
+{{- range .}}
+  <label>{{.Name}}
+  {{- if .Choices}}
+    <select name="{{.Name}}">
+    {{- range .Choices}}
+      <option{{if .Selected}} selected{{end}}>{{.Value}}</option>
+    {{- end}}
+    </select>
+  {{- else}}
+    <input name="{{.Name}}" type="{{.InputType}}"
+    {{- if .Step}} step="{{.Step}}"{{end}}
+    {{- if .Placeholder}} placeholder="{{.Placeholder}}"{{end}}
+    {{- if .Value}} value="{{.Value}}"{{end}}
+    {{- if .Required}} required{{end}}>
+  {{- end}}
+  </label>
+  {{- if .Description}}
+  <small>{{.Description}}</small>
+  {{- end}}
+{{- end}}
+  <input type="submit">
+</form>
+`))
+
+// jsonSchema converts s, the schema of p, to a JSON Schema.
+func (p *Program) jsonSchema(s Schema) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := []string{}
+	for i, arg := range s.Arguments {
+		prop := map[string]interface{}{"type": jsonType(arg.Type)}
+		if arg.Description != "" {
+			prop["description"] = arg.Description
+		}
+		if arg.Choices != nil && arg.Type != "bool" {
+			prop["enum"] = arg.Choices
+		}
+		if arg.Example != "" {
+			prop["examples"] = []string{arg.Example}
+		}
+		// The repeated argument can be empty unless it is the only one.
+		rest, only := arg.Repeated, len(s.Arguments) == 1
+		if arg.Default != nil {
+			// Use the parsed default so that it has the right JSON type.
+			if v, err := p.parsers[i].Parse(*arg.Default); err == nil {
+				prop["default"] = v
+			}
+		} else if !rest || only {
+			required = append(required, arg.Name)
+		}
+		if rest {
+			prop = map[string]interface{}{"type": "array", "items": prop}
+			if only {
+				prop["minItems"] = 1
+			}
+		}
+		properties[arg.Name] = prop
+	}
+	return map[string]interface{}{
+		"$schema":    "https://json-schema.org/draft/2020-12/schema",
+		"title":      s.Program,
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// jsonType returns the JSON Schema type for an argument type in a Schema.
+func jsonType(typ string) string {
+	switch typ {
+	case "int":
+		return "integer"
+	case "float64":
+		return "number"
+	case "bool":
+		return "boolean"
+	}
+	return "string"
+}
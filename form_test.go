@@ -0,0 +1,82 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGenerateForm(t *testing.T) {
+	defer func(name string) {
+		std.name = name
+		SetEveryParser(Parser{})
+		SetNames()
+	}(std.name)
+	std.name = "sleep"
+	SetParsers(Float64.Help("seconds to sleep").Example("1.5"),
+		Choice("s", "m").Default("s"), Int.Default("3"))
+	SetNames("duration", "unit", "times")
+	tests := []struct {
+		format   FormFormat
+		expected string
+	}{
+		{HTMLForm, `<form method="get">
+  <label>duration
+    <input name="duration" type="number" step="any" placeholder="1.5" required>
+  </label>
+  <small>seconds to sleep</small>
+  <label>unit
+    <select name="unit">
+      <option selected>s</option>
+      <option>m</option>
+    </select>
+  </label>
+  <label>times
+    <input name="times" type="number" value="3">
+  </label>
+  <input type="submit">
+</form>
+`},
+		{JSONSchema, `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "properties": {
+    "duration": {
+      "description": "seconds to sleep",
+      "examples": [
+        "1.5"
+      ],
+      "type": "number"
+    },
+    "times": {
+      "default": 3,
+      "type": "integer"
+    },
+    "unit": {
+      "default": "s",
+      "enum": [
+        "s",
+        "m"
+      ],
+      "type": "string"
+    }
+  },
+  "required": [
+    "duration"
+  ],
+  "title": "sleep",
+  "type": "object"
+}
+`},
+	}
+	for i, test := range tests {
+		var b bytes.Buffer
+		if err := GenerateForm(&b, test.format); err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != test.expected {
+			t.Errorf("%d. GenerateForm wrote\n%s\nexpected\n%s", i, b.String(),
+				test.expected)
+		}
+	}
+}
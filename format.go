@@ -0,0 +1,695 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// An InputFormat is a format for lines of input, which determines how they
+// are split into tokens.
+type InputFormat int
+
+const (
+	// Shell splits lines on whitespace, with quoting and escaping similar to
+	// shells. It is the default.
+	Shell InputFormat = iota
+	// CSV reads comma-separated values as described in RFC 4180.
+	CSV
+	// TSV reads tab-separated values.
+	TSV
+	// JSONLines reads one JSON value per line. An array provides the arguments
+	// in order, and an object provides them by name (see SetNames). Strings
+	// are used as they are, null becomes an empty string, and other values
+	// are used as JSON text.
+	JSONLines
+	// Auto chooses one of the other formats by looking at the first line.
+	Auto
+	// FixedWidth splits lines into fields of fixed widths. Use SetFixedWidths
+	// rather than setting it directly.
+	FixedWidth
+	// Binary reads length-prefixed binary records instead of lines. Use
+	// SetBinaryRecords rather than setting it directly.
+	Binary
+	// Gob reads a stream of gob-encoded values. Use SetGobRecords rather than
+	// setting it directly.
+	Gob
+	// MessagePack reads a stream of MessagePack values. An array provides the
+	// arguments in order, and a map provides them by name, as in JSONLines.
+	MessagePack
+)
+
+// SetInputFormat sets the format of lines read from standard input and from
+// files. It is Shell by default. With Auto, the format is chosen based on the
+// first line: JSONLines if it begins with "[" or "{", TSV if it contains tabs,
+// CSV if it contains commas, and Shell otherwise. The user can override the
+// format with the built-in flag "--input-format" followed by "=shell", "=csv",
+// "=tsv", "=json", or "=auto" (see SetBuiltinFlags).
+//
+// Deprecated: Use Program.SetInputFormat instead.
+func SetInputFormat(format InputFormat) {
+	std.SetInputFormat(format)
+}
+
+// SetInputFormat is like the package-level SetInputFormat, but for p.
+func (p *Program) SetInputFormat(format InputFormat) {
+	p.inputFormat = format
+}
+
+// A record is the list of tokens obtained from a single line of input. If the
+// line had more tokens than the line limit allows, rest holds the text starting
+// at the first extra token. For line-based formats, raw may hold the line
+// itself (for writeReject), which is only valid until the next record is read.
+type record struct {
+	tokens []string
+	rest   []byte
+	extra  map[string]string // unused columns, for HeaderWithRest
+	err    error             // problem with this record only, such as decoding
+	raw    []byte            // the line it came from, if there is one
+	n      int               // its line or position, if not its index
+}
+
+// A recordReader reads records from an input in a particular format.
+type recordReader interface {
+	// next returns the next record, or io.EOF if there are no more.
+	next() (record, error)
+}
+
+// newRecordReader returns a recordReader for in, in the input format of r.
+func (r *run) newRecordReader(in io.Reader) recordReader {
+	p := &r.Program
+	records := p.newFormatReader(r.withContext(in))
+	if p.tee != nil {
+		records = teeRecords{p, records}
+	}
+	if p.maxTokens > 0 {
+		records = maxTokensRecords{p, records}
+	}
+	if p.utf8Policy != AllowInvalidUTF8 {
+		records = &utf8Records{source: records, policy: p.utf8Policy}
+	}
+	if p.shuffleSeed != 0 && r.source != InteractiveSource {
+		records = &shuffleRecords{seed: p.shuffleSeed, source: records}
+	}
+	if r.ctx != nil {
+		records = contextRecords{r.ctx, records}
+	}
+	return records
+}
+
+// newFormatReader is like newRecordReader, but it does not enforce maxTokens.
+func (p *Program) newFormatReader(r io.Reader) recordReader {
+	format := p.inputFormat
+	if m, ok := r.(*mapping); ok && p.splitter == nil {
+		if format == Auto {
+			line, _, _ := bytes.Cut(m.data, []byte("\n"))
+			format = sniffLine(line)
+		}
+		if format == Shell {
+			return p.withColumns(&mappedRecords{p: p, data: m.data})
+		}
+	}
+	br := bufio.NewReader(r)
+	if format == Auto {
+		format = sniffFormat(br)
+	}
+	switch format {
+	case CSV:
+		cr := csv.NewReader(br)
+		cr.FieldsPerRecord = -1
+		return p.withHeader(p.withColumns(csvRecords{p, cr}))
+	case TSV:
+		return p.withHeader(p.withColumns(tsvRecords{p, p.newScanner(br)}))
+	case JSONLines:
+		return jsonRecords{p, p.newScanner(br)}
+	case FixedWidth:
+		return p.withColumns(fixedRecords{p, p.newScanner(br)})
+	case Binary:
+		return binaryRecords{p, br}
+	case Gob:
+		return gobRecords{p, gob.NewDecoder(br)}
+	case MessagePack:
+		return msgpackRecords{p, br}
+	}
+	return p.withColumns(shellRecords{p, p.newLineScanner(br)})
+}
+
+// sniffFormat looks at the first line of br, without consuming it, to decide
+// which format it is in.
+func sniffFormat(br *bufio.Reader) InputFormat {
+	var line []byte
+	for n := 1; ; n = br.Buffered() + 1 {
+		data, err := br.Peek(n)
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			line = data[:i]
+			break
+		}
+		if err != nil {
+			line = data
+			break
+		}
+	}
+	return sniffLine(line)
+}
+
+// sniffLine decides which format the first line of input is in.
+func sniffLine(line []byte) InputFormat {
+	line = bytes.TrimSpace(line)
+	switch {
+	case len(line) > 0 && (line[0] == '[' || line[0] == '{'):
+		return JSONLines
+	case bytes.IndexByte(line, '\t') >= 0:
+		return TSV
+	case bytes.IndexByte(line, ',') >= 0:
+		return CSV
+	}
+	return Shell
+}
+
+// limit returns the number of tokens allowed per line, or -1 for no limit.
+func (p *Program) limit() int {
+	if p.repeat && p.lineLimit > 0 && p.columns == nil {
+		return p.lineLimit
+	}
+	return -1
+}
+
+// limitFields makes a record from fields, joining those beyond the limit with
+// sep to form the rest of the record.
+func (p *Program) limitFields(fields []string, sep string) record {
+	n := p.limit()
+	if n < 0 || len(fields) <= n || p.headerMode != NoHeader {
+		return record{tokens: fields}
+	}
+	rest := []byte(strings.Join(fields[n:], sep))
+	return record{tokens: fields[:n], rest: rest}
+}
+
+// shellRecords reads records in the Shell format.
+type shellRecords struct {
+	p       *Program
+	scanner *bufio.Scanner
+}
+
+func (r shellRecords) next() (record, error) {
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return record{}, err
+		}
+		return record{}, io.EOF
+	}
+	return r.p.shellRecord(r.scanner.Bytes()), nil
+}
+
+// shellRecord makes a record from a line in the Shell format, using the custom
+// Tokenizer if one has been installed.
+func (p *Program) shellRecord(line []byte) record {
+	// Tokenizing unquotes in place, so the line must be copied first.
+	var raw []byte
+	if p.keepRaw() {
+		raw = append([]byte(nil), line...)
+	}
+	if p.tokenizer != nil {
+		fields, err := p.tokenizer.Tokenize(line)
+		if err != nil {
+			return record{err: err, raw: raw}
+		}
+		rec := p.limitFields(fields, " ")
+		rec.raw = raw
+		return rec
+	}
+	n, capped := p.limit(), false
+	if p.maxTokens > 0 && (n < 0 || n > p.maxTokens) {
+		// Stop early rather than allocating memory for all the tokens.
+		n, capped = p.maxTokens, true
+	}
+	tokens, rest := tokenizeN(line, n)
+	if capped && rest != nil {
+		return record{err: p.errMaxTokens(), raw: raw}
+	}
+	return record{tokens: tokens.strings(), rest: rest, raw: raw}
+}
+
+// csvRecords reads records in the CSV format.
+type csvRecords struct {
+	p      *Program
+	reader *csv.Reader
+}
+
+func (r csvRecords) next() (record, error) {
+	fields, err := r.reader.Read()
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		// The reader carries on from the next line, so only this one fails.
+		return record{err: err, n: parseErr.StartLine}, nil
+	}
+	if err != nil {
+		return record{}, err
+	}
+	rec := r.p.limitFields(fields, ",")
+	// A quoted field can span several lines, so number the record by the
+	// line it starts on rather than by its position.
+	rec.n, _ = r.reader.FieldPos(0)
+	return rec, nil
+}
+
+// tsvRecords reads records in the TSV format. Unlike CSV, there is no quoting,
+// so fields cannot contain tabs or newlines.
+type tsvRecords struct {
+	p       *Program
+	scanner *bufio.Scanner
+}
+
+func (r tsvRecords) next() (record, error) {
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return record{}, err
+		}
+		return record{}, io.EOF
+	}
+	line := strings.TrimSuffix(r.scanner.Text(), "\r")
+	if line == "" {
+		return record{tokens: []string{}, raw: r.scanner.Bytes()}, nil
+	}
+	var fields []string
+	if r.p.maxTokens > 0 {
+		// Split at most one field beyond the maximum, to detect it cheaply.
+		fields = strings.SplitN(line, "\t", r.p.maxTokens+1)
+	} else {
+		fields = strings.Split(line, "\t")
+	}
+	rec := r.p.limitFields(fields, "\t")
+	rec.raw = r.scanner.Bytes()
+	return rec, nil
+}
+
+// jsonRecords reads records in the JSONLines format.
+type jsonRecords struct {
+	p       *Program
+	scanner *bufio.Scanner
+}
+
+func (r jsonRecords) next() (record, error) {
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return record{}, err
+		}
+		return record{}, io.EOF
+	}
+	line := bytes.TrimSpace(r.scanner.Bytes())
+	if len(line) == 0 {
+		return record{tokens: []string{}, raw: r.scanner.Bytes()}, nil
+	}
+	var fields []string
+	var err error
+	if line[0] == '{' {
+		fields, err = r.p.jsonObjectFields(line)
+	} else {
+		fields, err = jsonArrayFields(line)
+	}
+	if err != nil {
+		return record{err: err, raw: r.scanner.Bytes()}, nil
+	}
+	rec := r.p.limitFields(fields, " ")
+	rec.raw = r.scanner.Bytes()
+	return rec, nil
+}
+
+// jsonArrayFields converts a JSON array to tokens.
+func jsonArrayFields(line []byte) ([]string, error) {
+	var values []json.RawMessage
+	if err := json.Unmarshal(line, &values); err != nil {
+		return nil, fmt.Errorf("invalid JSON line: %s", err)
+	}
+	fields := make([]string, len(values))
+	for i, v := range values {
+		fields[i] = jsonField(v)
+	}
+	return fields, nil
+}
+
+// jsonObjectFields converts a JSON object to tokens, taking the values of the
+// members named after the arguments in order. In repeat mode, the member named
+// after the repeated argument can be an array of values.
+func (p *Program) jsonObjectFields(line []byte) ([]string, error) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(line, &obj); err != nil {
+		return nil, fmt.Errorf("invalid JSON line: %s", err)
+	}
+	var fields []string
+	if p.repeat {
+		fields = []string{}
+	}
+	for i := range p.parsers {
+		v, ok := obj[p.argName(i)]
+		if !ok {
+			break
+		}
+		if p.isRest(i) && bytes.HasPrefix(v, []byte("[")) {
+			rest, err := jsonArrayFields(v)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, rest...)
+			continue
+		}
+		fields = append(fields, jsonField(v))
+	}
+	return fields, nil
+}
+
+// jsonField converts a JSON value to a token. Strings are unquoted, null
+// becomes an empty string, and other values are left as JSON text.
+func jsonField(v json.RawMessage) string {
+	var s string
+	if json.Unmarshal(v, &s) == nil {
+		return s
+	}
+	return string(v)
+}
+
+// A HeaderMode determines whether the first line of CSV or TSV input is a
+// header that names the columns.
+type HeaderMode int
+
+const (
+	// NoHeader treats the first line like any other. It is the default.
+	NoHeader HeaderMode = iota
+	// Header treats the first line as a header. Each argument is taken from
+	// the column with the same name (see SetNames) rather than by position,
+	// and other columns are ignored. In repeat mode, the header is skipped
+	// and all columns are used. The line limit does not apply.
+	Header
+	// HeaderWithRest is like Header, but the columns that are not used for
+	// arguments are passed to fn after the arguments, as a map[string]string
+	// from column names to values.
+	HeaderWithRest
+)
+
+// SetHeader sets whether the first line of input in the CSV and TSV formats is
+// a header, and what happens to the columns that are not used for arguments.
+//
+// Deprecated: Use Program.SetHeader instead.
+func SetHeader(mode HeaderMode) {
+	std.SetHeader(mode)
+}
+
+// SetHeader is like the package-level SetHeader, but for p.
+func (p *Program) SetHeader(mode HeaderMode) {
+	p.headerMode = mode
+}
+
+// headerRecords reads records with a header from another recordReader.
+type headerRecords struct {
+	p       *Program
+	source  recordReader
+	header  []string
+	columns []int // index of the column for each argument
+}
+
+// withHeader wraps r in a headerRecords if the header mode of p requires it.
+func (p *Program) withHeader(r recordReader) recordReader {
+	if p.headerMode == NoHeader {
+		return r
+	}
+	return &headerRecords{p: p, source: r}
+}
+
+// readHeader reads the header and finds the columns for the arguments.
+func (r *headerRecords) readHeader() error {
+	rec, err := r.source.next()
+	if err != nil {
+		return err
+	}
+	r.header = rec.tokens
+	p := r.p
+	if p.repeat {
+		return nil
+	}
+	index := make(map[string]int, len(r.header))
+	for i, name := range r.header {
+		index[name] = i
+	}
+	for i := range p.parsers {
+		col, ok := index[p.argName(i)]
+		if !ok {
+			if i < p.minArgs() {
+				return fmt.Errorf("missing column %q", p.argName(i))
+			}
+			break
+		}
+		r.columns = append(r.columns, col)
+	}
+	return nil
+}
+
+func (r *headerRecords) next() (record, error) {
+	if r.header == nil {
+		if err := r.readHeader(); err != nil {
+			return record{}, err
+		}
+	}
+	rec, err := r.source.next()
+	if err != nil || r.p.repeat {
+		return rec, err
+	}
+	fields := rec.tokens
+	tokens := make([]string, 0, len(r.columns))
+	used := make(map[int]bool, len(r.columns))
+	for _, col := range r.columns {
+		if col >= len(fields) {
+			break
+		}
+		tokens = append(tokens, fields[col])
+		used[col] = true
+	}
+	var extra map[string]string
+	if r.p.headerMode == HeaderWithRest {
+		extra = make(map[string]string)
+		for i, name := range r.header {
+			if !used[i] && i < len(fields) {
+				extra[name] = fields[i]
+			}
+		}
+	}
+	return record{tokens: tokens, extra: extra, raw: rec.raw}, nil
+}
+
+// SetColumns makes the program use only the given fields of each line, in the
+// given order, where fields are numbered from 1. For example, SetColumns(3, 1)
+// turns the line "a b c d" into the arguments "c" and "a". A line that does not
+// have all the fields gets the ones it has, in order, up to the first missing
+// one. This applies to the Shell, CSV, and TSV formats, and it takes place
+// before the header (see SetHeader) is read. The line limit (see SetLineLimit)
+// does not apply when columns are selected. Calling SetColumns with no
+// arguments makes the program use all fields again.
+//
+// Deprecated: Use Program.SetColumns instead.
+func SetColumns(fields ...int) {
+	std.SetColumns(fields...)
+}
+
+// SetColumns is like the package-level SetColumns, but for p.
+func (p *Program) SetColumns(fields ...int) {
+	if len(fields) == 0 {
+		fields = nil
+	}
+	p.columns = fields
+}
+
+// columnRecords selects the columns of p from the records of another
+// recordReader.
+type columnRecords struct {
+	p      *Program
+	source recordReader
+}
+
+// withColumns wraps r in a columnRecords if columns have been selected.
+func (p *Program) withColumns(r recordReader) recordReader {
+	if p.columns == nil {
+		return r
+	}
+	return columnRecords{p, r}
+}
+
+func (r columnRecords) next() (record, error) {
+	rec, err := r.source.next()
+	if err != nil || rec.err != nil {
+		return rec, err
+	}
+	tokens := make([]string, 0, len(r.p.columns))
+	for _, f := range r.p.columns {
+		if f < 1 || f > len(rec.tokens) {
+			break
+		}
+		tokens = append(tokens, rec.tokens[f-1])
+	}
+	rec.tokens = tokens
+	return rec, nil
+}
+
+// SetFixedWidths sets the input format to FixedWidth, with fields of the given
+// widths in characters (runes, not bytes). Each field has surrounding spaces
+// trimmed before it is parsed. A line that ends partway through the fields
+// gets only the ones it reaches, and text after the last field is ignored.
+//
+// Deprecated: Use Program.SetFixedWidths instead.
+func SetFixedWidths(fieldWidths ...int) {
+	std.SetFixedWidths(fieldWidths...)
+}
+
+// SetFixedWidths is like the package-level SetFixedWidths, but for p.
+func (p *Program) SetFixedWidths(fieldWidths ...int) {
+	p.widths = fieldWidths
+	p.inputFormat = FixedWidth
+}
+
+// fixedRecords reads records in the FixedWidth format.
+type fixedRecords struct {
+	p       *Program
+	scanner *bufio.Scanner
+}
+
+func (r fixedRecords) next() (record, error) {
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return record{}, err
+		}
+		return record{}, io.EOF
+	}
+	line := []rune(strings.TrimSuffix(r.scanner.Text(), "\r"))
+	fields := []string{}
+	for _, w := range r.p.widths {
+		if len(line) == 0 {
+			break
+		}
+		if w > len(line) {
+			w = len(line)
+		}
+		fields = append(fields, strings.TrimSpace(string(line[:w])))
+		line = line[w:]
+	}
+	return record{tokens: fields, raw: r.scanner.Bytes()}, nil
+}
+
+// A LengthPrefix is a way of encoding the length of a binary record.
+type LengthPrefix int
+
+const (
+	// Varint is an unsigned varint, as in encoding/binary and protocol
+	// buffers' length-delimited streams.
+	Varint LengthPrefix = iota
+	// Uint32 is a 4-byte unsigned integer in big-endian byte order.
+	Uint32
+)
+
+// maxRecordSize is the largest binary record that will be read, so that a
+// corrupt length prefix does not cause a huge allocation.
+const maxRecordSize = 64 << 20
+
+// SetBinaryRecords sets the input format to Binary, where the input is a
+// sequence of records, each one a length encoded with prefix followed by that
+// many bytes of payload. The decode function turns each payload into the
+// tokens to parse. If it returns an error, that record fails like a line with
+// a parse error, and reading continues with the next one. If decode is nil,
+// each payload becomes a single token.
+//
+// Deprecated: Use Program.SetBinaryRecords instead.
+func SetBinaryRecords(prefix LengthPrefix,
+	decode func([]byte) ([]string, error)) {
+	std.SetBinaryRecords(prefix, decode)
+}
+
+// SetBinaryRecords is like the package-level SetBinaryRecords, but for p.
+func (p *Program) SetBinaryRecords(prefix LengthPrefix,
+	decode func([]byte) ([]string, error)) {
+	p.lengthPrefix = prefix
+	p.decoder = decode
+	p.inputFormat = Binary
+}
+
+// binaryRecords reads records in the Binary format.
+type binaryRecords struct {
+	p      *Program
+	reader *bufio.Reader
+}
+
+func (r binaryRecords) next() (record, error) {
+	var size uint64
+	switch r.p.lengthPrefix {
+	case Varint:
+		n, err := binary.ReadUvarint(r.reader)
+		if err != nil {
+			return record{}, err
+		}
+		size = n
+	case Uint32:
+		var buf [4]byte
+		if _, err := io.ReadFull(r.reader, buf[:]); err != nil {
+			return record{}, err
+		}
+		size = uint64(binary.BigEndian.Uint32(buf[:]))
+	}
+	if size > maxRecordSize {
+		return record{}, fmt.Errorf("record too large (%d bytes)", size)
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r.reader, payload); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return record{}, err
+	}
+	if r.p.decoder == nil {
+		return record{tokens: []string{string(payload)}}, nil
+	}
+	tokens, err := r.p.decoder(payload)
+	return record{tokens: tokens, err: err}, nil
+}
+
+// SetMaxTokens limits the number of tokens in each record of input to n, so
+// that a single enormous record from an untrusted source cannot exhaust memory.
+// A record with more tokens fails with an error, like one with a parse error.
+// In the Shell and TSV formats, the extra tokens are never allocated. If n is
+// zero, there is no limit, which is the default. Unlike SetLineLimit, this
+// applies to both SetParsers and SetEveryParser, and the extra tokens are never
+// passed to fn.
+//
+// Deprecated: Use Program.SetMaxTokens instead.
+func SetMaxTokens(n int) {
+	std.SetMaxTokens(n)
+}
+
+// SetMaxTokens is like the package-level SetMaxTokens, but for p.
+func (p *Program) SetMaxTokens(n int) {
+	p.maxTokens = n
+}
+
+// errMaxTokens returns the error for a record with more than maxTokens tokens.
+func (p *Program) errMaxTokens() error {
+	return fmt.Errorf("too many tokens (at most %d per record)", p.maxTokens)
+}
+
+// maxTokensRecords enforces the maxTokens of p on the records of another
+// recordReader.
+type maxTokensRecords struct {
+	p      *Program
+	source recordReader
+}
+
+func (r maxTokensRecords) next() (record, error) {
+	rec, err := r.source.next()
+	if err == nil && len(rec.tokens) > r.p.maxTokens {
+		rec = record{err: r.p.errMaxTokens(), raw: rec.raw}
+	}
+	return rec, err
+}
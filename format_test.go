@@ -0,0 +1,386 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newRecordReader returns a recordReader for in that uses the settings of the
+// default program.
+func newRecordReader(in io.Reader) recordReader {
+	return std.newRun().newRecordReader(in)
+}
+
+var formatTests = []struct {
+	format  InputFormat
+	input   string
+	records [][]string
+}{
+	{Shell, "a 'b c'\n\nd,e\n", [][]string{{"a", "b c"}, {}, {"d,e"}}},
+	{CSV, "a,\"b, c\",don't\nd\n", [][]string{{"a", "b, c", "don't"}, {"d"}}},
+	{CSV, "\"x\ny\",z\n", [][]string{{"x\ny", "z"}}},
+	{TSV, "a\tb c\t\"d\n", [][]string{{"a", "b c", "\"d"}}},
+	{JSONLines, "[1, \"a b\", true, null]\n\n[[1,2]]\n",
+		[][]string{{"1", "a b", "true", ""}, {}, {"[1,2]"}}},
+	{JSONLines, `{"y": 2, "x": "1"}` + "\n", [][]string{{"1", "2"}}},
+	{Auto, "[1]\n", [][]string{{"1"}}},
+	{Auto, "a\tb\n", [][]string{{"a", "b"}}},
+	{Auto, "a,b c\n", [][]string{{"a", "b c"}}},
+	{Auto, "a b\nc,d\n", [][]string{{"a", "b"}, {"c,d"}}},
+	{Auto, "", nil},
+}
+
+func TestRecordReader(t *testing.T) {
+	defer func() {
+		SetInputFormat(Shell)
+		SetEveryParser(Parser{})
+		SetNames()
+	}()
+	SetParsers(Parser{}, Parser{})
+	SetNames("x", "y")
+	for i, test := range formatTests {
+		SetInputFormat(test.format)
+		records := newRecordReader(strings.NewReader(test.input))
+		var got [][]string
+		for {
+			rec, err := records.next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Errorf("%d. next() returned %v", i, err)
+				break
+			}
+			got = append(got, rec.tokens)
+		}
+		if !reflect.DeepEqual(got, test.records) {
+			t.Errorf("%d. reading %q\nreturned %q\nexpected %q", i,
+				test.input, got, test.records)
+		}
+	}
+}
+
+func TestRecordLimit(t *testing.T) {
+	defer func() {
+		SetInputFormat(Shell)
+		SetLineLimit(0, DropExtra)
+	}()
+	SetLineLimit(2, RawExtra)
+	SetInputFormat(CSV)
+	rec, _ := newRecordReader(strings.NewReader("a,b,c,d\n")).next()
+	if !reflect.DeepEqual(rec.tokens, []string{"a", "b"}) ||
+		string(rec.rest) != "c,d" {
+		t.Errorf("next() returned %q and %q", rec.tokens, rec.rest)
+	}
+}
+
+var headerTests = []struct {
+	mode    HeaderMode
+	input   string
+	records [][]string
+	extras  []map[string]string
+}{
+	{Header, "z,y,x\n1,2,3\n4,5\n", [][]string{{"3", "2"}, {}}, nil},
+	{HeaderWithRest, "y,z,x\n1,2,3\n",
+		[][]string{{"3", "1"}}, []map[string]string{{"z": "2"}}},
+}
+
+func TestHeader(t *testing.T) {
+	defer func() {
+		SetInputFormat(Shell)
+		SetHeader(NoHeader)
+		SetEveryParser(Parser{})
+		SetNames()
+	}()
+	SetParsers(Parser{}, Parser{})
+	SetNames("x", "y")
+	SetInputFormat(CSV)
+	for i, test := range headerTests {
+		SetHeader(test.mode)
+		records := newRecordReader(strings.NewReader(test.input))
+		var got [][]string
+		var extras []map[string]string
+		for {
+			rec, err := records.next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Errorf("%d. next() returned %v", i, err)
+				break
+			}
+			got = append(got, rec.tokens)
+			if rec.extra != nil {
+				extras = append(extras, rec.extra)
+			}
+		}
+		if !reflect.DeepEqual(got, test.records) {
+			t.Errorf("%d. reading %q\nreturned %q\nexpected %q", i,
+				test.input, got, test.records)
+		}
+		if !reflect.DeepEqual(extras, test.extras) {
+			t.Errorf("%d. extras were %v, expected %v", i, extras, test.extras)
+		}
+	}
+}
+
+func TestHeaderMissingColumn(t *testing.T) {
+	defer func() {
+		SetInputFormat(Shell)
+		SetHeader(NoHeader)
+		SetEveryParser(Parser{})
+		SetNames()
+	}()
+	SetParsers(Parser{}, Parser{})
+	SetNames("x", "y")
+	SetInputFormat(TSV)
+	SetHeader(Header)
+	records := newRecordReader(strings.NewReader("x\tz\n1\t2\n"))
+	_, err := records.next()
+	if err == nil || err.Error() != `missing column "y"` {
+		t.Errorf("next() returned %v, expected missing column error", err)
+	}
+}
+
+var columnTests = []struct {
+	format  InputFormat
+	input   string
+	records [][]string
+}{
+	{Shell, "a b c d\n", [][]string{{"c", "a"}}},
+	{Shell, "a b\nx\n", [][]string{{}, {}}},
+	{CSV, "a,b,c\n", [][]string{{"c", "a"}}},
+	{TSV, "a\tb\tc\td\te\n", [][]string{{"c", "a"}}},
+}
+
+func TestColumns(t *testing.T) {
+	defer func() {
+		SetInputFormat(Shell)
+		SetColumns()
+	}()
+	SetColumns(3, 1)
+	for i, test := range columnTests {
+		SetInputFormat(test.format)
+		records := newRecordReader(strings.NewReader(test.input))
+		var got [][]string
+		for {
+			rec, err := records.next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Errorf("%d. next() returned %v", i, err)
+				break
+			}
+			got = append(got, rec.tokens)
+		}
+		if !reflect.DeepEqual(got, test.records) {
+			t.Errorf("%d. reading %q\nreturned %q\nexpected %q", i,
+				test.input, got, test.records)
+		}
+	}
+}
+
+func TestColumnsKeepErrors(t *testing.T) {
+	tooMany := New()
+	tooMany.SetMaxTokens(2)
+	tooMany.SetColumns(1)
+	unterminated := New()
+	unterminated.SetTokenizer(POSIXTokenizer(DefaultIFS))
+	unterminated.SetColumns(1)
+	tests := []struct {
+		p     *Program
+		input string
+	}{
+		{tooMany, "a b c d e f\n"},
+		{unterminated, "'unterminated x\n"},
+	}
+	for i, test := range tests {
+		calls := 0
+		code, err := test.p.Run(func([]interface{}) { calls++ },
+			WithArgs([]string{}), WithInput(strings.NewReader(test.input)),
+			WithErrorOutput(io.Discard))
+		if code == 0 || err == nil || calls != 0 {
+			t.Errorf("%d. Run returned %d, %v after %d calls to fn", i,
+				code, err, calls)
+		}
+	}
+}
+
+func TestColumnsLineNumbers(t *testing.T) {
+	defer func() {
+		SetInputFormat(Shell)
+		SetColumns()
+	}()
+	SetInputFormat(CSV)
+	SetColumns(2)
+	records := newRecordReader(strings.NewReader("\"a\nb\",c\nd,e\n"))
+	var lines []int
+	for {
+		rec, err := records.next()
+		if err != nil {
+			break
+		}
+		lines = append(lines, rec.n)
+	}
+	if !reflect.DeepEqual(lines, []int{1, 3}) {
+		t.Errorf("the records are on lines %v, expected [1 3]", lines)
+	}
+}
+
+func TestCSVMalformedRow(t *testing.T) {
+	p := New()
+	p.SetInputFormat(CSV)
+	var rows []string
+	code, err := p.Run(func(args []interface{}) {
+		rows = append(rows, args[0].(string))
+	}, WithArgs([]string{}), WithErrorOutput(io.Discard),
+		WithInput(strings.NewReader("a,b\n\"x\"y,z\nc,d")))
+	if !reflect.DeepEqual(rows, []string{"a", "c"}) {
+		t.Errorf("fn was called for the rows starting with %q", rows)
+	}
+	if code == 0 || err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Run returned %d, %v; expected an error for line 2", code,
+			err)
+	}
+}
+
+func TestFixedWidths(t *testing.T) {
+	defer SetInputFormat(Shell)
+	SetFixedWidths(3, 5, 2)
+	input := "ab 12   xyz\n\nαβγδεζ\n  1\n"
+	expected := [][]string{{"ab", "12", "xy"}, {}, {"αβγ", "δεζ"}, {"1"}}
+	records := newRecordReader(strings.NewReader(input))
+	var got [][]string
+	for {
+		rec, err := records.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("next() returned %v", err)
+		}
+		got = append(got, rec.tokens)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("reading %q\nreturned %q\nexpected %q", input, got, expected)
+	}
+}
+
+func TestBinaryRecords(t *testing.T) {
+	defer SetInputFormat(Shell)
+	split := func(b []byte) ([]string, error) {
+		if len(b) == 0 {
+			return nil, errors.New("empty record")
+		}
+		return strings.Split(string(b), ","), nil
+	}
+	tests := []struct {
+		prefix LengthPrefix
+		decode func([]byte) ([]string, error)
+		input  string
+	}{
+		{Varint, nil, "\x03a,b\x00\x01c"},
+		{Varint, split, "\x03a,b\x00\x01c"},
+		{Uint32, split, "\x00\x00\x00\x03a,b\x00\x00\x00\x00\x00\x00\x00\x01c"},
+	}
+	expected := [][][]string{
+		{{"a,b"}, {""}, {"c"}},
+		{{"a", "b"}, nil, {"c"}},
+		{{"a", "b"}, nil, {"c"}},
+	}
+	for i, test := range tests {
+		SetBinaryRecords(test.prefix, test.decode)
+		records := newRecordReader(strings.NewReader(test.input))
+		var got [][]string
+		for {
+			rec, err := records.next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Errorf("%d. next() returned %v", i, err)
+				break
+			}
+			if (rec.err != nil) != (rec.tokens == nil) {
+				t.Errorf("%d. record %q has error %v", i, rec.tokens, rec.err)
+			}
+			got = append(got, rec.tokens)
+		}
+		if !reflect.DeepEqual(got, expected[i]) {
+			t.Errorf("%d. reading %q\nreturned %q\nexpected %q", i,
+				test.input, got, expected[i])
+		}
+	}
+}
+
+func TestBinaryTruncated(t *testing.T) {
+	defer SetInputFormat(Shell)
+	SetBinaryRecords(Uint32, nil)
+	records := newRecordReader(strings.NewReader("\x00\x00\x00\x05ab"))
+	if _, err := records.next(); err != io.ErrUnexpectedEOF {
+		t.Errorf("next() returned %v, expected %v", err, io.ErrUnexpectedEOF)
+	}
+}
+
+var maxTokensTests = []struct {
+	format InputFormat
+	input  string
+	ok     []bool
+}{
+	{Shell, "a b c\na 'b c' d e\n\n", []bool{true, false, true}},
+	{TSV, "a\tb\tc\na\tb\tc\td\n", []bool{true, false}},
+	{CSV, "a,b,c\na,b,c,d\n", []bool{true, false}},
+	{JSONLines, "[1,2,3]\n[1,2,3,4]\n", []bool{true, false}},
+}
+
+func TestMaxTokens(t *testing.T) {
+	defer func() {
+		SetInputFormat(Shell)
+		SetMaxTokens(0)
+	}()
+	SetMaxTokens(3)
+	for i, test := range maxTokensTests {
+		SetInputFormat(test.format)
+		records := newRecordReader(strings.NewReader(test.input))
+		var ok []bool
+		for {
+			rec, err := records.next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%d. next() returned %v", i, err)
+			}
+			ok = append(ok, rec.err == nil)
+			if rec.err != nil && rec.tokens != nil {
+				t.Errorf("%d. failed record has tokens %q", i, rec.tokens)
+			}
+		}
+		if !reflect.DeepEqual(ok, test.ok) {
+			t.Errorf("%d. reading %q\nsucceeded %v\nexpected %v", i,
+				test.input, ok, test.ok)
+		}
+	}
+}
+
+func TestMaxTokensWithLineLimit(t *testing.T) {
+	defer func() {
+		SetMaxTokens(0)
+		SetLineLimit(0, DropExtra)
+		SetEveryParser(Parser{})
+	}()
+	SetEveryParser(Parser{})
+	SetMaxTokens(5)
+	SetLineLimit(2, RawExtra)
+	rec, _ := newRecordReader(strings.NewReader("a b c d\n")).next()
+	if rec.err != nil || len(rec.tokens) != 2 || string(rec.rest) != "c d" {
+		t.Errorf("next() = %q with rest %q and error %v", rec.tokens, rec.rest,
+			rec.err)
+	}
+}
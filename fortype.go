@@ -0,0 +1,169 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"encoding"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// textUnmarshalerType is the type of encoding.TextUnmarshaler.
+var textUnmarshalerType = reflect.TypeOf(
+	(*encoding.TextUnmarshaler)(nil)).Elem()
+
+// ForType returns a Parser that parses a string as a value of type t, so that
+// ad hoc types can be used as arguments without hand-written parsers. It
+// supports the following types:
+//
+//   - Types whose pointers implement encoding.TextUnmarshaler, such as
+//     net/netip.Addr, which are parsed with UnmarshalText.
+//   - Strings, bools, and numbers, including named types like time.Month,
+//     which are parsed like Int and Float64.
+//   - Structs whose exported fields are all of the types above, which are
+//     parsed from the fields' values separated by commas, such as "3,4" for
+//     struct{ X, Y int }.
+//
+// ForType panics if t is not supported. The returned values have type t.
+func ForType(t reflect.Type) Parser {
+	p := typeParser(t)
+	name := strings.ToLower(t.Name())
+	isText := reflect.PointerTo(t).Implements(textUnmarshalerType)
+	if t.Kind() == reflect.Struct && !isText {
+		p = structParser(t)
+		var names []string
+		for _, f := range exportedFields(t) {
+			names = append(names, strings.ToLower(f.Name))
+		}
+		name = strings.Join(names, ",")
+	}
+	if p == nil {
+		panic(fmt.Sprintf("parse: ForType: unsupported type %v", t))
+	}
+	if name == "" {
+		name = t.String()
+	}
+	return NewParser(p).withInfo(parserInfo{name: name, typ: t.String()})
+}
+
+// typeParser returns a function that parses a type that is not a struct, or nil
+// if t is not supported.
+func typeParser(t reflect.Type) func(string) (interface{}, error) {
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return func(s string) (interface{}, error) {
+			v := reflect.New(t)
+			err := v.Interface().(encoding.TextUnmarshaler).
+				UnmarshalText([]byte(s))
+			if err != nil {
+				return nil, err
+			}
+			return v.Elem().Interface(), nil
+		}
+	}
+	bits := t.Bits
+	var parse func(s string) (interface{}, error)
+	switch t.Kind() {
+	case reflect.String:
+		parse = func(s string) (interface{}, error) { return s, nil }
+	case reflect.Bool:
+		parse = func(s string) (interface{}, error) {
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return nil, &ValueError{s, "boolean", strconv.ErrSyntax, "", ""}
+			}
+			return b, nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64:
+		parse = func(s string) (interface{}, error) {
+			n, err := strconv.ParseInt(s, 0, bits())
+			if err != nil {
+				return nil, &ValueError{s, "whole number",
+					err.(*strconv.NumError).Err,
+					strconv.FormatInt(-1<<(bits()-1), 10),
+					strconv.FormatInt(1<<(bits()-1)-1, 10)}
+			}
+			return n, nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		parse = func(s string) (interface{}, error) {
+			n, err := strconv.ParseUint(s, 0, bits())
+			if err != nil {
+				return nil, &ValueError{s, "non-negative whole number",
+					err.(*strconv.NumError).Err, "0",
+					strconv.FormatUint(1<<bits()-1, 10)}
+			}
+			return n, nil
+		}
+	case reflect.Float32, reflect.Float64:
+		parse = func(s string) (interface{}, error) {
+			x, err := strconv.ParseFloat(s, bits())
+			if err != nil {
+				max := math.MaxFloat64
+				if bits() == 32 {
+					max = math.MaxFloat32
+				}
+				return nil, &ValueError{s, "number",
+					err.(*strconv.NumError).Err,
+					strconv.FormatFloat(-max, 'g', -1, bits()),
+					strconv.FormatFloat(max, 'g', -1, bits())}
+			}
+			return x, nil
+		}
+	default:
+		return nil
+	}
+	return func(s string) (interface{}, error) {
+		x, err := parse(s)
+		if err != nil {
+			return nil, err
+		}
+		return reflect.ValueOf(x).Convert(t).Interface(), nil
+	}
+}
+
+// exportedFields returns the exported fields of the struct type t.
+func exportedFields(t reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.IsExported() {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// structParser returns a function that parses the struct type t, or nil if any
+// of its exported fields are not supported.
+func structParser(t reflect.Type) func(string) (interface{}, error) {
+	fields := exportedFields(t)
+	parsers := make([]func(string) (interface{}, error), len(fields))
+	for i, f := range fields {
+		if parsers[i] = typeParser(f.Type); parsers[i] == nil {
+			return nil
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return func(s string) (interface{}, error) {
+		parts := strings.Split(s, ",")
+		if len(parts) != len(fields) {
+			return nil, fmt.Errorf("%q does not have %d comma-separated "+
+				"values", s, len(fields))
+		}
+		v := reflect.New(t).Elem()
+		for i, f := range fields {
+			x, err := parsers[i](strings.TrimSpace(parts[i]))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", strings.ToLower(f.Name), err)
+			}
+			v.FieldByIndex(f.Index).Set(reflect.ValueOf(x))
+		}
+		return v.Interface(), nil
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type point struct {
+	X, Y    int
+	Label   string
+	private bool
+}
+
+type scaled struct {
+	Factor float32
+	On     bool
+}
+
+var forTypeTests = []struct {
+	typ   reflect.Type
+	input string
+	value interface{}
+	msg   string
+}{
+	{reflect.TypeOf(0), "42", 42, ""},
+	{reflect.TypeOf(int8(0)), "127", int8(127), ""},
+	{reflect.TypeOf(int8(0)), "128", nil, `"128" is too large (max 127)`},
+	{reflect.TypeOf(uint16(0)), "-1", nil,
+		`"-1" is not a non-negative whole number`},
+	{reflect.TypeOf(time.Month(0)), "3", time.March, ""},
+	{reflect.TypeOf(""), "x y", "x y", ""},
+	{reflect.TypeOf(true), "true", true, ""},
+	{reflect.TypeOf(true), "yes", nil, `"yes" is not a boolean`},
+	{reflect.TypeOf(float32(0)), "1.5", float32(1.5), ""},
+	{reflect.TypeOf(float32(0)), "1e39", nil,
+		`"1e39" is too large (max 3.4028235e+38)`},
+	{reflect.TypeOf(netip.Addr{}), "10.0.0.1",
+		netip.MustParseAddr("10.0.0.1"), ""},
+	{reflect.TypeOf(point{}), "3,4,home", point{3, 4, "home", false}, ""},
+	{reflect.TypeOf(point{}), "3, 4, home", point{3, 4, "home", false}, ""},
+	{reflect.TypeOf(point{}), "3,4", nil,
+		`"3,4" does not have 3 comma-separated values`},
+	{reflect.TypeOf(point{}), "3,x,home", nil, `y: "x" is not a whole number`},
+	{reflect.TypeOf(scaled{}), "0.5,false", scaled{0.5, false}, ""},
+}
+
+func TestForType(t *testing.T) {
+	for i, test := range forTypeTests {
+		value, err := ForType(test.typ).Parse(test.input)
+		msg := ""
+		if err != nil {
+			msg = err.Error()
+		}
+		if value != test.value || msg != test.msg {
+			t.Errorf("%d. ForType(%v)(%q)\nreturned %#v and %q\n"+
+				"expected %#v and %q", i, test.typ, test.input, value, msg,
+				test.value, test.msg)
+		}
+	}
+}
+
+func TestForTypeInfo(t *testing.T) {
+	info := ForType(reflect.TypeOf(point{})).info()
+	if info.name != "x,y,label" || info.typ != "parse.point" {
+		t.Errorf("wrong metadata %+v", info)
+	}
+}
+
+func TestForTypeUnsupported(t *testing.T) {
+	for _, typ := range []reflect.Type{
+		reflect.TypeOf([]int{}),
+		reflect.TypeOf(struct{ M map[string]int }{}),
+		reflect.TypeOf(struct{}{}),
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("ForType(%v) did not panic", typ)
+				}
+			}()
+			ForType(typ)
+		}()
+	}
+}
@@ -0,0 +1,262 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// generatedType describes a type for which GenerateParsers writes a parser.
+type generatedType struct {
+	Name string // name of the type
+	Kind string // one of the keys of basicKinds, or "text"
+}
+
+// basicKinds maps the names of the basic types that GenerateParsers supports as
+// underlying types to the code that converts the string s into a value of that
+// type, which it assigns to v.
+var basicKinds = map[string]string{
+	"string":  `v := s`,
+	"bool":    parseCall("ParseBool(s)", "boolean", `""`, `""`),
+	"int":     parseIntCall(""),
+	"int8":    parseIntCall("8"),
+	"int16":   parseIntCall("16"),
+	"int32":   parseIntCall("32"),
+	"int64":   parseIntCall("64"),
+	"uint":    parseUintCall(""),
+	"uint8":   parseUintCall("8"),
+	"uint16":  parseUintCall("16"),
+	"uint32":  parseUintCall("32"),
+	"uint64":  parseUintCall("64"),
+	"float32": parseFloatCall("32"),
+	"float64": parseFloatCall("64"),
+}
+
+// parseCall returns code that calls the strconv function call and converts its
+// error to a ValueError for the type called noun with the given limits.
+func parseCall(call, noun, min, max string) string {
+	return fmt.Sprintf(`v, err := strconv.%s
+	if err != nil {
+		return nil, &parse.ValueError{Value: s, Type: %q,
+			Err: err.(*strconv.NumError).Err,
+			Min: %s,
+			Max: %s}
+	}`, call, noun, min, max)
+}
+
+func parseIntCall(bits string) string {
+	size := bits
+	if size == "" {
+		size = "0"
+	}
+	return parseCall("ParseInt(s, 0, "+size+")", "whole number",
+		"strconv.FormatInt(math.MinInt"+bits+", 10)",
+		"strconv.FormatInt(math.MaxInt"+bits+", 10)")
+}
+
+func parseUintCall(bits string) string {
+	size := bits
+	if size == "" {
+		size = "0"
+	}
+	return parseCall("ParseUint(s, 0, "+size+")", "non-negative whole number",
+		`"0"`, "strconv.FormatUint(math.MaxUint"+bits+", 10)")
+}
+
+func parseFloatCall(bits string) string {
+	return parseCall("ParseFloat(s, "+bits+")", "number",
+		"strconv.FormatFloat(-math.MaxFloat"+bits+", 'g', -1, "+bits+")",
+		"strconv.FormatFloat(math.MaxFloat"+bits+", 'g', -1, "+bits+")")
+}
+
+// generatedCode is the template for the output of GenerateParsers.
+var generatedCode = template.Must(template.New("").Funcs(template.FuncMap{
+	"convert": func(kind string) string { return basicKinds[kind] },
+}).Parse(`// Code generated by parse.GenerateParsers. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"github.com/mk12/parse"
+{{- if .Math}}
+	"math"
+{{- end}}
+{{- if .Strconv}}
+	"strconv"
+{{- end}}
+)
+{{range .Types}}
+// {{.Name}}Parser is a parse.Parser that parses a string as a value of type
+// {{.Name}}.
+var {{.Name}}Parser = parse.NewParser(func(s string) (interface{}, error) {
+{{- if eq .Kind "text"}}
+	var v {{.Name}}
+	if err := v.UnmarshalText([]byte(s)); err != nil {
+		return nil, err
+	}
+	return v, nil
+{{- else}}
+	{{convert .Kind}}
+	return {{.Name}}(v), nil
+{{- end}}
+})
+
+// Assert{{.Name}}s converts a list of interface{} to a list of {{.Name}} values
+// using a type assertion for each element. It is useful when combined with
+// parse.SetEveryParser({{.Name}}Parser).
+func Assert{{.Name}}s(args []interface{}) []{{.Name}} {
+	values := make([]{{.Name}}, len(args))
+	for i := range args {
+		values[i] = args[i].({{.Name}})
+	}
+	return values
+}
+{{end}}`))
+
+// GenerateParsers writes Go source code to w that defines a Parser and an
+// Assert function for each of the named types, which are defined in the Go
+// package in the directory dir. For a type T, the Parser is called TParser and
+// the function is called AssertTs. This reduces the boilerplate in programs
+// with many custom argument types. If no types are named, code is generated for
+// every supported type in the package.
+//
+// A type is supported if its underlying type is a string, a bool, or a number,
+// or if it has an UnmarshalText method, which takes precedence. The code for
+// numbers uses the same syntax and error messages as Int and Float64.
+//
+// GenerateParsers is meant to be called from a small program run by go
+// generate, such as one containing the following, with the directive
+// "//go:generate go run gen_parsers.go" in the package:
+//
+//	//go:build ignore
+//
+//	package main
+//
+//	func main() {
+//		f, _ := os.Create("parsers_gen.go")
+//		defer f.Close()
+//		if err := parse.GenerateParsers(f, ".", "Celsius", "Mode"); err != nil {
+//			log.Fatal(err)
+//		}
+//	}
+func GenerateParsers(w io.Writer, dir string, typeNames ...string) error {
+	fset := token.NewFileSet()
+	// Empty files are skipped, since the output file is usually created just
+	// before GenerateParsers is called, and so are files excluded by build
+	// constraints, like the program that calls it.
+	pkgs, err := parser.ParseDir(fset, dir, func(info fs.FileInfo) bool {
+		match, err := build.Default.MatchFile(dir, info.Name())
+		return match && err == nil &&
+			!strings.HasSuffix(info.Name(), "_test.go") && info.Size() > 0
+	}, 0)
+	if err != nil {
+		return err
+	}
+	var pkg *ast.Package
+	for _, p := range pkgs {
+		if pkg != nil {
+			return fmt.Errorf("%s contains more than one package", dir)
+		}
+		pkg = p
+	}
+	if pkg == nil {
+		return fmt.Errorf("%s does not contain a Go package", dir)
+	}
+	underlying, textTypes := collectTypes(pkg)
+	all := len(typeNames) == 0
+	if all {
+		for name := range underlying {
+			typeNames = append(typeNames, name)
+		}
+		sort.Strings(typeNames)
+	}
+	data := struct {
+		Package       string
+		Math, Strconv bool
+		Types         []generatedType
+	}{Package: pkg.Name}
+	for _, name := range typeNames {
+		kind, ok := underlying[name]
+		if !ok {
+			return fmt.Errorf("type %s is not defined in %s", name, dir)
+		}
+		if textTypes[name] {
+			kind = "text"
+		} else if _, ok := basicKinds[kind]; !ok {
+			if all {
+				continue
+			}
+			return fmt.Errorf("type %s is not supported (underlying type "+
+				"%s)", name, kind)
+		}
+		data.Math = data.Math || kind != "text" && kind != "string" &&
+			kind != "bool"
+		data.Strconv = data.Strconv || kind != "text" && kind != "string"
+		data.Types = append(data.Types, generatedType{name, kind})
+	}
+	var b bytes.Buffer
+	if err := generatedCode.Execute(&b, data); err != nil {
+		return err
+	}
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// collectTypes returns the types defined in pkg, mapped to the names of their
+// underlying types, as well as the set of types with UnmarshalText methods.
+func collectTypes(pkg *ast.Package) (map[string]string, map[string]bool) {
+	underlying := make(map[string]string)
+	textTypes := make(map[string]bool)
+	for _, file := range pkg.Files {
+		for _, decl := range file.Decls {
+			switch d := decl.(type) {
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					ts, ok := spec.(*ast.TypeSpec)
+					if !ok || ts.TypeParams != nil {
+						continue
+					}
+					underlying[ts.Name.Name] = types.ExprString(ts.Type)
+				}
+			case *ast.FuncDecl:
+				if d.Name.Name == "UnmarshalText" && d.Recv != nil {
+					recv := d.Recv.List[0].Type
+					if star, ok := recv.(*ast.StarExpr); ok {
+						recv = star.X
+					}
+					if id, ok := recv.(*ast.Ident); ok {
+						textTypes[id.Name] = true
+					}
+				}
+			}
+		}
+	}
+	// Resolve types defined in terms of other types in the package.
+	for name, u := range underlying {
+		for seen := 0; seen < len(underlying); seen++ {
+			next, ok := underlying[u]
+			if !ok || next == u {
+				break
+			}
+			u = next
+		}
+		underlying[name] = u
+	}
+	return underlying, textTypes
+}
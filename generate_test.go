@@ -0,0 +1,113 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const generateSource = `package units
+
+import "strings"
+
+type Celsius float64
+
+type Count uint16
+
+type Name string
+
+type Alias Name
+
+type Mode int
+
+func (m *Mode) UnmarshalText(text []byte) error {
+	*m = Mode(len(strings.TrimSpace(string(text))))
+	return nil
+}
+
+type Point struct{ X, Y int }
+`
+
+func writeGenerateSource(t *testing.T) string {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "units.go"),
+		[]byte(generateSource), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestGenerateParsers(t *testing.T) {
+	dir := writeGenerateSource(t)
+	var b bytes.Buffer
+	if err := GenerateParsers(&b, dir); err != nil {
+		t.Fatal(err)
+	}
+	src := b.String()
+	_, err := parser.ParseFile(token.NewFileSet(), "gen.go", src, 0)
+	if err != nil {
+		t.Fatalf("generated code does not parse: %v\n%s", err, src)
+	}
+	for _, s := range []string{
+		"// Code generated by parse.GenerateParsers. DO NOT EDIT.",
+		"package units",
+		"var CelsiusParser = parse.NewParser(",
+		"strconv.ParseFloat(s, 64)",
+		"func AssertCelsiuss(args []interface{}) []Celsius {",
+		"strconv.ParseUint(s, 0, 16)",
+		"var NameParser",
+		"var AliasParser",
+		"v.UnmarshalText([]byte(s))",
+		`"math"`,
+	} {
+		if !strings.Contains(src, s) {
+			t.Errorf("generated code does not contain %q\n%s", s, src)
+		}
+	}
+	if strings.Contains(src, "PointParser") {
+		t.Errorf("generated code for a struct type\n%s", src)
+	}
+}
+
+func TestGenerateParsersIgnored(t *testing.T) {
+	dir := writeGenerateSource(t)
+	gen := "//go:build ignore\n\npackage main\n\ntype Main int\n"
+	err := os.WriteFile(filepath.Join(dir, "gen.go"), []byte(gen), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := GenerateParsers(&b, dir); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(b.String(), "MainParser") {
+		t.Errorf("generated code for an ignored file\n%s", b.String())
+	}
+}
+
+func TestGenerateParsersErrors(t *testing.T) {
+	dir := writeGenerateSource(t)
+	var b bytes.Buffer
+	err := GenerateParsers(&b, dir, "Point")
+	if err == nil || !strings.Contains(err.Error(), "not supported") {
+		t.Errorf("expected an unsupported type error, got %v", err)
+	}
+	err = GenerateParsers(&b, dir, "Missing")
+	if err == nil || !strings.Contains(err.Error(), "not defined") {
+		t.Errorf("expected an undefined type error, got %v", err)
+	}
+	b.Reset()
+	if err := GenerateParsers(&b, dir, "Name"); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(b.String(), "strconv") {
+		t.Errorf("imported strconv for a string type\n%s", b.String())
+	}
+}
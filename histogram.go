@@ -0,0 +1,161 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Histogram makes Main collect the numbers that it parses, on the command line
+// or across all the lines of input, and print a histogram of them with the
+// given number of buckets, followed by a summary with percentiles, to the
+// output stream (see SetStreams) at the end. It is meant for quick tools that
+// have latencies or sizes piped into them. Like SetReduction, it requires a
+// single numeric parser, and fn can be nil. The buckets divide the range from
+// the smallest number to the largest evenly. For example, with Histogram(2)
+// and SetEveryParser(Int), the input "1 2 2 3 9" prints
+//
+//	1 - 5  4 ########################################
+//	5 - 9  1 ##########
+//	count 5, min 1, p50 2, p90 9, p99 9, max 9, mean 3.4
+//
+// Nothing is printed if there are no numbers. Histogram(0), the default, turns
+// it off.
+//
+// Deprecated: Use Program.Histogram instead.
+func Histogram(buckets int) {
+	std.Histogram(buckets)
+}
+
+// Histogram is like the package-level Histogram, but for p.
+func (p *Program) Histogram(buckets int) {
+	p.histogramBuckets = buckets
+}
+
+// maxBar is the length of the bar for the fullest bucket in a histogram.
+const maxBar = 40
+
+// A histogram collects the numbers for Histogram.
+type histogram struct {
+	mutex  sync.Mutex
+	values []float64
+}
+
+// histogramFn returns a function that calls fn, unless it is nil, and then
+// adds the numbers in its arguments to the histogram of r.
+func (r *run) histogramFn(fn func([]interface{})) func([]interface{}) {
+	return func(args []interface{}) {
+		if fn != nil {
+			fn(args)
+		}
+		r.histo.add(args[:len(args)-len(r.derived)])
+	}
+}
+
+// add adds the numbers in values to h, ignoring other values.
+func (h *histogram) add(values []interface{}) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for _, v := range values {
+		switch n := v.(type) {
+		case int:
+			h.values = append(h.values, float64(n))
+		case float64:
+			h.values = append(h.values, n)
+		}
+	}
+}
+
+// write writes the histogram of h with the given number of buckets, followed
+// by its summary, to w.
+func (h *histogram) write(w io.Writer, buckets int) {
+	h.mutex.Lock()
+	values := append([]float64(nil), h.values...)
+	h.mutex.Unlock()
+	if len(values) == 0 {
+		return
+	}
+	sort.Float64s(values)
+	min, max := values[0], values[len(values)-1]
+	if min == max {
+		buckets = 1
+	}
+	counts := make([]int, buckets)
+	fullest := 0
+	for _, v := range values {
+		i := buckets - 1
+		if v < max {
+			i = int((v - min) / (max - min) * float64(buckets))
+		}
+		counts[i]++
+		if counts[i] > counts[fullest] {
+			fullest = i
+		}
+	}
+	// Format the bounds and counts first so that the columns line up.
+	rows := make([][3]string, buckets)
+	var widths [3]int
+	width := (max - min) / float64(buckets)
+	for i := range rows {
+		rows[i] = [3]string{
+			formatNumber(min + float64(i)*width),
+			formatNumber(min + float64(i+1)*width),
+			strconv.Itoa(counts[i]),
+		}
+		if i == buckets-1 {
+			rows[i][1] = formatNumber(max)
+		}
+		for j, s := range rows[i] {
+			if len(s) > widths[j] {
+				widths[j] = len(s)
+			}
+		}
+	}
+	for i, row := range rows {
+		bar := counts[i] * maxBar / counts[fullest]
+		line := fmt.Sprintf("%-*s - %-*s  %*s %s", widths[0], row[0],
+			widths[1], row[1], widths[2], row[2], strings.Repeat("#", bar))
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	fmt.Fprintf(w, "count %d, min %s, p50 %s, p90 %s, p99 %s, max %s, "+
+		"mean %s\n", len(values), formatNumber(min),
+		formatNumber(percentile(values, 50)),
+		formatNumber(percentile(values, 90)),
+		formatNumber(percentile(values, 99)), formatNumber(max),
+		formatNumber(sum/float64(len(values))))
+}
+
+// percentile returns the p-th percentile of sorted, which must not be empty,
+// using the nearest-rank method.
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// formatNumber formats f in the shortest way that represents it, rounded to
+// six significant digits.
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'g', 6, 64)
+}
+
+// printSummaries prints the result of the reduction (see SetReduction) and the
+// histogram (see Histogram), if there are any.
+func (r *run) printSummaries() {
+	r.printReduction()
+	if r.histo != nil {
+		r.histo.write(r.output, r.histogramBuckets)
+	}
+}
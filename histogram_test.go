@@ -0,0 +1,63 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+var histogramTests = []struct {
+	parser  Parser
+	buckets int
+	input   string
+	output  string
+}{
+	{Int, 2, "1 2 2 3 9\n", "" +
+		"1 - 5  4 ########################################\n" +
+		"5 - 9  1 ##########\n" +
+		"count 5, min 1, p50 2, p90 9, p99 9, max 9, mean 3.4\n"},
+	{Float64, 4, "0.5\n1\n10\n2.5\n", "" +
+		"0.5   - 2.875  3 ########################################\n" +
+		"2.875 - 5.25   0\n" +
+		"5.25  - 7.625  0\n" +
+		"7.625 - 10     1 #############\n" +
+		"count 4, min 0.5, p50 1, p90 10, p99 10, max 10, mean 3.5\n"},
+	{Int, 3, "7 7\n7\n", "" +
+		"7 - 7  3 ########################################\n" +
+		"count 3, min 7, p50 7, p90 7, p99 7, max 7, mean 7\n"},
+	{Int, 3, "", ""},
+}
+
+func TestHistogram(t *testing.T) {
+	defer func() {
+		SetArgs(nil)
+		SetInput(nil)
+		SetOutput(os.Stdout)
+		SetEveryParser(Parser{})
+		Histogram(0)
+	}()
+	SetArgs([]string{})
+	for i, test := range histogramTests {
+		var out bytes.Buffer
+		SetOutput(&out)
+		SetEveryParser(test.parser)
+		Histogram(test.buckets)
+		SetInput(strings.NewReader(test.input))
+		Main(nil)
+		if out.String() != test.output {
+			t.Errorf("%d. Main with %q printed\n%s\nexpected\n%s", i,
+				test.input, out.String(), test.output)
+		}
+	}
+}
+
+func TestHistogramInvalid(t *testing.T) {
+	defer Histogram(0)
+	Histogram(10)
+	if Validate() == nil {
+		t.Error("Validate accepted a histogram of strings")
+	}
+}
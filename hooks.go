@@ -0,0 +1,98 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+// BeforeInvoke registers fn to be called with the arguments of each invocation
+// that Main makes, whether they come from the command line or a line of input,
+// before they are passed to fn. Together with AfterInvoke, it makes it possible
+// to add timing, auditing, or logging around each invocation without writing
+// a loop in place of Main. Hooks are called in the order they were registered.
+// When lines are processed concurrently (see SetJobs), hooks are too, so they
+// must be safe for concurrent use.
+//
+// Deprecated: Use Program.BeforeInvoke instead.
+func BeforeInvoke(fn func(args []string)) {
+	std.BeforeInvoke(fn)
+}
+
+// BeforeInvoke is like the package-level BeforeInvoke, but for p.
+func (p *Program) BeforeInvoke(fn func(args []string)) {
+	p.beforeHooks = append(p.beforeHooks, fn)
+}
+
+// AfterInvoke registers fn to be called at the end of each invocation that
+// Main makes, with the parsed arguments and the error that made it fail, if
+// any. The error is the one returned by Parse if the arguments did not parse,
+// in which case parsed is nil, or the one passed to Fail. Hooks are called in
+// the reverse order of registration, like deferred calls, so that they nest
+// with the ones registered by BeforeInvoke.
+//
+// Deprecated: Use Program.AfterInvoke instead.
+func AfterInvoke(fn func(parsed []interface{}, err error)) {
+	std.AfterInvoke(fn)
+}
+
+// AfterInvoke is like the package-level AfterInvoke, but for p.
+func (p *Program) AfterInvoke(fn func(parsed []interface{}, err error)) {
+	p.afterHooks = append(p.afterHooks, fn)
+}
+
+// OnParseError registers fn to be called for each argument of an invocation
+// that fails to parse, with the argument and the error returned by its Parser,
+// before the error is printed. It is not called when the number of arguments
+// is wrong or a computed argument fails (see Derive); AfterInvoke sees those.
+// Hooks are called in the order they were registered.
+//
+// Deprecated: Use Program.OnParseError instead.
+func OnParseError(fn func(arg string, err error)) {
+	std.OnParseError(fn)
+}
+
+// OnParseError is like the package-level OnParseError, but for p.
+func (p *Program) OnParseError(fn func(arg string, err error)) {
+	p.parseErrorHooks = append(p.parseErrorHooks, fn)
+}
+
+// ClearHooks removes the hooks registered with BeforeInvoke, AfterInvoke, and
+// OnParseError.
+//
+// Deprecated: Use Program.ClearHooks instead.
+func ClearHooks() {
+	std.ClearHooks()
+}
+
+// ClearHooks is like the package-level ClearHooks, but for p.
+func (p *Program) ClearHooks() {
+	p.beforeHooks, p.afterHooks, p.parseErrorHooks = nil, nil, nil
+}
+
+// beforeInvoke calls the hooks registered with BeforeInvoke.
+func (p *Program) beforeInvoke(args []string) {
+	for _, hook := range p.beforeHooks {
+		hook(args)
+	}
+}
+
+// afterInvoke calls the hooks registered with AfterInvoke.
+func (p *Program) afterInvoke(parsed []interface{}, err error) {
+	for i := len(p.afterHooks) - 1; i >= 0; i-- {
+		p.afterHooks[i](parsed, err)
+	}
+}
+
+// parseError calls the hooks registered with OnParseError for each argument
+// that failed in err, which was returned by Parse.
+func (p *Program) parseError(err error) {
+	var errs MultiError
+	switch e := err.(type) {
+	case MultiError:
+		errs = e
+	case *ArgError:
+		errs = MultiError{e}
+	}
+	for _, e := range errs {
+		for _, hook := range p.parseErrorHooks {
+			hook(e.Arg, e.Err)
+		}
+	}
+}
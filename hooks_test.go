@@ -0,0 +1,57 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	defer func() {
+		SetArgs(nil)
+		SetInput(nil)
+		SetErrorOutput(os.Stderr)
+		SetEveryParser(Parser{})
+		ClearHooks()
+	}()
+	var events []string
+	BeforeInvoke(func(args []string) {
+		events = append(events, fmt.Sprintf("before %q", args))
+	})
+	AfterInvoke(func(parsed []interface{}, err error) {
+		events = append(events, fmt.Sprintf("after %v %v", parsed, err))
+	})
+	AfterInvoke(func(parsed []interface{}, err error) {
+		events = append(events, "inner")
+	})
+	OnParseError(func(arg string, err error) {
+		events = append(events, fmt.Sprintf("error %q %v", arg, err))
+	})
+	SetParsers(Int, Int)
+	SetErrorOutput(nil)
+	SetArgs([]string{})
+	SetInput(strings.NewReader("1 2\nx 3\n1\n4 0\n"))
+	fn := HandleErrors(func(args []interface{}) error {
+		events = append(events, fmt.Sprint("fn ", args))
+		if args[1] == 0 {
+			return fmt.Errorf("zero")
+		}
+		return nil
+	})
+	catchExit(func() { Main(fn) })
+	expected := []string{
+		`before ["1" "2"]`, "fn [1 2]", "inner", "after [1 2] <nil>",
+		`before ["x" "3"]`, `error "x" "x" is not a whole number`, "inner",
+		`after [] "x" is not a whole number`,
+		`before ["1"]`, "inner", "after [] too few arguments",
+		`before ["4" "0"]`, "fn [4 0]", "inner", "after [4 0] zero",
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("hooks were called with\n%s\nexpected\n%s",
+			strings.Join(events, "\n"), strings.Join(expected, "\n"))
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxRequestBody is the largest request body that HTTPHandler reads, so that
+// a client cannot make the server hold an unbounded amount of input.
+const maxRequestBody = 10 << 20
+
+// HTTPHandler returns an http.Handler that exposes the program as an HTTP
+// endpoint, turning a program that processes lines of standard input into a
+// small web service. Each request is parsed in the same way as command-line
+// arguments or lines of standard input and passed to fn, and the output that fn
+// writes to Output becomes the response.
+//
+// For GET requests, the arguments are taken from the query parameters named
+// after them (see SetNames). Alternatively, the "args" parameter can hold all
+// of them, tokenized like a line of standard input. For POST requests, each
+// line of the body is a separate invocation of fn, tokenized by the Tokenizer
+// if one is set (see SetTokenizer). Bodies over 10 MiB are rejected with status
+// 413. If any arguments fail to
+// parse, the response has status 400 and contains the error messages. If fn
+// calls Fail, the status is 500 instead, unless arguments also failed to parse.
+// If fn calls Skip for every line, the status is 204 and the response is empty,
+// and if it calls Stop, the remaining lines of that request are ignored. The
+// hooks (see BeforeInvoke) run for every line, as they do for Main. Requests
+// are handled concurrently, each with Output returning its own response.
+//
+// Deprecated: Use Program.HTTPHandler instead.
+func HTTPHandler(fn func([]interface{})) http.Handler {
+	return std.HTTPHandler(fn)
+}
+
+// HTTPHandler is like the package-level HTTPHandler, but for p.
+func (p *Program) HTTPHandler(fn func([]interface{})) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var lines []record
+		switch req.Method {
+		case "GET", "HEAD":
+			lines = []record{{tokens: p.queryArgs(req.URL.Query())}}
+		case "POST":
+			body := http.MaxBytesReader(w, req.Body, maxRequestBody)
+			scanner := p.newLineScanner(body)
+			for scanner.Scan() {
+				rec := p.shellRecord(scanner.Bytes())
+				// The rest refers to the scanner's buffer.
+				if rec.rest != nil {
+					rec.rest = append([]byte(nil), rec.rest...)
+				}
+				lines = append(lines, rec)
+			}
+			if err := scanner.Err(); err != nil {
+				status := http.StatusBadRequest
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					status = http.StatusRequestEntityTooLarge
+				}
+				http.Error(w, err.Error(), status)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, HEAD, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var out bytes.Buffer
+		r := p.newRun()
+		r.output = &out
+		defer r.enter()()
+		status := http.StatusOK
+		var errs []string
+		skipped := 0
+		for n, rec := range lines {
+			if r.stopping() {
+				break
+			}
+			r.beforeInvoke(rec.tokens)
+			parsed, err := r.parseRecord(rec)
+			if err != nil {
+				r.parseError(err)
+				status = http.StatusBadRequest
+			} else {
+				var skip bool
+				skip, err = r.callFn(fn, parsed)
+				if skip {
+					skipped++
+				}
+				if err != nil && status == http.StatusOK {
+					status = http.StatusInternalServerError
+				}
+			}
+			r.afterInvoke(parsed, err)
+			if err != nil {
+				msg := err.Error()
+				if len(lines) > 1 {
+					msg = fmt.Sprintf("line %d: %s", n+1,
+						strings.Replace(msg, "\n", "\n  ", -1))
+				}
+				errs = append(errs, msg)
+			}
+		}
+		switch {
+		case errs != nil:
+			http.Error(w, strings.Join(errs, "\n"), status)
+		case skipped > 0 && skipped == len(lines):
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.Write(out.Bytes())
+		}
+	})
+}
+
+// queryArgs returns the arguments given by the query parameters q.
+func (p *Program) queryArgs(q url.Values) []string {
+	if all, ok := q["args"]; ok {
+		return tokenize([]byte(strings.Join(all, " "))).strings()
+	}
+	var args []string
+	for i := range p.parsers {
+		v, ok := q[p.argName(i)]
+		if !ok {
+			break
+		}
+		if p.isRest(i) {
+			for _, s := range v {
+				args = append(args, tokenize([]byte(s)).strings()...)
+			}
+			continue
+		}
+		args = append(args, v[0])
+	}
+	return args
+}
@@ -0,0 +1,154 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+var httpTests = []struct {
+	method string
+	target string
+	body   string
+	code   int
+	output string
+}{
+	{"GET", "/?a=1&b=2", "", 200, "3\n"},
+	{"GET", "/?a=1", "", 200, "11\n"},
+	{"GET", "/?args=4+5", "", 200, "9\n"},
+	{"GET", "/?a=x&b=y", "", 400,
+		"\"x\" is not a whole number\n\"y\" is not a whole number\n"},
+	{"GET", "/", "", 400, "too few arguments\n"},
+	{"POST", "/", "1 2\n3 4\n", 200, "3\n7\n"},
+	{"POST", "/", "1 2\nx\n", 400, "line 2: \"x\" is not a whole number\n"},
+	{"PUT", "/", "", 405, "method not allowed\n"},
+	{"GET", "/?a=0&b=1", "", 500, "division by zero\n"},
+	{"POST", "/", "0 1\nx\n", 400,
+		"line 1: division by zero\nline 2: \"x\" is not a whole number\n"},
+	{"GET", "/?a=-1", "", 204, ""},
+	{"POST", "/", "-1\n1 2\n", 200, "3\n"},
+	{"POST", "/", "1 2\n200\n3 4\n", 200, "3\n"},
+}
+
+func TestHTTPHandler(t *testing.T) {
+	defer func() {
+		SetEveryParser(Parser{})
+		SetNames()
+	}()
+	SetParsers(Int, Int.Default("10"))
+	SetNames("a", "b")
+	h := HTTPHandler(func(args []interface{}) {
+		switch a := args[0].(int); {
+		case a == 0:
+			Fail(errors.New("division by zero"))
+		case a < 0:
+			Skip()
+		case a > 100:
+			Stop()
+		}
+		fmt.Fprintln(Output(), args[0].(int)+args[1].(int))
+	})
+	for i, test := range httpTests {
+		r := httptest.NewRequest(test.method, test.target,
+			strings.NewReader(test.body))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != test.code || w.Body.String() != test.output {
+			t.Errorf("%d. %s %s\nreturned %d %q\nexpected %d %q", i,
+				test.method, test.target, w.Code, w.Body.String(), test.code,
+				test.output)
+		}
+	}
+	if Output() != os.Stdout {
+		t.Error("Output() was not restored to os.Stdout")
+	}
+}
+
+func TestHTTPHandlerHooks(t *testing.T) {
+	p := New()
+	p.SetParsers(Int)
+	var before, failed int
+	p.BeforeInvoke(func([]string) { before++ })
+	p.AfterInvoke(func(_ []interface{}, err error) {
+		if err != nil {
+			failed++
+		}
+	})
+	h := p.HTTPHandler(func(args []interface{}) {
+		if args[0].(int) == 0 {
+			Fail(errors.New("zero"))
+		}
+	})
+	r := httptest.NewRequest("POST", "/", strings.NewReader("1\n0\nx\n"))
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if before != 3 || failed != 2 {
+		t.Errorf("hooks saw %d invocations and %d failures, expected 3 and 2",
+			before, failed)
+	}
+}
+
+func TestHTTPHandlerTokenizer(t *testing.T) {
+	p := New()
+	p.SetParsers(Int, Int)
+	p.SetTokenizer(DelimiterTokenizer(","))
+	h := p.HTTPHandler(func(args []interface{}) {
+		fmt.Fprintln(Output(), args[0].(int)+args[1].(int))
+	})
+	r := httptest.NewRequest("POST", "/", strings.NewReader("1,2\n3,4\n"))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != 200 || w.Body.String() != "3\n7\n" {
+		t.Errorf("POST with a custom Tokenizer returned %d %q, expected "+
+			"200 %q", w.Code, w.Body.String(), "3\n7\n")
+	}
+}
+
+func TestHTTPHandlerBodyLimit(t *testing.T) {
+	p := New()
+	p.SetParsers(String)
+	calls := 0
+	h := p.HTTPHandler(func([]interface{}) { calls++ })
+	line := strings.Repeat("x", 1000) + "\n"
+	body := strings.Repeat(line, maxRequestBody/len(line)+1)
+	r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != 413 || calls != 0 {
+		t.Errorf("POST with a large body returned %d after %d calls, "+
+			"expected 413 after 0", w.Code, calls)
+	}
+}
+
+func TestHTTPHandlerConcurrently(t *testing.T) {
+	p := New()
+	p.SetParsers(Int)
+	var started sync.WaitGroup
+	started.Add(2)
+	h := p.HTTPHandler(func(args []interface{}) {
+		// Both requests are inside fn before either writes its output.
+		started.Done()
+		started.Wait()
+		fmt.Fprintln(Output(), args[0])
+	})
+	var done sync.WaitGroup
+	for _, n := range []string{"1", "2"} {
+		done.Add(1)
+		go func(n string) {
+			defer done.Done()
+			r := httptest.NewRequest("GET", "/?args="+n, nil)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Body.String() != n+"\n" {
+				t.Errorf("GET %s returned %q, expected %q", n,
+					w.Body.String(), n+"\n")
+			}
+		}(n)
+	}
+	done.Wait()
+}
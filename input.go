@@ -0,0 +1,266 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// SetFilesMode enables or disables files mode. In files mode, the command-line
+// arguments are not passed to fn. Instead, they are treated as the names of
+// files, and each line of each file is parsed and passed to fn in the same way
+// as lines of standard input, like the cat command. The name "-" stands for
+// standard input, and names beginning with "http://" or "https://" are
+// downloaded. With no arguments, lines are read from standard input as usual.
+//
+// Deprecated: Use Program.SetFilesMode instead.
+func SetFilesMode(on bool) {
+	std.SetFilesMode(on)
+}
+
+// SetFilesMode is like the package-level SetFilesMode, but for p.
+func (p *Program) SetFilesMode(on bool) {
+	p.filesMode = on
+}
+
+// SetInputURL makes the program read lines from the given URL instead of from
+// standard input when it is invoked without arguments. The body of the response
+// is streamed through the line scanner as it is downloaded.
+//
+// Input from URLs and files is decompressed automatically if it is compressed
+// with gzip.
+//
+// Deprecated: Use Program.SetInputURL instead.
+func SetInputURL(url string) {
+	std.SetInputURL(url)
+}
+
+// SetInputURL is like the package-level SetInputURL, but for p.
+func (p *Program) SetInputURL(url string) {
+	p.inputURL = url
+}
+
+// isURL returns true if name should be downloaded rather than opened as a file.
+func isURL(name string) bool {
+	return strings.HasPrefix(name, "http://") ||
+		strings.HasPrefix(name, "https://")
+}
+
+// An Opener opens the input called name for reading. The name "-" stands for
+// standard input, and other names are file names or URLs given as arguments in
+// files mode (see SetFilesMode) or to SetInputURL.
+type Opener func(name string) (io.ReadCloser, error)
+
+// SetOpener makes the program open its inputs, including standard input, with o
+// instead of reading them from the file system, the network, and the process's
+// standard input. This lets the program run where those are not available, as
+// in a browser when it is compiled to WebAssembly (see JSOpener), or read from
+// other kinds of storage. Input opened by o is still decompressed if it is
+// compressed with gzip. If o is nil, which is the default, inputs are opened
+// normally.
+//
+// Deprecated: Use Program.SetOpener instead.
+func SetOpener(o Opener) {
+	std.SetOpener(o)
+}
+
+// SetOpener is like the package-level SetOpener, but for p.
+func (p *Program) SetOpener(o Opener) {
+	p.opener = o
+}
+
+// openInput opens the file or URL called name for reading, decompressing it if
+// necessary. The name "-" stands for standard input.
+func (r *run) openInput(name string) (io.ReadCloser, error) {
+	var rc io.ReadCloser
+	switch {
+	case r.opener != nil:
+		var err error
+		if rc, err = r.opener(name); err != nil {
+			return nil, err
+		}
+	case name == "-":
+		in, err := r.stdin()
+		if err != nil {
+			return nil, err
+		}
+		rc = io.NopCloser(in)
+	case isURL(name):
+		resp, err := http.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("%s: %s", name, resp.Status)
+		}
+		rc = resp.Body
+	default:
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		if r.mmapMode {
+			if m := mapFile(f); m != nil {
+				return m, nil
+			}
+		}
+		rc = f
+	}
+	return gunzip(rc)
+}
+
+// gunzipReader is a gzip.Reader that also closes its source when closed.
+type gunzipReader struct {
+	*gzip.Reader
+	source io.Closer
+}
+
+func (r gunzipReader) Close() error {
+	r.Reader.Close()
+	return r.source.Close()
+}
+
+// gunzip returns a reader that decompresses rc if it starts with the gzip magic
+// number, or otherwise returns its contents unchanged.
+func gunzip(rc io.ReadCloser) (io.ReadCloser, error) {
+	br := bufio.NewReader(rc)
+	magic, _ := br.Peek(2)
+	if len(magic) < 2 || magic[0] != 0x1f || magic[1] != 0x8b {
+		return struct {
+			io.Reader
+			io.Closer
+		}{br, rc}, nil
+	}
+	zr, err := gzip.NewReader(br)
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+	return gunzipReader{zr, rc}, nil
+}
+
+// mapInput opens the file or URL called name and processes its lines like
+// mapLines. Errors are prefixed by the name and line number. It returns true if
+// the input was opened and all of its lines were parsed successfully.
+func (r *run) mapInput(fn func([]interface{}), name string) bool {
+	l := log.New(r.diagnostics, r.name+": "+name+":", 0)
+	r.reportInput(name)
+	rc, err := r.openInput(name)
+	if err != nil {
+		r.noteError("", err)
+		r.log.Println(err)
+		return false
+	}
+	defer rc.Close()
+	in, done := r.watchInput(name, rc)
+	defer done()
+	return r.mapReader(fn, in, l, 1)
+}
+
+// SetJobs sets the maximum number of files that are processed at the same time
+// in files mode (see SetFilesMode). If n is zero or negative, the limit is the
+// number of CPUs. It is 1 by default, so files are processed one after another
+// and the order of fn's output matches the order of the input. With a larger
+// limit, fn is called concurrently from multiple goroutines and must be safe
+// for that. Error messages include the file name and line number, so they can
+// still be attributed when they are interleaved. The user can lower the limit
+// with the built-in flag "--jobs=n" (see SetBuiltinFlags), but not raise it,
+// since fn might not be safe for concurrent use.
+//
+// Deprecated: Use Program.SetJobs instead.
+func SetJobs(n int) {
+	std.SetJobs(n)
+}
+
+// SetJobs is like the package-level SetJobs, but for p.
+func (p *Program) SetJobs(n int) {
+	p.jobs = n
+}
+
+// jobLimit returns the maximum number of files to process at the same time,
+// taking into account the limit given by the built-in flag "--jobs".
+func (p *Program) jobLimit() int {
+	if p.deterministic {
+		return 1
+	}
+	limit := p.jobs
+	if limit <= 0 {
+		limit = runtime.NumCPU()
+	}
+	if p.jobsFlag > 0 && p.jobsFlag < limit {
+		return p.jobsFlag
+	}
+	return limit
+}
+
+// mapFiles processes each file or URL named in names. It processes up to
+// jobLimit of them concurrently, starting them in order. It returns true if all
+// of them were processed successfully.
+func (r *run) mapFiles(fn func([]interface{}), names []string) bool {
+	if r.jobLimit() > 1 && len(names) > 1 {
+		return r.mapFilesConcurrently(fn, names)
+	}
+	if len(names) == 1 && r.splitFiles && r.jobLimit() > 1 && !r.checksums {
+		if success, ok := r.mapSplit(fn, names[0]); ok {
+			return success
+		}
+	}
+	success := true
+	for _, name := range names {
+		if r.stopping() {
+			break
+		}
+		if !r.mapInput(fn, name) {
+			success = false
+			if !r.keepGoing {
+				break
+			}
+		}
+	}
+	return success
+}
+
+// mapFilesConcurrently is like mapFiles, but it processes the files in separate
+// goroutines. Unless keepGoing is true, it stops starting new ones after one
+// fails, but it waits for the ones already started.
+func (r *run) mapFilesConcurrently(fn func([]interface{}),
+	names []string) bool {
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		success = true
+	)
+	sem := make(chan struct{}, r.jobLimit())
+	for _, name := range names {
+		mu.Lock()
+		stop := !success && !r.keepGoing || r.stopping()
+		mu.Unlock()
+		if stop {
+			break
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			ok := r.mapInput(fn, name)
+			<-sem
+			if !ok {
+				mu.Lock()
+				success = false
+				mu.Unlock()
+			}
+		}(name)
+	}
+	wg.Wait()
+	return success
+}
@@ -0,0 +1,128 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func gzipped(s string) []byte {
+	var b bytes.Buffer
+	w := gzip.NewWriter(&b)
+	io.WriteString(w, s)
+	w.Close()
+	return b.Bytes()
+}
+
+func TestOpenInput(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/plain":
+				io.WriteString(w, "1 2\n")
+			case "/gz":
+				w.Write(gzipped("3 4\n"))
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+	defer server.Close()
+	dir := t.TempDir()
+	file := filepath.Join(dir, "data.gz")
+	os.WriteFile(file, gzipped("5\n6\n"), 0600)
+
+	defer SetEveryParser(Parser{})
+	SetEveryParser(Int)
+	var got []int
+	fn := func(args []interface{}) {
+		got = append(got, AssertInts(args)...)
+	}
+	names := []string{server.URL + "/plain", server.URL + "/gz", file}
+	if !std.newRun().mapFiles(fn, names) {
+		t.Error("mapFiles returned false")
+	}
+	if expected := []int{1, 2, 3, 4, 5, 6}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("mapFiles passed %v\nexpected %v", got, expected)
+	}
+	if _, err := std.newRun().openInput(server.URL + "/missing"); err == nil {
+		t.Error("openInput succeeded for a missing URL")
+	}
+}
+
+func TestMapFilesConcurrently(t *testing.T) {
+	defer func() {
+		SetJobs(1)
+		SetEveryParser(Parser{})
+		SetStreams(Streams{os.Stdout, os.Stderr, nil})
+	}()
+	dir := t.TempDir()
+	var names []string
+	for i := 0; i < 8; i++ {
+		name := filepath.Join(dir, fmt.Sprintf("%d.txt", i))
+		data := fmt.Sprintf("%d\n%d\n", i, i+10)
+		if i == 5 {
+			data += "x\n"
+		}
+		os.WriteFile(name, []byte(data), 0600)
+		names = append(names, name)
+	}
+	var diags bytes.Buffer
+	SetStreams(Streams{os.Stdout, &diags, nil})
+	SetEveryParser(Int)
+	SetJobs(3)
+	var mu sync.Mutex
+	var got []int
+	fn := func(args []interface{}) {
+		mu.Lock()
+		got = append(got, AssertInts(args)...)
+		mu.Unlock()
+	}
+	if std.newRun().mapFiles(fn, names) {
+		t.Error("mapFiles returned true, expected false")
+	}
+	sort.Ints(got)
+	expected := []int{0, 1, 2, 3, 4, 5, 6, 7, 10, 11, 12, 13, 14, 15, 16, 17}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("mapFiles passed %v\nexpected %v", got, expected)
+	}
+	if s := diags.String(); !strings.Contains(s, names[5]+":3: ") {
+		t.Errorf("error %q does not name the file and line", s)
+	}
+}
+
+func TestOpener(t *testing.T) {
+	defer SetOpener(nil)
+	inputs := map[string]string{"-": "stdin\n", "a": "x y\n"}
+	SetOpener(func(name string) (io.ReadCloser, error) {
+		s, ok := inputs[name]
+		if !ok {
+			return nil, fmt.Errorf("%s: no such input", name)
+		}
+		return io.NopCloser(strings.NewReader(s)), nil
+	})
+	for name, expected := range inputs {
+		rc, err := std.newRun().openInput(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data, _ := io.ReadAll(rc); string(data) != expected {
+			t.Errorf("input %q has %q, expected %q", name, data, expected)
+		}
+	}
+	_, err := std.newRun().openInput("b")
+	if err == nil || err.Error() != "b: no such input" {
+		t.Errorf("opening a missing input returned %v", err)
+	}
+}
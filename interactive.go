@@ -0,0 +1,116 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SetPrompt sets the prompt that is printed to standard error before each line
+// that the user types when the program reads arguments interactively from a
+// terminal, such as "calc> ". There is no prompt by default.
+//
+// Deprecated: Use Program.SetPrompt instead.
+func SetPrompt(s string) {
+	std.SetPrompt(s)
+}
+
+// SetPrompt is like the package-level SetPrompt, but for p.
+func (p *Program) SetPrompt(s string) {
+	p.prompt = s
+}
+
+// SetContinuationPrompt sets the prompt printed instead of the one given to
+// SetPrompt when a line continues the previous one, which happens when a
+// newline is escaped with a backslash or occurs inside quotation marks. It is
+// "> " by default, like the PS2 prompt in shells.
+//
+// Deprecated: Use Program.SetContinuationPrompt instead.
+func SetContinuationPrompt(s string) {
+	std.SetContinuationPrompt(s)
+}
+
+// SetContinuationPrompt is like the package-level SetContinuationPrompt, but
+// for p.
+func (p *Program) SetContinuationPrompt(s string) {
+	p.contPrompt = s
+}
+
+// promptReader is a wrapper for another io.Reader that writes a prompt to w
+// before reading each new line from its source. It keeps track of escapes and
+// quotation marks using the same quoteState as scanLines so that it can tell
+// when a line is a continuation of the previous one.
+type promptReader struct {
+	source     io.Reader
+	w          io.Writer
+	prompt     string // printed before each new line
+	contPrompt string // printed before a line that continues the previous one
+	midLine    bool   // the last read did not end with a newline
+	cont       bool   // the next line continues the current token
+	state      quoteState
+}
+
+// newPromptReader returns a promptReader that reads from in, which is standard
+// input, and prints the prompts of p to its diagnostics stream.
+func (p *Program) newPromptReader(in io.Reader) *promptReader {
+	return &promptReader{source: in, w: p.diagnostics, prompt: p.prompt,
+		contPrompt: p.contPrompt}
+}
+
+// currentPrompt returns the prompt that should be shown for the next line.
+func (r *promptReader) currentPrompt() string {
+	if r.cont {
+		return r.contPrompt
+	}
+	return r.prompt
+}
+
+func (r *promptReader) Read(data []byte) (n int, err error) {
+	if !r.midLine {
+		io.WriteString(r.w, r.currentPrompt())
+	}
+	n, err = r.source.Read(data)
+	if n == 0 {
+		if err == io.EOF {
+			// Leave the cursor at the start of a line for the shell.
+			io.WriteString(r.w, "\n")
+		}
+		return
+	}
+	r.update(data[:n])
+	return
+}
+
+// update updates the state of the reader after reading data.
+func (r *promptReader) update(data []byte) {
+	escapedNewline := false
+	for _, c := range data {
+		escapedNewline = r.state.escaped && c == '\n'
+		r.state.step(c)
+	}
+	r.midLine = data[len(data)-1] != '\n'
+	r.cont = r.state.quote != 0 || escapedNewline
+}
+
+// canAsk returns true if the value for q can be asked for when it is missing.
+func (p *Program) canAsk(q Parser) bool {
+	return !q.isZero() && q.info().question != "" && p.stdinIsTerminal()
+}
+
+// ask prints the question for q to the diagnostics stream and returns the
+// answer read from standard input. An empty answer becomes "n".
+func (r *run) ask(q Parser) string {
+	fmt.Fprint(r.diagnostics, q.info().question)
+	in, err := r.stdin()
+	if err != nil {
+		return "n"
+	}
+	line, _ := bufio.NewReader(in).ReadString('\n')
+	if answer := strings.TrimSpace(line); answer != "" {
+		return answer
+	}
+	return "n"
+}
@@ -0,0 +1,57 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+var promptTests = []struct {
+	input   string
+	prompts string
+}{
+	{"", "$ \n"},
+	{"a\n", "$ $ \n"},
+	{"a b\nc\n", "$ $ $ \n"},
+	{"'a\nb'\nc\n", "$ > $ $ \n"},
+	{`a\` + "\nb\n", "$ > $ \n"},
+	{`"a\"` + "\n" + `b"` + "\n", "$ > $ \n"},
+	{`\\` + "\na\n", "$ $ $ \n"},
+}
+
+func TestPromptReader(t *testing.T) {
+	defer SetPrompt("")
+	SetPrompt("$ ")
+	for i, test := range promptTests {
+		var w bytes.Buffer
+		r := std.newPromptReader(
+			iotest.OneByteReader(strings.NewReader(test.input)))
+		r.w = &w
+		scanner := std.newLineScanner(r)
+		for scanner.Scan() {
+		}
+		if w.String() != test.prompts {
+			t.Errorf("%d. reading %q\nprompted %q\nexpected %q", i,
+				test.input, w.String(), test.prompts)
+		}
+	}
+}
+
+func TestAsk(t *testing.T) {
+	defer func(s Streams) {
+		SetStreams(s)
+		SetInput(nil)
+	}(CurrentStreams())
+	var diag bytes.Buffer
+	SetErrorOutput(&diag)
+	SetInput(strings.NewReader("yes\n"))
+	if answer := std.newRun().ask(Confirm); answer != "yes" {
+		t.Errorf("ask returned %q, expected \"yes\"", answer)
+	}
+	if diag.String() != "Proceed? [y/N] " {
+		t.Errorf("ask wrote %q to the diagnostics stream", diag.String())
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// A Result describes a run of a program by Invoke.
+type Result struct {
+	ExitCode int // the exit status that the program would have had
+	Lines    int // the number of lines of input processed
+	Failed   int // the number of lines that failed
+	Skipped  int // the number of lines that fn skipped (see Skip)
+}
+
+// An ExitError is returned by Invoke when the program would have exited with a
+// nonzero status.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// Invoke runs p with fn like p.Main would, but in-process and without exiting,
+// so that one Go program can drive another program that uses parse, to compose
+// tools or to test them from end to end. The program receives argv as its
+// command-line arguments, not including its name, and reads stdin as standard
+// input, which is treated as a pipe unless it is os.Stdin itself, in which case
+// it can be a terminal like it can for Main. If stdin is nil, standard input is
+// empty. Results and diagnostics are written to stdout and stderr. Built-in
+// flags in argv, such as "--keep-going", apply to the run only.
+//
+// Invoke returns an ExitError if the program would have exited with a nonzero
+// status, along with the Result. The parts of parse that concern the whole
+// process, namely the handlers registered with OnExit, the report (see
+// SetReport), the temporary directory (see TempDir), and the lock (see
+// Exclusive), are left to the process that calls Invoke.
+func Invoke(p *Program, fn func([]interface{}), argv []string, stdin io.Reader,
+	stdout, stderr io.Writer) (res Result, err error) {
+	q := *p
+	q.output, q.diagnostics = orDiscard(stdout), orDiscard(stderr)
+	q.logger = nil
+	if argv == nil {
+		argv = []string{}
+	}
+	if stdin == nil {
+		stdin = strings.NewReader("")
+	}
+	q.commandLineOverride, q.stdinOverride = argv, stdin
+	if stdin == io.Reader(os.Stdin) {
+		q.stdinOverride = nil
+	}
+	r := q.newRun()
+	r.invoking, r.returning = true, true
+	defer func() {
+		res.Lines = int(r.counts.lines.Load())
+		res.Failed = int(r.counts.failed.Load())
+		res.Skipped = int(r.counts.skipped.Load())
+		if v := recover(); v != nil {
+			code, ok := v.(invokeExit)
+			if !ok {
+				panic(v)
+			}
+			res.ExitCode = int(code)
+			if sig := r.signalled.Load(); sig != 0 {
+				res.ExitCode = int(sig)
+			}
+			err = &ExitError{res.ExitCode}
+		}
+	}()
+	r.main(fn)
+	r.exitIfSignalled()
+	return res, nil
+}
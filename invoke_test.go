@@ -0,0 +1,55 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+var invokeTests = []struct {
+	argv     []string
+	stdin    string
+	stdout   string
+	stderr   string
+	expected Result
+}{
+	{[]string{"1", "2"}, "", "3\n", "", Result{}},
+	{[]string{"1"}, "", "", "usage: adder a b\n", Result{ExitCode: 1}},
+	{nil, "1 2\n3 4\n", "3\n7\n", "", Result{Lines: 2}},
+	{nil, "1 2\nx 4\n", "3\n", "adder: \"x\" is not a whole number\n",
+		Result{ExitCode: 1, Lines: 2, Failed: 1}},
+	{[]string{"-q"}, "x 4\n5 5\n", "10\n", "",
+		Result{ExitCode: 1, Lines: 2, Failed: 1}},
+}
+
+func TestInvoke(t *testing.T) {
+	p := New()
+	p.SetName("adder")
+	p.SetVerbosityFlags(true)
+	p.SetParsers(Int, Int)
+	p.SetNames("a", "b")
+	fn := func(args []interface{}) {
+		fmt.Fprintln(Output(), args[0].(int)+args[1].(int))
+	}
+	for i, test := range invokeTests {
+		var stdout, stderr bytes.Buffer
+		res, err := Invoke(p, fn, test.argv, strings.NewReader(test.stdin),
+			&stdout, &stderr)
+		if res != test.expected || stdout.String() != test.stdout ||
+			stderr.String() != test.stderr {
+			t.Errorf("%d. Invoke(%q) = %+v, wrote %q and %q\n"+
+				"expected %+v, %q and %q", i, test.argv, res, stdout.String(),
+				stderr.String(), test.expected, test.stdout, test.stderr)
+		}
+		if (err != nil) != (res.ExitCode != 0) {
+			t.Errorf("%d. Invoke returned error %v with exit status %d", i,
+				err, res.ExitCode)
+		}
+	}
+	if p.verbosity != Normal {
+		t.Errorf("Invoke with -q left verbosity at %v", p.verbosity)
+	}
+}
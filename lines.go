@@ -0,0 +1,47 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+//go:build go1.23
+
+package parse
+
+import (
+	"iter"
+)
+
+// Lines returns an iterator over the invocations of the program, so that it
+// can pull parsed arguments on demand instead of having Main call a function
+// for each of them. It reads from the same places as Main and Collect, and
+// like Collect, it never prints anything or exits. For example:
+//
+//	for args, err := range parse.Lines() {
+//		if err != nil {
+//			log.Println(err)
+//			continue
+//		}
+//		...
+//	}
+//
+// The error for a line that fails is a *LineError, after which the iteration
+// continues with the next line. Other errors, such as for a file that cannot
+// be opened, end the iteration. The program can stop early by breaking out of
+// the loop. With no input to read, the command-line arguments are yielded as
+// the only invocation, with the error returned by Parse if they fail.
+//
+// Deprecated: Use Program.Lines instead.
+func Lines() iter.Seq2[[]interface{}, error] {
+	return std.Lines()
+}
+
+// Lines is like the package-level Lines, but it reads the input of p.
+func (p *Program) Lines() iter.Seq2[[]interface{}, error] {
+	return func(yield func([]interface{}, error) bool) {
+		r := p.newRun()
+		args := r.stripFlags(append(r.envArgs(), r.commandLine()...))
+		names := r.inputNames(args)
+		if names == nil {
+			yield(r.parseArgs(args))
+			return
+		}
+		r.eachInput(names, yield)
+	}
+}
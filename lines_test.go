@@ -0,0 +1,51 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+//go:build go1.23
+
+package parse
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	defer func(args []string) {
+		os.Args = args
+		SetEveryParser(Parser{})
+		SetFilesMode(false)
+	}(os.Args)
+	name := filepath.Join(t.TempDir(), "input")
+	os.WriteFile(name, []byte("1 2\nx\n3\n4\n5\n"), 0600)
+	SetEveryParser(Int)
+	SetFilesMode(true)
+	os.Args = []string{"prog", name}
+	var got [][]interface{}
+	var errs []error
+	for args, err := range Lines() {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		got = append(got, args)
+		if args[0] == 4 {
+			break
+		}
+	}
+	expected := [][]interface{}{{1, 2}, {3}, {4}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Lines yielded %v\nexpected %v", got, expected)
+	}
+	if len(errs) != 1 || errs[0].(*LineError).Line != 2 {
+		t.Errorf("Lines yielded errors %v", errs)
+	}
+	SetFilesMode(false)
+	os.Args = []string{"prog", "7", "8"}
+	for args, err := range Lines() {
+		if err != nil || !reflect.DeepEqual(args, []interface{}{7, 8}) {
+			t.Errorf("Lines yielded %v, %v for arguments", args, err)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"log"
+	"net"
+	"time"
+)
+
+// Listen listens on the network address addr and processes lines received over
+// each connection in the same way as lines of standard input, so that a program
+// can act as a server on a Unix socket or TCP port. The network must be a
+// stream-oriented one such as "tcp" or "unix" (see net.Listen).
+//
+// Connections are handled concurrently, and so are the calls to fn for
+// different connections. While fn runs, Output returns the connection, so that
+// the results go back to the client that sent the line. Errors are also
+// reported to that client, prefixed by "error: ", and a connection with errors
+// is logged by the server. Each connection is processed separately, so a call
+// to Stop only closes the connection that sent the line, without waiting for
+// the client to close it, and the server keeps accepting others. Listen only
+// returns if accepting a connection fails.
+//
+// Deprecated: Use Program.Listen instead.
+func Listen(network, addr string, fn func([]interface{})) error {
+	return std.Listen(network, addr, fn)
+}
+
+// Listen is like the package-level Listen, but for p.
+func (p *Program) Listen(network, addr string, fn func([]interface{})) error {
+	ln, err := net.Listen(network, addr)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+	return p.serve(ln, fn)
+}
+
+// serve accepts connections from ln and handles each one in a new goroutine.
+func (p *Program) serve(ln net.Listener, fn func([]interface{})) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		go p.serveConn(conn, fn)
+	}
+}
+
+// serveConn processes the lines received over conn and then closes it. The
+// connection has a run of its own, so that Stop only ends this connection.
+func (p *Program) serveConn(conn net.Conn, fn func([]interface{})) {
+	defer conn.Close()
+	r := p.newRun()
+	r.source = PipeSource
+	r.output = conn
+	defer r.enter()()
+	f := func(args []interface{}) {
+		defer func() {
+			if v := recover(); v != nil {
+				if v == ErrStop {
+					// End the read that is waiting for the next line, so
+					// that the connection closes without the client's help.
+					conn.SetReadDeadline(time.Now())
+				}
+				panic(v)
+			}
+		}()
+		fn(args)
+	}
+	if !r.mapReader(f, conn, log.New(conn, "error: ", 0), 0) {
+		r.log.Printf("%s: some lines failed", conn.RemoteAddr())
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestServeConn(t *testing.T) {
+	defer SetEveryParser(Parser{})
+	SetEveryParser(Int)
+	client, server := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		std.serveConn(server, func(args []interface{}) {
+			fmt.Fprintln(Output(), len(args))
+		})
+		close(done)
+	}()
+	go func() {
+		io.WriteString(client, "1 2 3\nx\n4\n")
+	}()
+	expected := "3\nerror: \"x\" is not a whole number\n1\n"
+	buf := make([]byte, len(expected))
+	if _, err := io.ReadFull(client, buf); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != expected {
+		t.Errorf("connection received %q\nexpected %q", buf, expected)
+	}
+	client.Close()
+	<-done
+}
+
+func TestServeConnStop(t *testing.T) {
+	p := New()
+	p.SetEveryParser(Int)
+	fn := func(args []interface{}) {
+		if args[0] == 0 {
+			Stop()
+		}
+		fmt.Fprintln(Output(), args[0])
+	}
+	serve := func(input string) string {
+		client, server := net.Pipe()
+		go p.serveConn(server, fn)
+		go io.WriteString(client, input)
+		data, _ := io.ReadAll(client)
+		return string(data)
+	}
+	if got := serve("1\n0\n2\n"); got != "1\n" {
+		t.Errorf("first connection received %q, expected %q", got, "1\n")
+	}
+	if got := serve("3\n0\n"); got != "3\n" {
+		t.Errorf("second connection received %q after Stop, expected %q", got,
+			"3\n")
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"fmt"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+	"unicode"
+)
+
+// Float64Locale returns a Parser like Float64 that parses numbers formatted
+// according to the conventions of the locale identified by tag, such as
+// "1.234,5" for German and "1 234,5" for French. It also accepts the locale's
+// own digits and minus sign, and plain ASCII digits in their place. Locales
+// that group digits with a space accept any of " ", U+00A0, and U+202F.
+// Use language.Parse or language.MustParse to get the tag for a BCP 47 string.
+func Float64Locale(tag language.Tag) Parser {
+	return Float64.derive(localeSymbols(tag).wrap(Float64))
+}
+
+// spaceSeparators are the thousands separators accepted by locales that group
+// digits with a kind of space: an ASCII space, a no-break space, and a narrow
+// no-break space.
+const spaceSeparators = " \u00a0\u202f"
+
+// localeSymbols returns the symbols used to format numbers in the locale. They
+// are found by formatting sample numbers and picking them out of the result.
+func localeSymbols(tag language.Tag) numberSymbols {
+	p := message.NewPrinter(tag)
+	sym := numberSymbols{decimal: '.', zero: '0'}
+	if zero := []rune(p.Sprint(number.Decimal(0))); len(zero) == 1 {
+		sym.zero = zero[0]
+	}
+	// The sample has digits on both sides of each separator: "1,234,567.5".
+	// The distance between the first two separators is the size of the groups,
+	// which is 2 in locales like Hindi that write "12,34,567.5".
+	sample := []rune(p.Sprint(number.Decimal(1234567.5)))
+	separators := []int{}
+	for i := 1; i < len(sample)-1; i++ {
+		r := sample[i]
+		if sym.isDigit(r) {
+			continue
+		}
+		if i == len(sample)-2 {
+			sym.decimal = r
+		} else {
+			separators = append(separators, i)
+			sym.thousands = string(r)
+			if unicode.Is(unicode.Zs, r) {
+				// Locales that group with a space write it in different
+				// ways, such as U+00A0 or U+202F in French.
+				sym.thousands = spaceSeparators
+			}
+		}
+	}
+	if len(separators) >= 2 {
+		sym.group = separators[1] - separators[0] - 1
+	}
+	for _, r := range p.Sprint(number.Decimal(-1)) {
+		if r != '-' && !sym.isDigit(r) && !unicode.Is(unicode.Cf, r) {
+			sym.minus = r
+		}
+	}
+	return sym
+}
+
+// LanguageTag is a Parser that parses a BCP 47 language tag, such as "en-US"
+// or "zh-Hant-TW", as a language.Tag. The tag is canonicalized, so "EN_us" is
+// returned as en-US and the deprecated "iw" as he. Tags that are well formed
+// but use unknown subtags are rejected.
+var LanguageTag = NewParser(func(s string) (interface{}, error) {
+	tag, err := language.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a language tag", s)
+	}
+	return tag, nil
+}).withInfo(parserInfo{name: "lang", typ: "language tag"})
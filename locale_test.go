@@ -0,0 +1,75 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"golang.org/x/text/language"
+	"strings"
+	"testing"
+)
+
+var localeTests = []struct {
+	tag   string
+	input string
+	value interface{}
+}{
+	{"en", "1,234,567.5", 1234567.5},
+	{"en", "-0.25", -0.25},
+	{"de", "1.234.567,5", 1234567.5},
+	{"de", "1,234.5", nil},
+	{"fr", "1 234,5", 1234.5},
+	{"fr", "1 234,5", 1234.5},
+	{"fr", "1\u202f234\u202f567,5", 1234567.5},
+	{"fr", "1 23,5", nil},
+	{"de-CH", "1’234.5", 1234.5},
+	{"sv", "−1,5", -1.5},
+	{"sv", "-1,5", -1.5},
+	{"ar", "١٬٢٣٤٫٥", 1234.5},
+	{"ar", "؜-١٫٥", -1.5},
+	{"fa", "۱۲٫۵", 12.5},
+	{"hi", "12,34,567.5", 1234567.5},
+	{"hi", "1,234,567.5", nil},
+	{"en", "12,34,567.5", nil},
+}
+
+func TestFloat64Locale(t *testing.T) {
+	for i, test := range localeTests {
+		p := Float64Locale(language.MustParse(test.tag))
+		value, err := p.Parse(test.input)
+		if value != test.value {
+			t.Errorf("%d. Float64Locale(%s)(%q)\nreturned %s (%v)\nexpected %s",
+				i, test.tag, test.input, formatValue(value), err,
+				formatValue(test.value))
+		}
+		if err != nil && !strings.Contains(err.Error(), test.input) {
+			t.Errorf("%d. error %q does not show the input", i, err)
+		}
+	}
+}
+
+var languageTagTests = []struct {
+	input string
+	value string
+}{
+	{"en-US", "en-US"},
+	{"EN_us", "en-US"},
+	{"iw", "he"},
+	{"zh-hant-tw", "zh-Hant-TW"},
+	{"zz", ""},
+	{"not a tag", ""},
+	{"", ""},
+}
+
+func TestLanguageTag(t *testing.T) {
+	for i, test := range languageTagTests {
+		value, err := LanguageTag.Parse(test.input)
+		s := ""
+		if err == nil {
+			s = value.(language.Tag).String()
+		}
+		if s != test.value {
+			t.Errorf("%d. LanguageTag(%q) returned %q (%v), expected %q",
+				i, test.input, s, err, test.value)
+		}
+	}
+}
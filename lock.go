@@ -0,0 +1,69 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// ErrLocked is the error reported when another run holds the lock set by
+// Exclusive.
+var ErrLocked = errors.New("another run is in progress")
+
+// Exclusive makes Main take an exclusive lock on the file at lockPath, which it
+// creates if necessary, before it processes any arguments or input, and release
+// it when the program exits. If another run already holds the lock, the program
+// prints an error mentioning ErrLocked and exits with the runtime status (see
+// SetExitCodes) instead of waiting, so that batch tools started periodically,
+// as by cron, do not overlap when a previous run is still consuming its input.
+// On Unix, the lock is an flock, which the system releases even if the program
+// crashes. Elsewhere, the file itself is the lock and is removed on exit, so it
+// must be removed by hand after a crash. An empty path, the default, turns
+// locking off.
+//
+// Deprecated: Use Program.Exclusive instead.
+func Exclusive(path string) {
+	std.Exclusive(path)
+}
+
+// Exclusive is like the package-level Exclusive, but for p.
+func (p *Program) Exclusive(path string) {
+	p.lockPath = path
+}
+
+// heldLock is the file holding the lock taken by acquireLock, or nil.
+var (
+	heldLockMutex sync.Mutex
+	heldLock      *os.File
+)
+
+// acquireLock takes the lock set by Exclusive, if there is one.
+func (p *Program) acquireLock() error {
+	if p.lockPath == "" {
+		return nil
+	}
+	heldLockMutex.Lock()
+	defer heldLockMutex.Unlock()
+	f, err := lockFile(p.lockPath)
+	if err == ErrLocked {
+		return fmt.Errorf("%s: %w", p.lockPath, err)
+	}
+	if err != nil {
+		return err
+	}
+	heldLock = f
+	return nil
+}
+
+// releaseLock releases the lock taken by acquireLock, if there is one.
+func releaseLock() {
+	heldLockMutex.Lock()
+	defer heldLockMutex.Unlock()
+	if heldLock != nil {
+		unlockFile(heldLock)
+		heldLock = nil
+	}
+}
@@ -0,0 +1,23 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+//go:build !unix
+
+package parse
+
+import "os"
+
+// lockFile creates the file at path, which must not exist. It returns ErrLocked
+// if the file already exists.
+func lockFile(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+	if os.IsExist(err) {
+		return nil, ErrLocked
+	}
+	return f, err
+}
+
+// unlockFile releases a lock taken by lockFile by removing the file.
+func unlockFile(f *os.File) error {
+	f.Close()
+	return os.Remove(f.Name())
+}
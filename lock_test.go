@@ -0,0 +1,33 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestExclusive(t *testing.T) {
+	defer func() {
+		releaseLock()
+		Exclusive("")
+	}()
+	path := filepath.Join(t.TempDir(), "lock")
+	Exclusive(path)
+	if err := std.acquireLock(); err != nil {
+		t.Fatalf("acquireLock() = %v", err)
+	}
+	if _, err := lockFile(path); err != ErrLocked {
+		t.Errorf("lockFile while locked = %v, expected ErrLocked", err)
+	}
+	if err := std.acquireLock(); !errors.Is(err, ErrLocked) {
+		t.Errorf("second acquireLock() = %v, expected ErrLocked", err)
+	}
+	releaseLock()
+	f, err := lockFile(path)
+	if err != nil {
+		t.Fatalf("lockFile after releaseLock() = %v", err)
+	}
+	unlockFile(f)
+}
@@ -0,0 +1,35 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+//go:build unix
+
+package parse
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile opens or creates the file at path and takes an exclusive flock on
+// it without blocking. It returns ErrLocked if the file is already locked.
+func lockFile(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err != nil {
+		f.Close()
+		if err == syscall.EWOULDBLOCK {
+			return nil, ErrLocked
+		}
+		return nil, err
+	}
+	return f, nil
+}
+
+// unlockFile releases a lock taken by lockFile. The file is left in place,
+// since removing it could let two runs lock different files with the same name.
+func unlockFile(f *os.File) error {
+	syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+	return f.Close()
+}
@@ -0,0 +1,34 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+)
+
+// MIMEType is a Parser that accepts a MIME type, such as "text/plain" or
+// "text/html; charset=utf-8". It returns the type in canonical form, with the
+// type and parameter names in lower case, as formatted by the mime package.
+var MIMEType = NewParser(func(s string) (interface{}, error) {
+	mediaType, params, err := mime.ParseMediaType(s)
+	typ, subtype, ok := strings.Cut(mediaType, "/")
+	if err != nil || !ok || typ == "" || subtype == "" {
+		return nil, fmt.Errorf("%q is not a MIME type", s)
+	}
+	return mime.FormatMediaType(mediaType, params), nil
+}).withInfo(parserInfo{name: "type/subtype", typ: "mime type"})
+
+// FileExt is a Parser that accepts a file extension, with or without the
+// leading dot. It returns the extension with the dot, so that "png" and ".png"
+// are both returned as ".png", which is the form used by filepath.Ext. The
+// extension cannot contain slashes.
+var FileExt = NewParser(func(s string) (interface{}, error) {
+	ext := strings.TrimPrefix(s, ".")
+	if ext == "" || strings.ContainsAny(ext, `/\`) ||
+		strings.HasPrefix(ext, ".") {
+		return nil, fmt.Errorf("%q is not a file extension", s)
+	}
+	return "." + ext, nil
+}).withInfo(parserInfo{name: "ext", typ: "file extension"})
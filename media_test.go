@@ -0,0 +1,37 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import "testing"
+
+var mediaTests = []struct {
+	parser Parser
+	input  string
+	value  interface{}
+}{
+	{MIMEType, "text/plain", "text/plain"},
+	{MIMEType, "Text/HTML; Charset=utf-8", "text/html; charset=utf-8"},
+	{MIMEType, "application/vnd.api+json", "application/vnd.api+json"},
+	{MIMEType, "text", nil},
+	{MIMEType, "text/", nil},
+	{MIMEType, "/plain", nil},
+	{MIMEType, "text/plain; charset", nil},
+	{MIMEType, "", nil},
+	{FileExt, "png", ".png"},
+	{FileExt, ".png", ".png"},
+	{FileExt, "tar.gz", ".tar.gz"},
+	{FileExt, ".", nil},
+	{FileExt, "", nil},
+	{FileExt, "..png", nil},
+	{FileExt, "a/b", nil},
+}
+
+func TestMedia(t *testing.T) {
+	for i, test := range mediaTests {
+		value, err := test.parser.Parse(test.input)
+		if value != test.value {
+			t.Errorf("%d. %q returned %s (%v), expected %s", i, test.input,
+				formatValue(value), err, formatValue(test.value))
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+// parserInfo holds optional metadata about a Parser.
+type parserInfo struct {
+	name       string // placeholder for the argument in the usage message
+	literal    bool   // whether name lists the accepted values
+	typ        string // name of the parsed type in the schema
+	example    string // example of a valid argument
+	help       string // description of the argument
+	def        string // parsed when the argument is missing
+	hasDefault bool   // whether def is used
+	suggest    func(prefix string) []string
+	question   string // asked at a terminal when the argument is missing
+}
+
+// info returns a copy of the metadata for p. It is empty if p has none.
+func (p Parser) info() parserInfo {
+	if p.meta == nil {
+		return parserInfo{}
+	}
+	return *p.meta
+}
+
+// withInfo returns a Parser that parses strings in the same way as p, which
+// must not be the zero Parser, with info as its metadata.
+func (p Parser) withInfo(info parserInfo) Parser {
+	p.meta = &info
+	return p
+}
+
+// derive returns a Parser that parses strings with fn and has the same
+// metadata as p. It should be used by functions that create a new Parser by
+// wrapping an existing one.
+func (p Parser) derive(fn func(string) (interface{}, error)) Parser {
+	return Parser{fn: fn, meta: p.meta}
+}
+
+// with returns a new Parser that behaves exactly like p, or like String if p
+// is the zero Parser, with metadata that has been modified by f.
+func (p Parser) with(f func(info *parserInfo)) Parser {
+	q := p.orString()
+	info := q.info()
+	f(&info)
+	return q.withInfo(info)
+}
+
+// Suggest returns the possible values for an argument parsed by p that begin
+// with prefix. It is used to complete arguments when the user presses Tab in
+// the line editor (see SetLineEditing). Suggest returns nil if p does not
+// provide any suggestions, which is the case unless it was created by a
+// function like Choice or WithSuggest.
+func (p Parser) Suggest(prefix string) []string {
+	if suggest := p.info().suggest; suggest != nil {
+		return suggest(prefix)
+	}
+	return nil
+}
+
+// WithSuggest returns a new Parser that parses strings in the same way as p
+// but uses fn for its Suggest method.
+func (p Parser) WithSuggest(fn func(prefix string) []string) Parser {
+	return p.with(func(info *parserInfo) { info.suggest = fn })
+}
+
+// Example returns a new Parser that parses strings in the same way as p, with
+// ex as an example of a valid argument. The example is shown in the help
+// message and appended to the errors for arguments that p fails to parse, which
+// helps users get the format right for arguments like durations and dates.
+func (p Parser) Example(ex string) Parser {
+	return p.with(func(info *parserInfo) { info.example = ex })
+}
+
+// Help returns a new Parser that parses strings in the same way as p, with text
+// as a description of the argument, which is shown in the help message.
+func (p Parser) Help(text string) Parser {
+	return p.with(func(info *parserInfo) { info.help = text })
+}
+
+// Default returns a new Parser that parses strings in the same way as p, but
+// that makes its argument optional. When the argument is missing, value is
+// parsed in its place. Only arguments at the end can be optional, so Default
+// has no effect if a later argument passed to SetParsers is required. It has
+// no effect with SetEveryParser, or for the last argument passed to
+// SetVariadic. Validate reports these mistakes.
+func (p Parser) Default(value string) Parser {
+	return p.with(func(info *parserInfo) {
+		info.def = value
+		info.hasDefault = true
+	})
+}
+
+// Describe returns the name of the type that p parses, such as "int" for Int,
+// and an example of a valid argument, either of which may be empty. The same
+// metadata is used in the help message, in error messages, in the schema, and
+// in the list printed by the hidden built-in flag "--types".
+func (p Parser) Describe() (typeName, example string) {
+	info := p.info()
+	return info.typ, info.example
+}
+
+// A Describer describes the type of argument that it parses. Parser implements
+// it, and so can the values passed to FromArgType.
+type Describer interface {
+	Describe() (typeName, example string)
+}
+
+// A Suggester suggests values for arguments to complete. Parser implements it,
+// and so can the values passed to FromArgType.
+type Suggester interface {
+	Suggest(prefix string) []string
+}
+
+// An ArgType parses a type of argument. It is an alternative to writing a
+// function for NewParser for types that also want to provide metadata by
+// implementing Describer or Suggester.
+type ArgType interface {
+	Parse(s string) (interface{}, error)
+}
+
+// FromArgType returns a Parser that parses strings with t.Parse. If t is also
+// a Describer, its type name and example become the Parser's metadata, and if
+// t is a Suggester, it is used for the Parser's Suggest method.
+func FromArgType(t ArgType) Parser {
+	var info parserInfo
+	if d, ok := t.(Describer); ok {
+		info.typ, info.example = d.Describe()
+		info.name = info.typ
+	}
+	if s, ok := t.(Suggester); ok {
+		info.suggest = s.Suggest
+	}
+	return NewParser(t.Parse).withInfo(info)
+}
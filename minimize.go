@@ -0,0 +1,129 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// errNoFailure is returned by runMinimize when the input does not fail.
+var errNoFailure = errors.New("the input does not fail")
+
+// runMinimize reads all the input with readInputs, reduces it with minimize,
+// and writes the remaining lines to the results stream (see Output), so that a
+// user can attach a few lines to a bug report instead of a whole data set. It
+// prints how many lines were removed to the diagnostics stream.
+func (r *run) runMinimize(fn func([]interface{}), args []string) error {
+	data, err := r.readInputs(args)
+	if err != nil {
+		return err
+	}
+	var recs []record
+	records := r.newRecordReader(bytes.NewReader(data))
+	for {
+		rec, err := records.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		recs = append(recs, rec)
+	}
+	if !r.fails(fn, recs) {
+		return errNoFailure
+	}
+	min := minimize(recs, func(recs []record) bool { return r.fails(fn, recs) })
+	for _, rec := range min {
+		r.output.Write(r.formatRecord(rec))
+	}
+	fmt.Fprintf(r.diagnostics, "minimized %d lines to %d\n", len(recs),
+		len(min))
+	return nil
+}
+
+// fails processes recs like lines of input, discarding the output of fn, and
+// returns true if any of them fails to parse, makes fn panic, or makes fn call
+// Fail. It stops at the first failure, and at the first call to Stop.
+func (r *run) fails(fn func([]interface{}), recs []record) (failed bool) {
+	defer func(w io.Writer) { r.output = w }(r.output)
+	r.output = io.Discard
+	defer r.stopped.Store(false)
+	defer func() {
+		if recover() != nil {
+			failed = true
+		}
+	}()
+	for _, rec := range recs {
+		parsed, err := r.parseRecord(rec)
+		if err != nil {
+			return true
+		}
+		if _, err := r.callFn(fn, parsed); err != nil {
+			return true
+		}
+		if r.stopped.Load() {
+			break
+		}
+	}
+	return false
+}
+
+// minimize returns a subset of recs for which failing still returns true, such
+// that removing any one of the records would make it return false. It assumes
+// that failing(recs) is true. It uses the delta debugging algorithm, which
+// removes large chunks first and then smaller ones, so that it usually needs
+// far fewer calls to failing than there are records.
+func minimize(recs []record, failing func([]record) bool) []record {
+	n := 2
+	for len(recs) >= 2 {
+		chunks := splitRecords(recs, n)
+		reduced := false
+		for _, c := range chunks {
+			if failing(c) {
+				recs, n, reduced = c, 2, true
+				break
+			}
+		}
+		if !reduced && n > 2 {
+			for i := range chunks {
+				var rest []record
+				for j, c := range chunks {
+					if j != i {
+						rest = append(rest, c...)
+					}
+				}
+				if failing(rest) {
+					recs, n, reduced = rest, n-1, true
+					break
+				}
+			}
+		}
+		if reduced {
+			continue
+		}
+		if n >= len(recs) {
+			break
+		}
+		n = 2 * n
+		if n > len(recs) {
+			n = len(recs)
+		}
+	}
+	return recs
+}
+
+// splitRecords splits recs into n chunks of nearly equal size.
+func splitRecords(recs []record, n int) [][]record {
+	chunks := make([][]record, 0, n)
+	start := 0
+	for i := 0; i < n; i++ {
+		end := start + (len(recs)-start)/(n-i)
+		chunks = append(chunks, recs[start:end])
+		start = end
+	}
+	return chunks
+}
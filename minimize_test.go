@@ -0,0 +1,57 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+var minimizeTests = []struct {
+	input    string
+	expected [][]string
+}{
+	{"1\n2\n3\n4\n5\n6\n7\n8\n9\n", [][]string{{"3"}, {"7"}}},
+	{"1\n2\nx\n4\n5\n", [][]string{{"x"}}},
+	{"1\n7\n2\n3 3\n", [][]string{{"7"}, {"3", "3"}}},
+}
+
+func TestMinimize(t *testing.T) {
+	defer SetEveryParser(Parser{})
+	SetEveryParser(Int)
+	for i, test := range minimizeTests {
+		// fn fails once it has seen both 3 and 7.
+		seen := map[interface{}]bool{}
+		fn := func(args []interface{}) {
+			for _, a := range args {
+				seen[a] = true
+			}
+			if seen[3] && seen[7] {
+				panic("boom")
+			}
+		}
+		var recs []record
+		records := newRecordReader(bytes.NewReader([]byte(test.input)))
+		for {
+			rec, err := records.next()
+			if err == io.EOF {
+				break
+			}
+			recs = append(recs, rec)
+		}
+		min := minimize(recs, func(recs []record) bool {
+			seen = map[interface{}]bool{}
+			return std.newRun().fails(fn, recs)
+		})
+		var tokens [][]string
+		for _, rec := range min {
+			tokens = append(tokens, rec.tokens)
+		}
+		if !reflect.DeepEqual(tokens, test.expected) {
+			t.Errorf("%d. minimized to %q, expected %q", i, tokens,
+				test.expected)
+		}
+	}
+}
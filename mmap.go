@@ -0,0 +1,106 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"io"
+	"os"
+)
+
+// SetMmap enables or disables memory-mapping of input files. When it is on,
+// regular files named in files mode (see SetFilesMode) are mapped into memory
+// instead of being read through a buffer, and in the Shell format, lines are
+// tokenized directly in the mapping without being copied. This is faster for
+// very large files. The mapping is private, so the file itself is never
+// modified. It has no effect on standard input, URLs, compressed files, or on
+// platforms that do not support memory-mapping.
+//
+// Deprecated: Use Program.SetMmap instead.
+func SetMmap(on bool) {
+	std.SetMmap(on)
+}
+
+// SetMmap is like the package-level SetMmap, but for p.
+func (p *Program) SetMmap(on bool) {
+	p.mmapMode = on
+}
+
+// A mapping is a memory-mapped file. It can also be read like any other file,
+// for formats that do not use the mapping directly.
+type mapping struct {
+	data   []byte
+	offset int
+	file   *os.File
+}
+
+func (m *mapping) Read(p []byte) (int, error) {
+	if m.offset >= len(m.data) {
+		return 0, io.EOF
+	}
+	n := copy(p, m.data[m.offset:])
+	m.offset += n
+	return n, nil
+}
+
+func (m *mapping) Close() error {
+	err := unmap(m.data)
+	if cerr := m.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// mapFile maps f into memory if possible. It returns nil if f is not a regular,
+// nonempty file, if it starts with the gzip magic number, or if mapping fails.
+func mapFile(f *os.File) *mapping {
+	info, err := f.Stat()
+	if err != nil || !info.Mode().IsRegular() || info.Size() == 0 ||
+		int64(int(info.Size())) != info.Size() {
+		return nil
+	}
+	data, err := mmap(f, int(info.Size()))
+	if err != nil {
+		return nil
+	}
+	if len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b {
+		unmap(data)
+		return nil
+	}
+	return &mapping{data: data, file: f}
+}
+
+// mappedRecords reads records in the Shell format from a mapping. It finds
+// lines in the same way as newLineScanner, but without copying them.
+type mappedRecords struct {
+	p    *Program
+	data []byte
+}
+
+func (r *mappedRecords) next() (record, error) {
+	if len(r.data) == 0 {
+		return record{}, io.EOF
+	}
+	advance, line, terminated := scanMappedLine(r.data)
+	r.data = r.data[advance:]
+	line = removeEscapedNewlines(line)
+	if !terminated && len(line) == 0 {
+		// The rest of the input was only escaped newlines.
+		return record{}, io.EOF
+	}
+	return r.p.shellRecord(line), nil
+}
+
+// scanMappedLine is like scanLines at EOF, except that newlines escaped with a
+// backslash do not terminate the line. (Normally lineReader removes them
+// before scanLines sees them.) It also reports whether the line was terminated
+// by a newline rather than by the end of data.
+func scanMappedLine(data []byte) (advance int, line []byte, terminated bool) {
+	var state quoteState
+	for i, c := range data {
+		if state.bare() && c == '\n' {
+			return i + 1, dropCR(data[:i]), true
+		}
+		state.step(c)
+	}
+	return len(data), dropCR(data), false
+}
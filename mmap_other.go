@@ -0,0 +1,20 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+//go:build !unix
+
+package parse
+
+import (
+	"errors"
+	"os"
+)
+
+// mmap always fails on this platform, so files are read normally.
+func mmap(f *os.File, size int) ([]byte, error) {
+	return nil, errors.New("memory-mapping is not supported")
+}
+
+// unmap does nothing on this platform.
+func unmap(data []byte) error {
+	return nil
+}
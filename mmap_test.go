@@ -0,0 +1,88 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeTemp writes data to a new file in a temporary directory.
+func writeTemp(tb testing.TB, data string) string {
+	name := filepath.Join(tb.TempDir(), "input")
+	if err := os.WriteFile(name, []byte(data), 0644); err != nil {
+		tb.Fatal(err)
+	}
+	return name
+}
+
+// readAllRecords reads the tokens of every record in the file called name.
+func readAllRecords(tb testing.TB, name string) ([][]string, bool) {
+	rc, err := std.newRun().openInput(name)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer rc.Close()
+	_, mapped := rc.(*mapping)
+	records := newRecordReader(rc)
+	var got [][]string
+	for {
+		rec, err := records.next()
+		if err == io.EOF {
+			return got, mapped
+		}
+		if err != nil {
+			tb.Fatal(err)
+		}
+		got = append(got, rec.tokens)
+	}
+}
+
+var mmapInputs = []string{
+	"a b c\n",
+	"a b\r\nc\n\nd",
+	"'x\ny' z\n\"q\\\"\nr\"\n",
+	"one \\\ntwo\nthree\\\\\nfour\n",
+	"  \\  x\t'' \"\"\n",
+}
+
+func TestMmapMatchesScanner(t *testing.T) {
+	defer SetMmap(false)
+	for i, input := range mmapInputs {
+		name := writeTemp(t, input)
+		SetMmap(false)
+		expected, _ := readAllRecords(t, name)
+		SetMmap(true)
+		got, mapped := readAllRecords(t, name)
+		if !mapped {
+			t.Skip("memory-mapping is not supported")
+		}
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("%d. mapped %q\nreturned %q\nexpected %q", i, input, got,
+				expected)
+		}
+		data, _ := os.ReadFile(name)
+		if string(data) != input {
+			t.Errorf("%d. file was modified to %q", i, data)
+		}
+	}
+}
+
+func benchmarkRecords(b *testing.B, mmap bool) {
+	defer SetMmap(false)
+	line := "alpha 'beta gamma' 123 4.5 \"delta\\\"\" epsilon\n"
+	name := writeTemp(b, strings.Repeat(line, 1<<18))
+	SetMmap(mmap)
+	b.SetBytes(int64(len(line) << 18))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		readAllRecords(b, name)
+	}
+}
+
+func BenchmarkScannedFile(b *testing.B) { benchmarkRecords(b, false) }
+func BenchmarkMappedFile(b *testing.B)  { benchmarkRecords(b, true) }
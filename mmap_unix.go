@@ -0,0 +1,22 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+//go:build unix
+
+package parse
+
+import (
+	"os"
+	"syscall"
+)
+
+// mmap maps the first size bytes of f into memory. The mapping is private and
+// writable, so that tokenizing can modify it in place without changing f.
+func mmap(f *os.File, size int) ([]byte, error) {
+	return syscall.Mmap(int(f.Fd()), 0, size,
+		syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE)
+}
+
+// unmap releases a mapping created by mmap.
+func unmap(data []byte) error {
+	return syscall.Munmap(data)
+}
@@ -0,0 +1,133 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"fmt"
+	"golang.org/x/text/currency"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// An Amount is an amount of money, as returned by the Money parser. It is
+// stored as an integer number of minor units, so that adding up amounts does
+// not suffer from rounding errors like it would with float64.
+type Amount struct {
+	Units    int64  // number of minor units, such as cents for USD
+	Currency string // ISO 4217 currency code, such as "USD"
+}
+
+// currencyScale returns the number of digits after the decimal point in amounts
+// of the currency, such as 2 for USD and 0 for JPY.
+func currencyScale(code string) int {
+	unit, err := currency.ParseISO(code)
+	if err != nil {
+		return 0
+	}
+	scale, _ := currency.Standard.Rounding(unit)
+	return scale
+}
+
+// String formats a as a decimal number followed by the currency code, such as
+// "1234.56 USD".
+func (a Amount) String() string {
+	scale := currencyScale(a.Currency)
+	sign, units := "", strconv.FormatUint(uint64(a.Units), 10)
+	if a.Units < 0 {
+		sign, units = "-", strconv.FormatUint(-uint64(a.Units), 10)
+	}
+	if scale > 0 {
+		if len(units) <= scale {
+			units = strings.Repeat("0", scale-len(units)+1) + units
+		}
+		units = units[:len(units)-scale] + "." + units[len(units)-scale:]
+	}
+	return sign + units + " " + a.Currency
+}
+
+// currencySymbols maps common currency symbols to ISO 4217 codes. Symbols like
+// "kr" that are shared by several currencies are left out, so the code must be
+// given for those.
+var currencySymbols = []struct{ symbol, code string }{
+	{"US$", "USD"}, {"$", "USD"}, {"€", "EUR"}, {"£", "GBP"}, {"¥", "JPY"},
+	{"₹", "INR"}, {"₩", "KRW"}, {"₽", "RUB"}, {"₪", "ILS"}, {"₺", "TRY"},
+}
+
+// Money is a Parser that parses an amount of money as an Amount. The currency
+// is given by a symbol before the number, as in "$1,234.56" and "€5", or by an
+// ISO 4217 code before or after it, as in "1234.56 EUR" and "CHF 20". Commas
+// may separate groups of three digits, and a minus sign may come before
+// everything else. The number of digits after the decimal point cannot exceed
+// the number of minor units of the currency, so "$0.001" is rejected rather
+// than rounded.
+var Money = NewParser(func(s string) (interface{}, error) {
+	syntaxError := &ValueError{s, "amount of money", strconv.ErrSyntax, "", ""}
+	num, neg := strings.CutPrefix(strings.TrimSpace(s), "-")
+	code := ""
+	for _, cs := range currencySymbols {
+		if rest, ok := strings.CutPrefix(num, cs.symbol); ok {
+			num, code = rest, cs.code
+			break
+		}
+	}
+	if code == "" {
+		if c, rest, ok := strings.Cut(num, " "); ok && isCurrencyCode(c) {
+			num, code = rest, c
+		} else if rest, c, ok := cutLast(num, " "); ok && isCurrencyCode(c) {
+			num, code = rest, c
+		} else {
+			return nil, syntaxError
+		}
+	}
+	unit, err := currency.ParseISO(code)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a known currency", code)
+	}
+	code = unit.String()
+	scale := currencyScale(code)
+	whole, frac, _ := strings.Cut(strings.TrimSpace(num), ".")
+	// Misplaced commas, as in "1,2,3", become "!" and fail the check below.
+	whole = NumberStyle{Thousands: ","}.symbols().convert(whole)
+	if whole == "" || strings.Trim(whole, "0123456789") != "" ||
+		strings.Trim(frac, "0123456789") != "" {
+		return nil, syntaxError
+	}
+	if len(frac) > scale {
+		return nil, fmt.Errorf("%q has more than %d digits after the "+
+			"decimal point for %s", s, scale, code)
+	}
+	digits := whole + frac + strings.Repeat("0", scale-len(frac))
+	if neg {
+		digits = "-" + digits
+	}
+	units, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return nil, &ValueError{s, "amount of money",
+			err.(*strconv.NumError).Err,
+			Amount{math.MinInt64, code}.String(),
+			Amount{math.MaxInt64, code}.String()}
+	}
+	return Amount{units, code}, nil
+}).withInfo(parserInfo{name: "amount", typ: "money"})
+
+// isCurrencyCode returns true if s looks like an ISO 4217 currency code.
+func isCurrencyCode(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+	for _, c := range s {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// cutLast is like strings.Cut, but it cuts around the last instance of sep.
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
@@ -0,0 +1,62 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import "testing"
+
+var moneyTests = []struct {
+	input string
+	value interface{}
+	msg   string
+}{
+	{"$1,234.56", Amount{123456, "USD"}, ""},
+	{"-$5", Amount{-500, "USD"}, ""},
+	{"$0.5", Amount{50, "USD"}, ""},
+	{"1234.56 EUR", Amount{123456, "EUR"}, ""},
+	{"CHF 20", Amount{2000, "CHF"}, ""},
+	{"€ 3.10", Amount{310, "EUR"}, ""},
+	{"¥1,000", Amount{1000, "JPY"}, ""},
+	{"1.5 JPY", nil,
+		`"1.5 JPY" has more than 0 digits after the decimal point for JPY`},
+	{"$0.001", nil,
+		`"$0.001" has more than 2 digits after the decimal point for USD`},
+	{"12 XYZ", nil, `"XYZ" is not a known currency`},
+	{"12", nil, `"12" is not an amount of money`},
+	{"$1,,234", nil, `"$1,,234" is not an amount of money`},
+	{"$,123", nil, `"$,123" is not an amount of money`},
+	{"$1,2,3", nil, `"$1,2,3" is not an amount of money`},
+	{"$12,34.50", nil, `"$12,34.50" is not an amount of money`},
+	{"1,234,567.89 EUR", Amount{123456789, "EUR"}, ""},
+	{"$1e3", nil, `"$1e3" is not an amount of money`},
+	{"$100000000000000000", nil,
+		`"$100000000000000000" is too large (max 92233720368547758.07 USD)`},
+}
+
+func TestMoney(t *testing.T) {
+	for i, test := range moneyTests {
+		value, err := Money.Parse(test.input)
+		msg := ""
+		if err != nil {
+			msg = err.Error()
+		}
+		if value != test.value || msg != test.msg {
+			t.Errorf("%d. Money(%q)\nreturned %s and %q\nexpected %s and %q",
+				i, test.input, formatValue(value), msg,
+				formatValue(test.value), test.msg)
+		}
+	}
+}
+
+func TestAmountString(t *testing.T) {
+	tests := map[Amount]string{
+		{123456, "USD"}: "1234.56 USD",
+		{-5, "EUR"}:     "-0.05 EUR",
+		{1000, "JPY"}:   "1000 JPY",
+		{7, "BHD"}:      "0.007 BHD",
+	}
+	for a, expected := range tests {
+		if s := a.String(); s != expected {
+			t.Errorf("%#v.String() = %q, expected %q", a, s, expected)
+		}
+	}
+}
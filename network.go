@@ -0,0 +1,116 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// httpMethods are the methods accepted by HTTPMethod.
+var httpMethods = []string{
+	http.MethodConnect, http.MethodDelete, http.MethodGet, http.MethodHead,
+	http.MethodOptions, http.MethodPatch, http.MethodPost, http.MethodPut,
+	http.MethodTrace,
+}
+
+// HTTPMethod is a Parser that accepts one of the standard HTTP methods, such as
+// "GET" or "POST", ignoring case. It returns the method in upper case. Its
+// Suggest method returns the matching methods.
+var HTTPMethod = NewParser(func(s string) (interface{}, error) {
+	method := strings.ToUpper(s)
+	for _, m := range httpMethods {
+		if method == m {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("%q is not an HTTP method", s)
+}).withInfo(parserInfo{name: "method", typ: "http method"}).
+	WithSuggest(func(prefix string) []string {
+		return withPrefix(httpMethods, strings.ToUpper(prefix))
+	})
+
+// A StatusCode is an HTTP status code, as returned by the HTTPStatus parser.
+type StatusCode int
+
+// Class returns the first digit of c, which is its class. For example, it
+// returns 4 for 404.
+func (c StatusCode) Class() int {
+	return int(c) / 100
+}
+
+// IsInformational returns true if c is a 1xx status.
+func (c StatusCode) IsInformational() bool {
+	return c.Class() == 1
+}
+
+// IsSuccess returns true if c is a 2xx status.
+func (c StatusCode) IsSuccess() bool {
+	return c.Class() == 2
+}
+
+// IsRedirect returns true if c is a 3xx status.
+func (c StatusCode) IsRedirect() bool {
+	return c.Class() == 3
+}
+
+// IsClientError returns true if c is a 4xx status.
+func (c StatusCode) IsClientError() bool {
+	return c.Class() == 4
+}
+
+// IsServerError returns true if c is a 5xx status.
+func (c StatusCode) IsServerError() bool {
+	return c.Class() == 5
+}
+
+// String returns c followed by its text, such as "404 Not Found", or just the
+// number if the status is not a standard one.
+func (c StatusCode) String() string {
+	if text := http.StatusText(int(c)); text != "" {
+		return strconv.Itoa(int(c)) + " " + text
+	}
+	return strconv.Itoa(int(c))
+}
+
+// HTTPStatus is a Parser that parses an HTTP status code from 100 to 599 as a
+// StatusCode. Codes without a standard meaning, such as 299, are accepted.
+var HTTPStatus = NewParser(func(s string) (interface{}, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not an HTTP status code", s)
+	}
+	if n < 100 || n > 599 {
+		return nil, fmt.Errorf("%q is not an HTTP status code (100 to 599)", s)
+	}
+	return StatusCode(n), nil
+}).withInfo(parserInfo{name: "status", typ: "http status"})
+
+// Port is a Parser that parses a TCP or UDP port number from 1 to 65535 as an
+// int. Port 0, which asks the operating system to choose a port, is rejected.
+var Port = NewParser(func(s string) (interface{}, error) {
+	n, err := strconv.Atoi(s)
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("%q is not a port number", s)
+	case n == 0:
+		return nil, errors.New("port 0 is reserved (ports are 1 to 65535)")
+	case n < 0 || n > 65535:
+		return nil, fmt.Errorf("port %d is out of range (ports are 1 to 65535)",
+			n)
+	}
+	return n, nil
+}).withInfo(parserInfo{name: "port", typ: "port"})
+
+// UnprivilegedPort is like Port, but it only accepts ports from 1024 to 65535,
+// which programs can listen on without special privileges on most systems.
+var UnprivilegedPort = Port.Restrict(func(x interface{}) error {
+	if n := x.(int); n < 1024 {
+		return fmt.Errorf("port %d is privileged (ports below 1024 require "+
+			"root)", n)
+	}
+	return nil
+}).with(func(i *parserInfo) { i.typ = "unprivileged port" })
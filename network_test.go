@@ -0,0 +1,72 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"reflect"
+	"testing"
+)
+
+var networkTests = []struct {
+	parser Parser
+	input  string
+	value  interface{}
+	msg    string
+}{
+	{HTTPMethod, "GET", "GET", ""},
+	{HTTPMethod, "patch", "PATCH", ""},
+	{HTTPMethod, "FETCH", nil, `"FETCH" is not an HTTP method`},
+	{HTTPStatus, "200", StatusCode(200), ""},
+	{HTTPStatus, "599", StatusCode(599), ""},
+	{HTTPStatus, "99", nil, `"99" is not an HTTP status code (100 to 599)`},
+	{HTTPStatus, "600", nil, `"600" is not an HTTP status code (100 to 599)`},
+	{HTTPStatus, "OK", nil, `"OK" is not an HTTP status code`},
+	{Port, "8080", 8080, ""},
+	{Port, "80", 80, ""},
+	{Port, "65535", 65535, ""},
+	{Port, "0", nil, "port 0 is reserved (ports are 1 to 65535)"},
+	{Port, "70000", nil, "port 70000 is out of range (ports are 1 to 65535)"},
+	{Port, "-1", nil, "port -1 is out of range (ports are 1 to 65535)"},
+	{Port, "http", nil, `"http" is not a port number`},
+	{UnprivilegedPort, "1024", 1024, ""},
+	{UnprivilegedPort, "80", nil,
+		"port 80 is privileged (ports below 1024 require root)"},
+	{UnprivilegedPort, "70000", nil,
+		"port 70000 is out of range (ports are 1 to 65535)"},
+}
+
+func TestNetworkParsers(t *testing.T) {
+	for i, test := range networkTests {
+		value, err := test.parser.Parse(test.input)
+		msg := ""
+		if err != nil {
+			msg = err.Error()
+		}
+		if value != test.value || msg != test.msg {
+			t.Errorf("%d. %q\nreturned %v and %q\nexpected %v and %q",
+				i, test.input, value, msg, test.value, test.msg)
+		}
+	}
+}
+
+func TestHTTPMethodSuggest(t *testing.T) {
+	got := HTTPMethod.Suggest("p")
+	expected := []string{"PATCH", "POST", "PUT"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Suggest returned %v, expected %v", got, expected)
+	}
+}
+
+func TestStatusCode(t *testing.T) {
+	c := StatusCode(404)
+	if c.Class() != 4 || !c.IsClientError() || c.IsServerError() ||
+		c.IsSuccess() || c.IsRedirect() || c.IsInformational() {
+		t.Errorf("wrong class for %d", c)
+	}
+	if s := c.String(); s != "404 Not Found" {
+		t.Errorf("String returned %q", s)
+	}
+	if s := StatusCode(299).String(); s != "299" {
+		t.Errorf("String returned %q", s)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"golang.org/x/text/unicode/norm"
+	"strings"
+)
+
+// A Normalization is a set of changes made to each argument before it is
+// parsed. Normalizations can be combined with the bitwise OR operator.
+type Normalization int
+
+const (
+	// NFC composes characters into their canonical composed forms, so that,
+	// for example, "e" followed by a combining acute accent becomes "é".
+	NFC Normalization = 1 << iota
+	// NFKC is like NFC, but it also replaces compatibility characters with
+	// their ordinary equivalents, such as the ligature "ﬁ" with "fi" and the
+	// full-width "１" with "1". It takes precedence over NFC.
+	NFKC
+	// TrimSpace removes leading and trailing white space, including Unicode
+	// spaces such as the no-break space (U+00A0), from each argument.
+	TrimSpace
+	// FoldLower converts each argument to lower case, like the Lower parser
+	// wrapper does for a single Parser.
+	FoldLower
+	// FoldUpper converts each argument to upper case. It takes precedence
+	// over FoldLower.
+	FoldUpper
+)
+
+// SetNormalization sets the normalizations applied to each argument before it
+// is parsed, which is useful when input is copied from documents or web pages.
+// They apply to command-line arguments and every kind of input alike, and they
+// happen before empty arguments are rejected (see SetRejectEmpty). There are no
+// normalizations by default. For example, SetNormalization(NFKC|TrimSpace)
+// makes the Int parser accept "１２ " with a trailing no-break space.
+//
+// Deprecated: Use Program.SetNormalization instead.
+func SetNormalization(n Normalization) {
+	std.SetNormalization(n)
+}
+
+// SetNormalization is like the package-level SetNormalization, but for p.
+func (p *Program) SetNormalization(n Normalization) {
+	p.normalization = n
+}
+
+// normalize applies the normalizations of p to s.
+func (p *Program) normalize(s string) string {
+	if p.normalization&TrimSpace != 0 || p.trimPolicy == TrimAll {
+		s = trimSpace(s)
+	}
+	switch {
+	case p.normalization&NFKC != 0:
+		s = norm.NFKC.String(s)
+	case p.normalization&NFC != 0:
+		s = norm.NFC.String(s)
+	}
+	switch {
+	case p.normalization&FoldUpper != 0:
+		s = strings.ToUpper(s)
+	case p.normalization&FoldLower != 0:
+		s = strings.ToLower(s)
+	}
+	return s
+}
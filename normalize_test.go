@@ -0,0 +1,53 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import "testing"
+
+var normalizeTests = []struct {
+	n       Normalization
+	in, out string
+}{
+	{0, " é ", " é "},
+	{NFC, "é", "é"},
+	{NFC, "ﬁ１", "ﬁ１"},
+	{NFKC, "ﬁ１", "fi1"},
+	{NFC | NFKC, "ﬁé", "fié"},
+	{TrimSpace, "  x y\t　", "x y"},
+	{TrimSpace | NFKC, " １２ ", "12"},
+	{FoldLower, "AbÇ", "abç"},
+	{FoldUpper | FoldLower, "AbÇ", "ABÇ"},
+	{FoldLower | NFKC, "Ⅻ", "xii"},
+}
+
+func TestNormalize(t *testing.T) {
+	defer SetNormalization(0)
+	for i, test := range normalizeTests {
+		SetNormalization(test.n)
+		if out := std.normalize(test.in); out != test.out {
+			t.Errorf("%d. normalize(%q) = %q, expected %q", i, test.in, out,
+				test.out)
+		}
+	}
+}
+
+func TestNormalizeBeforeParsing(t *testing.T) {
+	defer func() {
+		SetNormalization(0)
+		SetRejectEmpty(false)
+		SetEveryParser(Parser{})
+	}()
+	SetEveryParser(Int)
+	if _, err := Parse([]string{"１２ "}); err == nil {
+		t.Error("Parse accepted an unnormalized number")
+	}
+	SetNormalization(NFKC | TrimSpace)
+	parsed, err := Parse([]string{"１２ "})
+	if err != nil || parsed[0] != 12 {
+		t.Errorf("Parse returned %v, %v, expected 12", parsed, err)
+	}
+	SetRejectEmpty(true)
+	if _, err := Parse([]string{" "}); err == nil {
+		t.Error("Parse accepted an argument that was empty after trimming")
+	}
+}
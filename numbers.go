@@ -0,0 +1,220 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// A NumberStyle describes the separators used in numbers that are formatted
+// for people rather than programs, such as those exported from spreadsheets.
+type NumberStyle struct {
+	// Thousands holds the characters that may separate groups of digits,
+	// such as "," for "1,000,000" or " ." for "1 000 000" and "1.000.000".
+	// Each separator must come between two digits, and the groups after the
+	// first must have three digits, so "12,34" is rejected rather than read
+	// as 1234.
+	Thousands string
+	// DecimalComma makes a comma the decimal separator, as in "3,14", in
+	// which case a period can only be a thousands separator.
+	DecimalComma bool
+}
+
+// IntStyle returns a Parser like Int that also accepts numbers written in the
+// given style, such as "1,000,000" with NumberStyle{Thousands: ","}.
+func IntStyle(style NumberStyle) Parser {
+	return Int.derive(style.wrap(Int))
+}
+
+// Float64Style returns a Parser like Float64 that also accepts numbers written
+// in the given style, such as "1.234,5" with NumberStyle{Thousands: ".",
+// DecimalComma: true}.
+func Float64Style(style NumberStyle) Parser {
+	return Float64.derive(style.wrap(Float64))
+}
+
+// symbols returns the symbols used by numbers in the style.
+func (style NumberStyle) symbols() numberSymbols {
+	sym := numberSymbols{thousands: style.Thousands, decimal: '.', zero: '0'}
+	if style.DecimalComma {
+		sym.decimal = ','
+	}
+	return sym
+}
+
+// wrap returns a function that converts the string from the style into Go
+// syntax before passing it to p.
+func (style NumberStyle) wrap(p Parser) func(string) (interface{}, error) {
+	return style.symbols().wrap(p)
+}
+
+// numberSymbols holds the symbols used to write numbers in a particular style
+// or locale.
+type numberSymbols struct {
+	thousands string // separators between groups of digits
+	decimal   rune   // the decimal separator
+	minus     rune   // the minus sign, if it is not '-'
+	zero      rune   // the digit zero, which is followed by the other digits
+	group     int    // digits between two separators, or 0 for 3
+}
+
+// wrap returns a function that converts the string from sym into Go syntax
+// before passing it to p. Errors still show the original string.
+func (sym numberSymbols) wrap(p Parser) func(string) (interface{}, error) {
+	return func(s string) (interface{}, error) {
+		x, err := p.Parse(sym.convert(s))
+		if ve, ok := err.(*ValueError); ok {
+			copy := *ve
+			copy.Value = s
+			err = &copy
+		}
+		return x, err
+	}
+}
+
+// isDigit returns true if r is a digit in sym or an ASCII digit.
+func (sym numberSymbols) isDigit(r rune) bool {
+	return r >= sym.zero && r <= sym.zero+9 || r >= '0' && r <= '9'
+}
+
+// grouped returns true if the thousands separator at index i of runes is in
+// the right place: the group of digits after the last separator has three
+// digits, the groups between two separators have sym.group digits, and the
+// first group has at most that many.
+func (sym numberSymbols) grouped(runes []rune, i int) bool {
+	size := sym.group
+	if size == 0 {
+		size = 3
+	}
+	start := i
+	for start > 0 && sym.isDigit(runes[start-1]) {
+		start--
+	}
+	end := i + 1
+	for end < len(runes) && sym.isDigit(runes[end]) {
+		end++
+	}
+	before, after := i-start, end-i-1
+	isSep := func(j int) bool {
+		return j >= 0 && j < len(runes) &&
+			strings.ContainsRune(sym.thousands, runes[j])
+	}
+	first, last := !isSep(start-1), !isSep(end)
+	switch {
+	case before == 0 || after == 0, first && before > size:
+		return false
+	case last:
+		return after == 3
+	}
+	return after == size
+}
+
+// convert removes thousands separators from s, replaces the decimal separator
+// with a period, and replaces the minus sign and digits with ASCII ones. It
+// also removes invisible formatting characters, such as the marks that some
+// locales put around the minus sign. Other characters that are invalid in sym,
+// including thousands separators that are not between groups of digits of the
+// integer part (see grouped), are replaced with "!" so that parsing fails.
+func (sym numberSymbols) convert(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	fraction := false
+	for i, r := range runes {
+		switch {
+		case strings.ContainsRune(sym.thousands, r):
+			if fraction || !sym.grouped(runes, i) {
+				b.WriteRune('!')
+			}
+		case r == sym.decimal:
+			fraction = true
+			b.WriteRune('.')
+		case r == '.':
+			b.WriteRune('!')
+		case r == sym.minus:
+			b.WriteRune('-')
+		case r > sym.zero && r <= sym.zero+9:
+			b.WriteRune('0' + r - sym.zero)
+		case r > unicode.MaxASCII && unicode.Is(unicode.Cf, r):
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// romanNumerals lists the values of Roman numerals and subtractive pairs from
+// largest to smallest.
+var romanNumerals = []struct {
+	value   int
+	numeral string
+}{
+	{1000, "M"}, {900, "CM"}, {500, "D"}, {400, "CD"}, {100, "C"}, {90, "XC"},
+	{50, "L"}, {40, "XL"}, {10, "X"}, {9, "IX"}, {5, "V"}, {4, "IV"}, {1, "I"},
+}
+
+// formatRoman returns n as a Roman numeral, assuming that 0 < n < 4000.
+func formatRoman(n int) string {
+	var b strings.Builder
+	for _, r := range romanNumerals {
+		for ; n >= r.value; n -= r.value {
+			b.WriteString(r.numeral)
+		}
+	}
+	return b.String()
+}
+
+// Roman is a Parser that parses a Roman numeral from I to MMMCMXCIX as an int,
+// such as "MCMXCIV" as 1994. Lower case is also accepted. The numeral must be
+// in standard form, so "IIII" and "IC" are rejected.
+var Roman = NewParser(func(s string) (interface{}, error) {
+	upper := strings.ToUpper(s)
+	n, rest := 0, upper
+	for _, r := range romanNumerals {
+		for strings.HasPrefix(rest, r.numeral) {
+			n += r.value
+			rest = rest[len(r.numeral):]
+		}
+	}
+	if n == 0 || n >= 4000 || formatRoman(n) != upper {
+		return nil, &ValueError{s, "Roman numeral", strconv.ErrSyntax, "", ""}
+	}
+	return n, nil
+}).withInfo(parserInfo{name: "numeral", typ: "int"})
+
+// ordinalSuffix returns the English ordinal suffix for n, such as "st" for 1
+// and "th" for 11.
+func ordinalSuffix(n int) string {
+	if n%100 >= 11 && n%100 <= 13 {
+		return "th"
+	}
+	switch n % 10 {
+	case 1:
+		return "st"
+	case 2:
+		return "nd"
+	case 3:
+		return "rd"
+	}
+	return "th"
+}
+
+// Ordinal is a Parser that parses an English ordinal number, such as "3rd" or
+// "21st", as an int. The suffix is not case sensitive, but it must match the
+// number, so "3th" is rejected. A number without a suffix is not accepted.
+var Ordinal = NewParser(func(s string) (interface{}, error) {
+	i := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	if i <= 0 {
+		return nil, &ValueError{s, "ordinal number", strconv.ErrSyntax, "", ""}
+	}
+	n, err := strconv.Atoi(s[:i])
+	if err != nil {
+		return nil, &ValueError{s, "ordinal number",
+			err.(*strconv.NumError).Err, "0th", maxInt + "th"}
+	}
+	if !strings.EqualFold(s[i:], ordinalSuffix(n)) {
+		return nil, &ValueError{s, "ordinal number", strconv.ErrSyntax, "", ""}
+	}
+	return n, nil
+}).withInfo(parserInfo{name: "ordinal", typ: "int"})
@@ -0,0 +1,127 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import "testing"
+
+var (
+	commas   = NumberStyle{Thousands: ","}
+	european = NumberStyle{Thousands: ". ", DecimalComma: true}
+)
+
+var numberStyleTests = []struct {
+	parser Parser
+	name   string
+	input  string
+	value  interface{}
+	msg    string
+}{
+	{Int, "Int", "1_000_000", 1000000, ""},
+	{Float64, "Float64", "1_000.5", 1000.5, ""},
+	{IntStyle(commas), "IntStyle(commas)", "1,000,000", 1000000, ""},
+	{IntStyle(commas), "IntStyle(commas)", "-12,345", -12345, ""},
+	{IntStyle(commas), "IntStyle(commas)", "1_000", 1000, ""},
+	{IntStyle(commas), "IntStyle(commas)", ",100", nil,
+		`",100" is not a whole number`},
+	{IntStyle(commas), "IntStyle(commas)", "100,", nil,
+		`"100," is not a whole number`},
+	{Float64Style(commas), "Float64Style(commas)", "1,234.5", 1234.5, ""},
+	{Float64Style(european), "Float64Style(european)", "1.234,5", 1234.5, ""},
+	{Float64Style(european), "Float64Style(european)", "1 234 567,25",
+		1234567.25, ""},
+	{Float64Style(european), "Float64Style(european)", "3,14", 3.14, ""},
+	{Float64Style(european), "Float64Style(european)", "3.14", nil,
+		`"3.14" is not a number`},
+	{Float64Style(european), "Float64Style(european)", "3.141", 3141.0, ""},
+	{IntStyle(commas), "IntStyle(commas)", "12,34", nil,
+		`"12,34" is not a whole number`},
+	{IntStyle(commas), "IntStyle(commas)", "1,2,3", nil,
+		`"1,2,3" is not a whole number`},
+	{IntStyle(commas), "IntStyle(commas)", "1,0000", nil,
+		`"1,0000" is not a whole number`},
+	{IntStyle(commas), "IntStyle(commas)", "1234,567", nil,
+		`"1234,567" is not a whole number`},
+	{IntStyle(commas), "IntStyle(commas)", "1,234,56", nil,
+		`"1,234,56" is not a whole number`},
+	{Float64Style(NumberStyle{DecimalComma: true}), "Float64Style(comma)",
+		"3.14", nil, `"3.14" is not a number`},
+	{IntStyle(european), "IntStyle(european)", "1.000", 1000, ""},
+}
+
+func TestNumberStyles(t *testing.T) {
+	for i, test := range numberStyleTests {
+		value, err := test.parser.Parse(test.input)
+		msg := ""
+		if err != nil {
+			msg = err.Error()
+		}
+		if value != test.value || msg != test.msg {
+			t.Errorf("%d. %s(%q)\nreturned %s and %q\nexpected %s and %q",
+				i, test.name, test.input, formatValue(value), msg,
+				formatValue(test.value), test.msg)
+		}
+	}
+}
+
+var romanTests = []struct {
+	input string
+	value interface{}
+}{
+	{"MCMXCIV", 1994},
+	{"mmxxiv", 2024},
+	{"I", 1},
+	{"IV", 4},
+	{"MMMCMXCIX", 3999},
+	{"IIII", nil},
+	{"IC", nil},
+	{"MMMM", nil},
+	{"", nil},
+	{"XIV ", nil},
+}
+
+func TestRoman(t *testing.T) {
+	for i, test := range romanTests {
+		value, err := Roman.Parse(test.input)
+		if value != test.value {
+			t.Errorf("%d. Roman(%q) returned %s (%v), expected %s",
+				i, test.input, formatValue(value), err, formatValue(test.value))
+		}
+	}
+	for n := 1; n < 4000; n++ {
+		if value, err := Roman.Parse(formatRoman(n)); value != n {
+			t.Fatalf("Roman(%q) returned %v (%v), expected %d",
+				formatRoman(n), value, err, n)
+		}
+	}
+}
+
+var ordinalTests = []struct {
+	input string
+	value interface{}
+}{
+	{"1st", 1},
+	{"2nd", 2},
+	{"3rd", 3},
+	{"4th", 4},
+	{"11th", 11},
+	{"12th", 12},
+	{"13TH", 13},
+	{"21st", 21},
+	{"102nd", 102},
+	{"0th", 0},
+	{"3th", nil},
+	{"11st", nil},
+	{"3", nil},
+	{"rd", nil},
+	{"-1st", nil},
+}
+
+func TestOrdinal(t *testing.T) {
+	for i, test := range ordinalTests {
+		value, err := Ordinal.Parse(test.input)
+		if value != test.value {
+			t.Errorf("%d. Ordinal(%q) returned %s (%v), expected %s",
+				i, test.input, formatValue(value), err, formatValue(test.value))
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import "io"
+
+// An Option configures a single call to Run, as an alternative to calling the
+// setter of the same name beforehand. Options are applied in order to a copy
+// of the program's settings that is used for that run only, so they do not
+// affect other runs. For example:
+//
+//	code, err := parse.Run(fn,
+//		parse.WithParsers(parse.Int, parse.Int),
+//		parse.WithUsage("a b"),
+//		parse.WithInput(strings.NewReader("1 2\n3 4\n")))
+type Option struct {
+	set func(p *Program)
+}
+
+// WithUsage is an Option that calls SetUsage with args.
+func WithUsage(args string) Option {
+	return Option{func(p *Program) { p.SetUsage(args) }}
+}
+
+// WithParsers is an Option that calls SetParsers with ps.
+func WithParsers(ps ...Parser) Option {
+	return Option{func(p *Program) { p.SetParsers(ps...) }}
+}
+
+// WithEveryParser is an Option that calls SetEveryParser with q.
+func WithEveryParser(q Parser) Option {
+	return Option{func(p *Program) { p.SetEveryParser(q) }}
+}
+
+// WithVariadic is an Option that calls SetVariadic with ps.
+func WithVariadic(ps ...Parser) Option {
+	return Option{func(p *Program) { p.SetVariadic(ps...) }}
+}
+
+// WithNames is an Option that calls SetNames with ns.
+func WithNames(ns ...string) Option {
+	return Option{func(p *Program) { p.SetNames(ns...) }}
+}
+
+// WithArgs is an Option that calls SetArgs with args.
+func WithArgs(args []string) Option {
+	return Option{func(p *Program) { p.SetArgs(args) }}
+}
+
+// WithInput is an Option that calls SetInput with r.
+func WithInput(r io.Reader) Option {
+	return Option{func(p *Program) { p.SetInput(r) }}
+}
+
+// WithOutput is an Option that calls SetOutput with w.
+func WithOutput(w io.Writer) Option {
+	return Option{func(p *Program) { p.SetOutput(w) }}
+}
+
+// WithErrorOutput is an Option that calls SetErrorOutput with w. Errors are
+// printed to w with a logger of the run's own, so the standard logger is left
+// alone.
+func WithErrorOutput(w io.Writer) Option {
+	return Option{func(p *Program) {
+		p.SetErrorOutput(w)
+		p.logger = nil
+	}}
+}
+
+// WithPrefix is an Option that calls SetOutputPrefix with template.
+func WithPrefix(template string) Option {
+	return Option{func(p *Program) { p.SetOutputPrefix(template) }}
+}
+
+// WithKeepGoing is an Option that calls SetKeepGoing with b.
+func WithKeepGoing(b bool) Option {
+	return Option{func(p *Program) { p.SetKeepGoing(b) }}
+}
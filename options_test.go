@@ -0,0 +1,72 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRunOptions(t *testing.T) {
+	var out, errs bytes.Buffer
+	fn := func(args []interface{}) {
+		fmt.Fprintln(Output(), args[0].(int)+args[1].(int))
+	}
+	code, err := Run(fn,
+		WithParsers(Int, Int),
+		WithNames("a", "b"),
+		WithArgs([]string{}),
+		WithInput(strings.NewReader("1 2\nx\n3 4\n")),
+		WithOutput(&out),
+		WithErrorOutput(&errs),
+		WithPrefix("{line}: "),
+		WithKeepGoing(true))
+	if code != 1 || err == nil {
+		t.Errorf("Run returned %d, %v", code, err)
+	}
+	if expected := "1: 3\n3: 7\n"; out.String() != expected {
+		t.Errorf("Run printed %q, expected %q", out.String(), expected)
+	}
+	if !strings.Contains(errs.String(), "too few arguments") {
+		t.Errorf("Run printed the errors %q", errs.String())
+	}
+	if len(std.parsers) != 1 || !std.repeat || std.names != nil ||
+		std.hasUsage || std.commandLineOverride != nil ||
+		std.stdinOverride != nil || std.outputPrefix != "" ||
+		std.output != os.Stdout {
+		t.Error("Run changed the default program with its options")
+	}
+}
+
+func TestRunUsageOption(t *testing.T) {
+	var errs bytes.Buffer
+	code, _ := Run(func([]interface{}) {},
+		WithParsers(Int, Int),
+		WithUsage("x y"),
+		WithArgs([]string{"1"}),
+		WithErrorOutput(&errs))
+	if code != 1 || !strings.Contains(errs.String(), "x y") {
+		t.Errorf("Run returned %d and printed %q", code, errs.String())
+	}
+}
+
+func TestRunOptionsFlags(t *testing.T) {
+	defer SetVerbosityFlags(false)
+	SetVerbosityFlags(true)
+	var errs bytes.Buffer
+	prefix := log.Prefix()
+	for _, args := range [][]string{{"--quiet", "x"}, {"--fail-fast", "-"}} {
+		Run(func([]interface{}) {},
+			WithParsers(Int),
+			WithArgs(args),
+			WithInput(strings.NewReader("")),
+			WithErrorOutput(&errs))
+	}
+	if std.verbosity != Normal || !std.keepGoing || log.Prefix() != prefix {
+		t.Error("Run did not restore the settings replaced by built-in flags")
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// A PanicError records a panic in a Parser, such as a failed type assertion in
+// a predicate passed to Restrict. Parse recovers from the panic and returns the
+// PanicError inside an ArgError, so that the failure is attributed to the
+// argument (and line of input) that caused it, and the program continues or
+// exits according to SetKeepGoing like it does for any other parse error.
+type PanicError struct {
+	Value interface{} // the value passed to panic
+	Stack []byte      // the stack trace of the panicking goroutine
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("parser panicked: %v", e.Value)
+}
+
+// callParser calls p with s, converting a panic into a PanicError.
+func callParser(p Parser, s string) (x interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			x, err = nil, &PanicError{r, debug.Stack()}
+		}
+	}()
+	x, err = p.Parse(s)
+	if debugChecks {
+		if err := checkResult(x, err); err != nil {
+			return nil, err
+		}
+	}
+	return x, err
+}
@@ -0,0 +1,52 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+// badParser is a Parser whose predicate makes a bad type assertion.
+var badParser = Int.Restrict(func(x interface{}) error {
+	_ = x.(string)
+	return nil
+})
+
+func TestParserPanic(t *testing.T) {
+	defer SetEveryParser(Parser{})
+	SetParsers(Parser{}, badParser)
+	_, err := Parse([]string{"a", "1"})
+	errs, ok := err.(MultiError)
+	if !ok || len(errs) != 1 || errs[0].Index != 1 {
+		t.Fatalf("Parse returned %#v, expected one ArgError", err)
+	}
+	pe, ok := errs[0].Err.(*PanicError)
+	if !ok {
+		t.Fatalf("error is %T, expected *PanicError", errs[0].Err)
+	}
+	if !strings.HasPrefix(pe.Error(), "parser panicked: interface conversion") {
+		t.Errorf("wrong message %q", pe.Error())
+	}
+	if !bytes.Contains(pe.Stack, []byte("panic_test.go")) {
+		t.Errorf("stack does not include the predicate:\n%s", pe.Stack)
+	}
+}
+
+func TestParserPanicInInput(t *testing.T) {
+	defer SetEveryParser(Parser{})
+	SetEveryParser(badParser)
+	var got int
+	input := strings.NewReader("1\n2\n")
+	var diags bytes.Buffer
+	l := log.New(&diags, "prog:", 0)
+	if std.newRun().mapReader(func([]interface{}) { got++ }, input, l, 1) {
+		t.Error("mapReader returned true, expected false")
+	}
+	if s := diags.String(); !strings.Contains(s, ":1: ") ||
+		!strings.Contains(s, ":2: ") {
+		t.Errorf("errors do not name the lines:\n%s", s)
+	}
+}
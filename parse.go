@@ -5,16 +5,24 @@
 // or via standard input, making it easy pipe data to the program in addition
 // to specifying arguments manually. It also handles the usage message and the
 // formatting of error messages.
+//
+// The package-level functions such as SetParsers and Main configure and run a
+// default Program. They keep existing programs working, but they are
+// deprecated: new code should create a Program with New and use its methods,
+// which keeps the configuration out of global state. Output, Stop, and the
+// other functions called from fn are not deprecated, since they refer to
+// whichever Program is running.
 package parse
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
-	"github.com/kless/term"
 	"io"
 	"log"
+	"math"
 	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"unicode"
@@ -24,16 +32,9 @@ func init() {
 	// We are using log to present errors to the user, so extra information such
 	// as the date and time are unnecessary.
 	log.SetFlags(0)
-	log.SetPrefix(programName + ": ")
+	log.SetPrefix(std.name + ": ")
 }
 
-// programName is the name of the executable file that contains the program.
-var programName = filepath.Base(os.Args[0])
-
-// usage is the program's usage message, a short string demonstrating to the
-// user how the program should be invoked.
-var usage = "<usage message missing>"
-
 // SetUsage creates the usage message using args, which should contain the
 // portion of the usage message that lists the arguments. This is typically a
 // list of the argument names separated by spaces.
@@ -41,63 +42,263 @@ var usage = "<usage message missing>"
 // For example, the sleep command found on Unix systems takes one argument, the
 // number of seconds to sleep for. A sleep program using parse should call
 // SetUsage("seconds"). This will produce "usage: sleep seconds".
+//
+// If SetUsage is not called, the usage message is generated from the names
+// given to SetNames, so calling SetNames("seconds") has the same effect.
+//
+// Deprecated: Use Program.SetUsage instead.
 func SetUsage(args string) {
-	usage = strings.TrimRightFunc(strings.Join([]string{"usage:", programName,
-		args}, " "), unicode.IsSpace)
+	std.SetUsage(args)
+}
+
+// SetUsage is like the package-level SetUsage, but for p.
+func (p *Program) SetUsage(args string) {
+	p.usage, p.hasUsage = args, true
+}
+
+// A Parser parses a string that is supposed to represent a particular data
+// type, using a function given to NewParser, along with metadata about the
+// argument such as its help text (see Help). It returns an error when the
+// string cannot be parsed. The zero Parser simply returns the passed string.
+type Parser struct {
+	fn   func(string) (interface{}, error)
+	meta *parserInfo // nil if there is no metadata
+}
+
+// NewParser returns a Parser that parses strings with fn.
+func NewParser(fn func(string) (interface{}, error)) Parser {
+	return Parser{fn: fn}
+}
+
+// Parse parses s. It returns an error when s cannot be parsed.
+func (p Parser) Parse(s string) (interface{}, error) {
+	if p.fn == nil {
+		return s, nil
+	}
+	return p.fn(s)
 }
 
-// A Parser is a function that parses a string that is supposed to represent a
-// particular data type. It returns an error when the string cannot be parsed. A
-// nil parser is equivalent to a function that simply returns the passed string.
-type Parser func(string) (interface{}, error)
+// isZero returns true if p is the zero Parser.
+func (p Parser) isZero() bool {
+	return p.fn == nil
+}
 
-// parsers is the list of Parser functions that will be used to parse the
-// arguments that the program receives. Each element in order corresponds to a
-// single argument, so the number of arguments expected by the program is
-// len(parsers), unless it is equal to one and repeat is true.
-var parsers = []Parser{nil}
+// String is a Parser that accepts any string and returns it unchanged. It is
+// used for arguments whose Parser is the zero Parser.
+var String = NewParser(func(s string) (interface{}, error) {
+	return s, nil
+}).withInfo(parserInfo{typ: "string"})
 
-// repeat allows parsers to parse an arbitrary amount of arguments with a single
-// function. If len(parsers) == 1 and repeat is true, then the program will
-// accept any nonzero number of arguments of the same type. If repeat is false,
-// it will accept only one argument.
-var repeat = true
+// orString returns p, or String if p is the zero Parser.
+func (p Parser) orString() Parser {
+	if p.isZero() {
+		return String
+	}
+	return p
+}
 
 // SetEveryParser assigns p to be used to parse the program's arguments. The
 // function passed to Main must be prepared to receive any nonzero number of
-// arguments (but they are guaranteed to be of the type that p returns).
+// arguments (but they are guaranteed to be of the type that p returns). If p is
+// the zero Parser, String is used.
+//
+// Deprecated: Use Program.SetEveryParser instead.
 func SetEveryParser(p Parser) {
-	parsers = []Parser{p}
-	repeat = true
+	std.SetEveryParser(p)
+}
+
+// SetEveryParser is like the package-level SetEveryParser, but for p.
+func (p *Program) SetEveryParser(q Parser) {
+	p.parsers = []Parser{q.orString()}
+	p.repeat, p.restIndex = true, 0
 }
 
 // SetParsers assigns ps to be used to parse the program's arguments. The
 // function passed to Main is guaranteed to receive len(ps) arguments. Each
 // Parser in ps corresponds to a single argument, so, for example, if the third
 // parses an int, then the third argument received by the program is guaranteed
-// to be an int.
+// to be an int. A zero Parser in ps stands for String, so that argument is
+// passed to fn as a string.
+//
+// Deprecated: Use Program.SetParsers instead.
 func SetParsers(ps ...Parser) {
-	parsers = ps
-	repeat = false
+	std.SetParsers(ps...)
+}
+
+// SetParsers is like the package-level SetParsers, but for p.
+func (p *Program) SetParsers(ps ...Parser) {
+	p.parsers = make([]Parser, len(ps))
+	for i, q := range ps {
+		p.parsers[i] = q.orString()
+	}
+	p.repeat, p.restIndex = false, 0
+}
+
+// SetVariadic is like SetParsers, but the last Parser in ps parses all the
+// arguments that remain after the others have one each, like the last
+// parameter of a variadic Go function. For example, with SetVariadic(Int,
+// ExistingFile), the program receives an int followed by any number of files,
+// possibly none. SetVariadic(p) is equivalent to SetEveryParser(p). The other
+// arguments can be optional (see Parser.Default), but the last one cannot. To
+// repeat an argument other than the last one, use SetRepeated. SetVariadic
+// panics if ps is empty.
+//
+// Deprecated: Use Program.SetVariadic instead.
+func SetVariadic(ps ...Parser) {
+	std.SetVariadic(ps...)
+}
+
+// SetVariadic is like the package-level SetVariadic, but for p.
+func (p *Program) SetVariadic(ps ...Parser) {
+	if len(ps) == 0 {
+		panic("parse: SetVariadic: no parsers")
+	}
+	p.SetParsers(ps...)
+	p.repeat, p.restIndex = true, len(ps)-1
+}
+
+// minArgs returns the number of arguments required. Without repeat mode,
+// arguments at the end whose parsers have defaults are optional. In repeat
+// mode, all the arguments whose parsers have defaults are optional, and so is
+// the repeated one.
+func (p *Program) minArgs() int {
+	n := len(p.parsers)
+	if p.repeat {
+		for i, q := range p.parsers {
+			if i == p.restIndex || q.info().hasDefault {
+				n--
+			}
+		}
+		return n
+	}
+	for n > 0 && p.parsers[n-1].info().hasDefault {
+		n--
+	}
+	return n
+}
+
+// parserAt returns the Parser for the argument at index i, or the zero Parser
+// if there is none (in which case the argument is not parsed). In repeat mode,
+// it returns the Parser of the repeated argument for all the arguments
+// starting at its index, which is only exact when it is the last one.
+func (p *Program) parserAt(i int) Parser {
+	if p.repeat && i >= p.restIndex {
+		return p.parsers[p.restIndex]
+	}
+	if i < len(p.parsers) {
+		return p.parsers[i]
+	}
+	return Parser{}
+}
+
+// An ExtraPolicy determines what happens to the tokens on a line of standard
+// input that come after the limit set by SetLineLimit.
+type ExtraPolicy int
+
+const (
+	// DropExtra ignores the extra tokens.
+	DropExtra ExtraPolicy = iota
+	// RejectExtra treats the line as an error, like a parse error.
+	RejectExtra
+	// RawExtra passes the rest of the line, starting at the first extra token,
+	// to fn as a single string after the parsed arguments. It is not parsed,
+	// and quotation marks and backslashes in it are left untouched.
+	RawExtra
+)
+
+// SetLineLimit limits the number of tokens parsed from each line of standard
+// input to n when using SetEveryParser. Tokens after the nth one are handled
+// according to policy. If n is zero, there is no limit, which is the default.
+// The limit has no effect on command-line arguments or when using SetParsers,
+// since then the number of arguments is already fixed.
+//
+// Deprecated: Use Program.SetLineLimit instead.
+func SetLineLimit(n int, policy ExtraPolicy) {
+	std.SetLineLimit(n, policy)
+}
+
+// SetLineLimit is like the package-level SetLineLimit, but for p.
+func (p *Program) SetLineLimit(n int, policy ExtraPolicy) {
+	p.lineLimit = n
+	p.extraPolicy = policy
+}
+
+// A ValueError describes a string that could not be parsed as the type that a
+// Parser expects. Its message is meant for end users rather than programmers,
+// so it names the expected type in plain words instead of quoting strconv.
+type ValueError struct {
+	Value string // the string that was rejected
+	Type  string // the expected type, such as "whole number"
+	Err   error  // strconv.ErrSyntax or strconv.ErrRange
+	Min   string // smallest allowed value, used when Err is strconv.ErrRange
+	Max   string // largest allowed value, used when Err is strconv.ErrRange
+}
+
+func (e *ValueError) Error() string {
+	if e.Err == strconv.ErrRange {
+		if strings.HasPrefix(e.Value, "-") {
+			return fmt.Sprintf("%q is too small (min %s)", e.Value, e.Min)
+		}
+		return fmt.Sprintf("%q is too large (max %s)", e.Value, e.Max)
+	}
+	return fmt.Sprintf("%q is not %s", e.Value, withArticle(e.Type))
 }
 
-// Int is a Parser that parses a string as an int.
-var Int = Parser(func(s string) (interface{}, error) {
+// withArticle prefixes noun with the indefinite article "a" or "an".
+func withArticle(noun string) string {
+	if noun != "" && strings.ContainsRune("aeiou", rune(noun[0])) {
+		return "an " + noun
+	}
+	return "a " + noun
+}
+
+// Limits of the int and float64 types, formatted for use in a ValueError.
+var (
+	minInt     = strconv.FormatInt(-1<<(strconv.IntSize-1), 10)
+	maxInt     = strconv.FormatInt(1<<(strconv.IntSize-1)-1, 10)
+	minFloat64 = strconv.FormatFloat(-math.MaxFloat64, 'g', -1, 64)
+	maxFloat64 = strconv.FormatFloat(math.MaxFloat64, 'g', -1, 64)
+)
+
+// Int is a Parser that parses a string as an int. It accepts the same syntax
+// as integer literals in Go, including prefixes like "0x" and underscores
+// between digits, as in "1_000_000". See IntStyle for other separators.
+var Int = NewParser(func(s string) (interface{}, error) {
 	n, err := strconv.ParseInt(s, 0, 0)
 	if err != nil {
-		return nil, err.(*strconv.NumError).Err
+		err = err.(*strconv.NumError).Err
+		if err == strconv.ErrSyntax && beyondInt(s) {
+			err = strconv.ErrRange
+		}
+		return nil, &ValueError{s, "whole number", err, minInt, maxInt}
 	}
 	return int(n), nil
-})
+}).withInfo(parserInfo{name: "integer", typ: "int"})
+
+// beyondInt returns true if s is a number in exponent notation, such as
+// "1e999", that is too large or too small to be an int, so that Int can say so
+// rather than calling it malformed.
+func beyondInt(s string) bool {
+	f, err := strconv.ParseFloat(s, 64)
+	if err == nil && (math.IsInf(f, 0) || f != math.Trunc(f)) {
+		return false
+	}
+	if err != nil && err.(*strconv.NumError).Err != strconv.ErrRange {
+		return false
+	}
+	return f >= -math.MinInt64 || f < math.MinInt64
+}
 
-// Float64 is a Parser that parses a string as a float64.
-var Float64 = Parser(func(s string) (interface{}, error) {
+// Float64 is a Parser that parses a string as a float64. Like Int, it accepts
+// underscores between digits. See Float64Style for other separators.
+var Float64 = NewParser(func(s string) (interface{}, error) {
 	n, err := strconv.ParseFloat(s, 64)
 	if err != nil {
-		return nil, err.(*strconv.NumError).Err
+		return nil, &ValueError{s, "number", err.(*strconv.NumError).Err,
+			minFloat64, maxFloat64}
 	}
 	return n, nil
-})
+}).withInfo(parserInfo{name: "number", typ: "float64"})
 
 // Restrict creates a new Parser by restricting p with the predicate function
 // pred. If the string is parsed without error by p, the value will be passed on
@@ -105,16 +306,36 @@ var Float64 = Parser(func(s string) (interface{}, error) {
 // For example, Restrict can be used to return an error if a well-formed string
 // parsed as an integer is negative (when negative inputs do not make sense).
 func (p Parser) Restrict(pred func(interface{}) error) Parser {
-	return func(s string) (interface{}, error) {
-		x, err := p(s)
+	p = p.orString()
+	return p.derive(func(s string) (interface{}, error) {
+		x, err := p.Parse(s)
 		if err != nil {
 			return nil, err
 		}
-		if err := pred(x); err != nil {
+		if debugChecks {
+			err = checkPredicate(pred, x)
+		} else {
+			err = pred(x)
+		}
+		if err != nil {
 			return nil, err
 		}
 		return x, nil
-	}
+	})
+}
+
+// Pre returns a new Parser that transforms the string with fn before passing it
+// to p, so that cleanup such as removing a byte order mark, surrounding
+// brackets, or thousands separators does not have to be repeated in every
+// custom Parser. It keeps the metadata of p, and it composes with Restrict in
+// either order: p.Pre(fn).Restrict(pred) and p.Restrict(pred).Pre(fn) behave
+// the same. Error messages quote the transformed string. If p is the zero
+// Parser, the transformed string itself is returned. See also Trim.
+func (p Parser) Pre(fn func(string) string) Parser {
+	q := p.orString()
+	return q.derive(func(s string) (interface{}, error) {
+		return q.Parse(fn(s))
+	})
 }
 
 // AssertInts converts a list of interface{} to a list of ints using a type
@@ -139,28 +360,186 @@ func AssertFloat64s(args []interface{}) []float64 {
 	return floats
 }
 
-// apply parses args and, if no errors were encountered, calls fn with them and
-// returns true. If there were errors, it prints them and returns false. The
-// length of args must not exceed that of parsers unless repeat is true.
-func apply(fn func([]interface{}), args []string) bool {
-	success := true
-	parsed := make([]interface{}, len(args))
-	for i, arg := range args {
-		p := parsers[0]
-		if !repeat {
-			p = parsers[i]
+// An ArgError records the failure to parse a single argument.
+type ArgError struct {
+	Index   int    // position of the argument, starting from zero
+	Arg     string // the argument as it was received
+	Err     error  // the error returned by the Parser
+	Example string // example of a valid argument, if the Parser has one
+}
+
+func (e *ArgError) Error() string {
+	msg := e.Arg + ": " + e.Err.Error()
+	// A ValueError already quotes the argument in its message.
+	if _, ok := e.Err.(*ValueError); ok {
+		msg = e.Err.Error()
+	}
+	if e.Err == ErrEmpty {
+		msg = fmt.Sprintf("argument %d is empty", e.Index+1)
+	}
+	if e.Example != "" {
+		msg += " (e.g. " + e.Example + ")"
+	}
+	return msg
+}
+
+// A MultiError collects the errors from all the arguments of one invocation
+// that failed to parse. It is ordered by argument index.
+type MultiError []*ArgError
+
+// Error returns the messages of all the errors, one per line.
+func (m MultiError) Error() string {
+	msgs := make([]string, len(m))
+	for i, err := range m {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Errors returned by Parse when it receives the wrong number of arguments.
+var (
+	errTooFew  = errors.New("too few arguments")
+	errTooMany = errors.New("too many arguments")
+)
+
+// ErrEmpty is the error in an ArgError for an empty argument when empty
+// arguments are rejected (see SetRejectEmpty).
+var ErrEmpty = errors.New("empty argument")
+
+// SetRejectEmpty sets whether empty arguments are rejected before they reach
+// the parsers. They are allowed by default. On a line of input, an empty
+// argument is written as an empty pair of quotation marks, and it behaves
+// exactly like an empty argument on the command line. This setting applies to
+// both, and to every other input format.
+// Defaults filled in for missing optional arguments are never rejected.
+//
+// Deprecated: Use Program.SetRejectEmpty instead.
+func SetRejectEmpty(on bool) {
+	std.SetRejectEmpty(on)
+}
+
+// SetRejectEmpty is like the package-level SetRejectEmpty, but for p.
+func (p *Program) SetRejectEmpty(on bool) {
+	p.rejectEmpty = on
+}
+
+// Parse parses args using the parsers that were set by SetEveryParser or
+// SetParsers, without calling any function or exiting. It returns the parsed
+// values if all arguments were parsed successfully, followed by the computed
+// arguments added by Derive. Otherwise, it returns an error, which is a
+// MultiError if the number of arguments was correct but some of them did not
+// parse, or a DerivedError if a computed argument could not be computed.
+//
+// Deprecated: Use Program.Parse instead.
+func Parse(args []string) ([]interface{}, error) {
+	return std.Parse(args)
+}
+
+// Parse is like the package-level Parse, but it uses the parsers of p.
+func (p *Program) Parse(args []string) ([]interface{}, error) {
+	slots, err := p.arrange(len(args))
+	if err != nil {
+		return nil, err
+	}
+	var errs MultiError
+	parsed := make([]interface{}, len(slots))
+	for i, s := range slots {
+		q := p.parsers[s.decl]
+		arg := q.info().def
+		if s.arg >= 0 {
+			arg = p.normalize(args[s.arg])
+		}
+		if arg == "" && p.rejectEmpty && s.arg >= 0 {
+			errs = append(errs, &ArgError{i, arg, ErrEmpty, q.info().example})
+			continue
 		}
 		var err error
-		parsed[i], err = p(arg)
+		parsed[i], err = callParser(q, arg)
 		if err != nil {
-			success = false
-			log.Printf("%s: %s\n", arg, err)
+			errs = append(errs, &ArgError{i, arg, err, q.info().example})
 		}
 	}
-	if success {
-		fn(parsed)
+	if errs != nil {
+		return nil, errs
 	}
-	return success
+	return p.addDerived(parsed)
+}
+
+// logError prints err using l. Each error in a MultiError is printed on its own
+// line so that they all receive the log prefix.
+func logError(l *log.Logger, err error) {
+	if errs, ok := err.(MultiError); ok {
+		for _, e := range errs {
+			l.Println(e)
+		}
+		return
+	}
+	l.Println(err)
+}
+
+// apply parses args and, if no errors were encountered, calls fn with them and
+// returns true. If there were errors, it prints them and returns false.
+func (r *run) apply(fn func([]interface{}), args []string) bool {
+	r.beforeInvoke(args)
+	parsed, err := r.Parse(args)
+	r.recordInvocation(record{tokens: args}, parsed, err, 0)
+	if err != nil {
+		r.parseFailed.Store(true)
+		r.parseError(err)
+		r.afterInvoke(nil, err)
+		r.noteError("", err)
+		logError(r.log, err)
+		return false
+	}
+	_, err = r.callFn(fn, parsed)
+	r.afterInvoke(parsed, err)
+	if err != nil {
+		r.noteError("", err)
+		r.log.Println(err)
+		return false
+	}
+	return true
+}
+
+// A SourceKind describes where the arguments of an invocation came from.
+type SourceKind int
+
+const (
+	ArgvSource        SourceKind = iota // the command line
+	PipeSource                          // standard input, from a pipe
+	FileSource                          // a file, or standard input from one
+	InteractiveSource                   // standard input, from a terminal
+	URLSource                           // a URL given to SetInputURL
+)
+
+var sourceNames = [...]string{"argv", "pipe", "file", "interactive", "url"}
+
+func (k SourceKind) String() string {
+	return sourceNames[k]
+}
+
+// Source returns where the arguments passed to fn came from. Programs can use
+// it to adjust their output, for example by printing prompts or explanations
+// only when the user is typing arguments interactively.
+func Source() SourceKind {
+	if r := activeRun(); r != nil {
+		return r.source
+	}
+	return ArgvSource
+}
+
+// stdinSource determines what kind of source standard input is.
+func (p *Program) stdinSource() SourceKind {
+	if p.stdinIsTerminal() {
+		return InteractiveSource
+	}
+	if p.stdinOverride != nil {
+		return PipeSource
+	}
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode().IsRegular() {
+		return FileSource
+	}
+	return PipeSource
 }
 
 // Main takes a function fn and applies it to a list of arguments, which comes
@@ -172,7 +551,10 @@ func apply(fn func([]interface{}), args []string) bool {
 // SetEveryParser or SetParsers. If neither of those functions were called, the
 // arguments will all be strings. If an argument can be valid for the parser but
 // invalid for the program, a custom Parser should be written or an existing one
-// should be modified using Restrict (don't print error messages from fn).
+// should be modified using Restrict (don't print error messages from fn). For
+// errors that only fn can detect, such as I/O failures, fn should call Fail, or
+// be wrapped by HandleErrors, so that they are reported with the line that
+// caused them.
 //
 // When the program is invoked with "-h" or "--help", the usage message will be
 // printed to standard output. When invoked directly with the wrong number of
@@ -182,63 +564,310 @@ func apply(fn func([]interface{}), args []string) bool {
 // until an EOF is encountered (each line is like a separate invocation of fn).
 // When invoked with the correct number of arguments, they will be parsed and
 // passed to fn.
+//
+// Flags handled by parse itself, such as "--keep-going" and "--fail-fast", may
+// come before the arguments if the program turns them on; see SetBuiltinFlags
+// and SetVerbosityFlags. An argument of "--" ends them. Default flags and
+// arguments can be given in an environment variable named after the program,
+// such as SLEEP_OPTS for sleep. They are tokenized like a line of standard
+// input and placed before the real ones.
+//
+// Deprecated: Use Program.Main instead.
 func Main(fn func([]interface{})) {
-	args := os.Args[1:]
+	std.Main(fn)
+}
+
+// Main is like the package-level Main, but it runs p.
+func (p *Program) Main(fn func([]interface{})) {
+	p.newRun().main(fn)
+}
+
+// main does the work of Main for r.
+func (r *run) main(fn func([]interface{})) {
+	defer r.enter()()
+	if err := r.Validate(); err != nil {
+		r.noteError("", err)
+		r.log.Println(err)
+		r.exit(exitConfig)
+	}
+	r.begin()
+	args := r.stripFlags(append(r.envArgs(), r.commandLine()...))
+	if r.reduction != NoReduction {
+		r.reduced = &reducer{ints: true, reduction: r.reduction}
+		fn = r.reduceFn(fn)
+	}
+	if r.histogramBuckets > 0 {
+		r.histo = &histogram{}
+		fn = r.histogramFn(fn)
+	}
 	switch {
+	case r.schemaMode:
+		if err := r.writeSchema(r.output); err != nil {
+			r.fatal(err)
+		}
+	case r.typesMode:
+		if err := r.writeTypes(r.output); err != nil {
+			r.fatal(err)
+		}
+	case r.benchMode:
+		if err := r.runBench(fn, args); err != nil {
+			r.fatal(err)
+		}
+	case r.reference != nil:
+		success, err := r.runReference(fn, args)
+		if err != nil {
+			r.fatal(err)
+		}
+		r.finishInput(success)
+	case r.minimizeMode:
+		if err := r.runMinimize(fn, args); err != nil {
+			r.fatal(err)
+		}
 	case len(args) == 1 && (args[0] == "-h" || args[0] == "--help"):
-		fmt.Println(usage)
+		fmt.Fprint(r.output, r.helpMessage())
+	case r.filesMode && len(args) > 0:
+		r.source = FileSource
+		r.finishInput(r.mapFiles(fn, args))
+	case len(args) == 0 && r.inputURL != "":
+		r.source = URLSource
+		r.finishInput(r.mapInput(fn, r.inputURL))
 	case len(args) == 1 && args[0] == "-":
-		log.SetPrefix("error: ")
+		r.log = log.New(r.log.Writer(), "error: ", 0)
 		fallthrough
-	case len(args) == 0 && !term.IsTerminal(term.InputFD):
-		mapLines(fn)
-	case repeat && len(args) > 0, !repeat && len(args) == len(parsers):
-		if !apply(fn, args) {
-			os.Exit(1)
+	case len(args) == 0 && !r.stdinIsTerminal():
+		r.source = r.stdinSource()
+		r.mapLines(fn)
+	case r.repeat && len(args) > 0 && len(args) >= r.minArgs(),
+		!r.repeat && len(args) >= r.minArgs() && len(args) <= len(r.parsers):
+		if !r.apply(fn, args) {
+			r.exit(r.failureCode())
+		}
+		r.printSummaries()
+	case !r.repeat && len(args) == len(r.parsers)-1 &&
+		r.canAsk(r.parsers[len(args)]):
+		if !r.apply(fn, append(args, r.ask(r.parsers[len(args)]))) {
+			r.exit(r.failureCode())
 		}
+		r.printSummaries()
 	default:
-		log.SetPrefix("")
-		log.Println(usage)
-		os.Exit(1)
+		log.New(r.log.Writer(), "", 0).Println(r.usageMessage())
+		r.exit(r.exitUsage)
+	}
+	if err := r.end(0); err != nil {
+		r.fatal(err)
 	}
 }
 
+// begin starts the report (see SetReport) and takes the lock (see Exclusive),
+// unless the program is run by Invoke.
+func (r *run) begin() {
+	if r.invoking {
+		return
+	}
+	r.startReport()
+	if err := r.acquireLock(); err != nil {
+		r.fatal(err)
+	}
+}
+
+// end writes the report with the exit status code, removes the temporary
+// directory (see TempDir) if no other run is in progress, and releases the
+// lock when Main returns or Run returns early, unless the program is run by
+// Invoke.
+func (r *run) end(code int) error {
+	if r.invoking {
+		return nil
+	}
+	err := r.writeReport(code)
+	if !r.othersRunning() {
+		removeTempDir()
+	}
+	releaseLock()
+	return err
+}
+
 // mapLines reads one line at a time from standard input, splits the line into
-// tokens, parses them, and passes them to fn. Before returning, it calls
-// os.Exit with exit status 1 if any of the input lines had the wrong number of
-// arguments or if there were any parse errors.
-func mapLines(fn func([]interface{})) {
+// tokens, parses them, and passes them to fn. Before returning, it exits with a
+// nonzero status if any of the input lines had the wrong number of arguments
+// or if there were any parse errors. Unless keepGoing is true, it
+// stops reading at the first such line.
+func (r *run) mapLines(fn func([]interface{})) {
+	in, err := r.stdin()
+	if err != nil {
+		r.fatal(err)
+	}
+	input, done := in, func() {}
+	switch {
+	case r.source == InteractiveSource && r.lineEditing && !r.deterministic:
+		input = r.newLineEditor(in)
+	case r.source == InteractiveSource && r.prompt != "":
+		input = r.newPromptReader(in)
+	case r.source != InteractiveSource:
+		input, done = r.watchInput("-", in)
+	}
+	r.reportInput("-")
+	success := r.mapReader(fn, input, r.log, 0)
+	done()
+	r.finishInput(success)
+}
+
+// finishInput prints the summary of the input at the Verbose level, the
+// checksums of the inputs (see SetChecksums), and the result of the reduction
+// and the histogram (see SetReduction and Histogram), and exits with a nonzero
+// status (see SetExitCodes) unless the input was processed successfully.
+func (r *run) finishInput(success bool) {
+	if r.verbosity >= Verbose {
+		r.log.Println(r.summary())
+	}
+	for _, c := range r.Checksums() {
+		r.log.Println(c)
+	}
+	r.printSummaries()
+	if !success {
+		r.exit(r.failureCode())
+	}
+}
+
+// mapReader is like mapLines, but it reads from in and prints errors using l.
+// If first is positive, line numbers starting from it are added to the prefix
+// of l for each line. It returns true if all the lines were parsed
+// successfully.
+//
+// Unless the input is interactive or copied to a tee (see SetTee), which must
+// only receive the records that were reached, reading happens in a pipeline
+// (see mapPipelined) so that it overlaps with calls to fn.
+func (r *run) mapReader(fn func([]interface{}), in io.Reader, l *log.Logger,
+	first int) bool {
+	if r.source != InteractiveSource && r.tee == nil {
+		ctx := r.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		ctx, cancel := context.WithCancel(ctx)
+		records := r.newRecordReader(interruptible(ctx, in))
+		return r.mapPipelined(fn, records, cancel, l, first)
+	}
+	records := r.newRecordReader(in)
 	success := true
-	scanner := newLineScanner(os.Stdin)
-	for scanner.Scan() {
-		args := tokenize(scanner.Bytes())
-		switch {
-		case !repeat && len(args) < len(parsers):
+	prefix := l.Prefix()
+	for n := 1; ; n++ {
+		if first > 0 {
+			l.SetPrefix(prefix + strconv.Itoa(lineNumber(first, n)) + ": ")
+		}
+		rec, err := records.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
 			success = false
-			log.Println("too few arguments")
-		case !repeat && len(args) > len(parsers):
+			r.noteError(l.Prefix(), err)
+			l.Println(err)
+			break
+		}
+		if !r.mapRecord(fn, rec, lineNumber(first, n), l) {
 			success = false
-			log.Println("too many arguments")
-		default:
-			if !apply(fn, args.strings()) {
-				success = false
+			if !r.keepGoing {
+				break
 			}
 		}
+		if r.stopping() {
+			break
+		}
 	}
+	return success
+}
 
-	if err := scanner.Err(); err != nil {
-		success = false
-		log.Println(err)
+// lineNumber returns the line number of the nth record read by mapReader, given
+// its first argument.
+func lineNumber(first, n int) int {
+	if first > 0 {
+		return first + n - 1
 	}
-	if !success {
-		os.Exit(1)
+	return n
+}
+
+// mapRecord applies fn to the tokens from line number n of the input. It
+// returns false if the line had the wrong number of arguments or any parse
+// errors, which it prints using l unless the verbosity level is Quiet.
+func (r *run) mapRecord(fn func([]interface{}), rec record, n int,
+	l *log.Logger) bool {
+	parsed, err := r.parseRecord(rec)
+	return r.applyRecord(fn, rec, parsed, err, n, l)
+}
+
+// parseRecord parses the tokens of rec, returning the arguments for fn.
+func (p *Program) parseRecord(rec record) ([]interface{}, error) {
+	if rec.err != nil {
+		return nil, rec.err
+	}
+	if rec.rest != nil && p.extraPolicy == RejectExtra {
+		return nil, fmt.Errorf("too many arguments (at most %d per line)",
+			p.lineLimit)
+	}
+	parsed, err := p.Parse(p.trimTokens(rec.tokens))
+	if err != nil {
+		return nil, err
+	}
+	if rec.rest != nil && p.extraPolicy == RawExtra {
+		parsed = append(parsed, string(rec.rest))
+	}
+	if rec.extra != nil {
+		parsed = append(parsed, rec.extra)
+	}
+	return parsed, nil
+}
+
+// summary returns a summary of the number of records processed by
+// applyRecord, such as "10 lines, 1 failed, 2 skipped".
+func (r *run) summary() string {
+	return fmt.Sprintf("%d lines, %d failed, %d skipped",
+		r.counts.lines.Load(), r.counts.failed.Load(), r.counts.skipped.Load())
+}
+
+// applyRecord finishes what mapRecord does once parseRecord has returned parsed
+// and err for rec.
+func (r *run) applyRecord(fn func([]interface{}), rec record,
+	parsed []interface{}, err error, n int, l *log.Logger) bool {
+	if r.verbosity >= Verbose {
+		l.Printf("line %d: %q\n", n, rec.tokens)
 	}
+	r.counts.lines.Add(1)
+	r.recordInvocation(rec, parsed, err, n)
+	r.beforeInvoke(rec.tokens)
+	if err != nil {
+		r.parseFailed.Store(true)
+		r.parseError(err)
+	} else {
+		if r.outputPrefix != "" {
+			fn = r.withOutputPrefix(fn, n)
+		}
+		var skipped bool
+		skipped, err = r.callFn(fn, parsed)
+		if skipped {
+			r.counts.skipped.Add(1)
+		}
+	}
+	r.afterInvoke(parsed, err)
+	if err != nil {
+		r.counts.failed.Add(1)
+		r.noteError(l.Prefix(), err)
+		if r.verbosity > Quiet {
+			logError(l, err)
+		}
+		r.writeReject(rec)
+		return false
+	}
+	return true
 }
 
 // newLineScanner returns a new bufio.Scanner that scans from r one line at a
 // time. It will scan multi-line tokens if newlines are escaped with a backslash
-// or if they are surrounded by quotation marks.
-func newLineScanner(r io.Reader) *bufio.Scanner {
+// or if they are surrounded by quotation marks. If a custom Splitter has been
+// installed, it uses that instead.
+func (p *Program) newLineScanner(r io.Reader) *bufio.Scanner {
+	if p.splitter != nil {
+		return p.newScanner(r)
+	}
 	scanner := bufio.NewScanner(lineReader{r})
 	scanner.Split(scanLines)
 	return scanner
@@ -252,9 +881,17 @@ type lineReader struct {
 
 func (r lineReader) Read(data []byte) (n int, err error) {
 	n, err = r.source.Read(data)
+	n = len(removeEscapedNewlines(data[:n]))
+	return
+}
+
+// removeEscapedNewlines removes each backslash followed by a newline from data
+// in place, returning the shortened slice.
+func removeEscapedNewlines(data []byte) []byte {
+	n := len(data)
 	shift := 0
 	escaped := false
-	for i, c := range data[:n] {
+	for i, c := range data {
 		if escaped && c == '\n' {
 			shift += 2
 			n -= 2
@@ -264,7 +901,7 @@ func (r lineReader) Read(data []byte) (n int, err error) {
 		// An unescaped backslash escapes the next character.
 		escaped = !escaped && c == '\\'
 	}
-	return
+	return data[:n]
 }
 
 // dropCR drops a terminal carriage return from the data.
@@ -282,21 +919,13 @@ func scanLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
 	if atEOF && len(data) == 0 {
 		return
 	}
-	escaped := false
-	quote := byte(0)
+	var state quoteState
 	for i, c := range data {
-		if quote == 0 {
-			if c == '\n' {
-				return i + 1, dropCR(data[:i]), nil
-			}
-			if !escaped && (c == '\'' || c == '"') {
-				quote = c
-			}
-		} else if !escaped && c == quote {
-			quote = 0
+		// Escaped newlines have already been removed by lineReader.
+		if state.quote == 0 && c == '\n' {
+			return i + 1, dropCR(data[:i]), nil
 		}
-		// An unescaped backslash escapes the next character.
-		escaped = !escaped && c == '\\'
+		state.step(c)
 	}
 	// If we're at EOF, we have a final, non-terminated line. Return it.
 	if atEOF {
@@ -344,37 +973,40 @@ func countMaxTokens(data []byte) int {
 // and quotation marks are excluded from the returned tokens unless escaped with
 // a backslash. They will also be removed from the data array.
 func tokenize(data []byte) tokenList {
-	tokens := make(tokenList, 0, countMaxTokens(data))
+	tokens, _ := tokenizeN(data, -1)
+	return tokens
+}
+
+// tokenizeN is like tokenize, but it stops after n tokens if n is not
+// negative. If there is another token after the nth one, the rest of data
+// starting with that token is returned unmodified. Otherwise, rest is nil.
+func tokenizeN(data []byte, n int) (tokens tokenList, rest []byte) {
+	max := countMaxTokens(data)
+	if n >= 0 && n < max {
+		max = n
+	}
+	tokens = make(tokenList, 0, max)
 	start := -1 // start index for token in data
 	shift := 0  // for deleting characters
 	wasSpace := true
-	escaped := false
-	quote := byte(0)
+	var state quoteState
 	for i, c := range data {
-		del := false
-		if !escaped {
-			if quote == 0 {
-				if c == '\'' || c == '"' {
-					quote = c
-					del = true
-				}
-				space := unicode.IsSpace(rune(c))
-				if wasSpace && !space {
-					start = i - shift
-				} else if !wasSpace && space {
-					tokens = append(tokens, data[start:i-shift])
-					start = -1
-				}
-				wasSpace = space
-			} else if c == quote {
-				quote = 0
-				del = true
+		if state.bare() {
+			space := unicode.IsSpace(rune(c))
+			if wasSpace && !space && len(tokens) == n {
+				// Nothing at or after i has been shifted yet.
+				return tokens, data[i:]
+			}
+			if wasSpace && !space {
+				start = i - shift
+			} else if !wasSpace && space {
+				tokens = append(tokens, data[start:i-shift])
+				start = -1
 			}
+			wasSpace = space
 		}
-		// An unescaped backslash escapes the next character.
-		escaped = !escaped && c == '\\'
 		// Delete unescaped backslashes or quotation marks.
-		if escaped || del {
+		if state.step(c) {
 			shift++
 		} else {
 			data[i-shift] = c
@@ -384,5 +1016,5 @@ func tokenize(data []byte) tokenList {
 	if start != -1 {
 		tokens = append(tokens, data[start:len(data)-shift])
 	}
-	return tokens
+	return tokens, nil
 }
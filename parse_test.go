@@ -6,6 +6,8 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
+	"log"
 	"reflect"
 	"strings"
 	"testing"
@@ -24,10 +26,10 @@ var usageTests = []struct {
 
 func TestUsage(t *testing.T) {
 	for i, test := range usageTests {
-		programName = test.name
+		std.name = test.name
 		SetUsage(test.args)
-		if usage != test.usage {
-			t.Errorf("%d. programName = %q; SetUsage(%q)\nusage = %q\n"+
+		if usage := std.usageMessage(); usage != test.usage {
+			t.Errorf("%d. name = %q; SetUsage(%q)\nusage = %q\n"+
 				"expected %q", i, test.name, test.args, usage, test.usage)
 		}
 	}
@@ -74,7 +76,7 @@ func formatFail(fail bool) string {
 
 func TestParsers(t *testing.T) {
 	for i, test := range parserTests {
-		value, err := test.parser(test.input)
+		value, err := test.parser.Parse(test.input)
 		if value != test.value || (err != nil) != test.fail {
 			t.Errorf("%d. %s(%q)\nreturned %s and %s\nexpected %s and %s",
 				i, test.name, test.input, formatValue(value),
@@ -84,6 +86,141 @@ func TestParsers(t *testing.T) {
 	}
 }
 
+var errorTests = []struct {
+	parser Parser
+	name   string
+	input  string
+	msg    string
+}{
+	{Int, "Int", "abc", `"abc" is not a whole number`},
+	{Int, "Int", "1e999", `"1e999" is too large (max ` + maxInt + ")"},
+	{Int, "Int", "-1e999", `"-1e999" is too small (min ` + minInt + ")"},
+	{Int, "Int", "1e3", `"1e3" is not a whole number`},
+	{Int, "Int", "1.5e30", `"1.5e30" is too large (max ` + maxInt + ")"},
+	{Int, "Int", "inf", `"inf" is not a whole number`},
+	{Int, "Int", "99999999999999999999", `"99999999999999999999" is too ` +
+		"large (max " + maxInt + ")"},
+	{Int, "Int", "-99999999999999999999", `"-99999999999999999999" is too ` +
+		"small (min " + minInt + ")"},
+	{Float64, "Float64", "a", `"a" is not a number`},
+	{Float64, "Float64", "1e999", `"1e999" is too large ` +
+		"(max 1.7976931348623157e+308)"},
+	{Int.Restrict(positive), "Int.Restrict(positive)", "-5",
+		"cannot be negative"},
+}
+
+func TestErrors(t *testing.T) {
+	for i, test := range errorTests {
+		_, err := test.parser.Parse(test.input)
+		if err == nil || err.Error() != test.msg {
+			t.Errorf("%d. %s(%q)\nreturned error %v\nexpected %q",
+				i, test.name, test.input, err, test.msg)
+		}
+	}
+}
+
+func TestExampleError(t *testing.T) {
+	defer SetEveryParser(Parser{})
+	SetParsers(Int.Example("42"), Int.Restrict(positive).Example("7"))
+	_, err := Parse([]string{"x", "-1"})
+	msg := `"x" is not a whole number (e.g. 42)` + "\n" +
+		"-1: cannot be negative (e.g. 7)"
+	if err == nil || err.Error() != msg {
+		t.Errorf("Parse returned error %v\nexpected %q", err, msg)
+	}
+}
+
+func TestParseDefaults(t *testing.T) {
+	defer SetEveryParser(Parser{})
+	SetParsers(Int, Int.Default("2"), Float64.Default("x"))
+	tests := []struct {
+		args   []string
+		values []interface{}
+		err    string
+	}{
+		{[]string{}, nil, errTooFew.Error()},
+		{[]string{"1"}, nil, `"x" is not a number`},
+		{[]string{"1", "3", "4"}, []interface{}{1, 3, 4.0}, ""},
+		{[]string{"1", "3", "4", "5"}, nil, errTooMany.Error()},
+	}
+	for i, test := range tests {
+		values, err := Parse(test.args)
+		msg := ""
+		if err != nil {
+			msg = err.Error()
+		}
+		if !reflect.DeepEqual(values, test.values) || msg != test.err {
+			t.Errorf("%d. Parse(%q)\nreturned %v and %q\nexpected %v and %q",
+				i, test.args, values, msg, test.values, test.err)
+		}
+	}
+}
+
+func TestParseVariadic(t *testing.T) {
+	defer func() {
+		SetEveryParser(Parser{})
+		SetNames()
+	}()
+	SetVariadic(Int, Int.Default("2"), Float64)
+	SetNames("a", "b", "c")
+	tests := []struct {
+		args   []string
+		values []interface{}
+		err    string
+	}{
+		{[]string{}, nil, errTooFew.Error()},
+		{[]string{"1"}, []interface{}{1, 2}, ""},
+		{[]string{"1", "3"}, []interface{}{1, 3}, ""},
+		{[]string{"1", "3", "4", "5"}, []interface{}{1, 3, 4.0, 5.0}, ""},
+		{[]string{"1", "3", "4", "x"}, nil, `"x" is not a number`},
+	}
+	for i, test := range tests {
+		values, err := Parse(test.args)
+		msg := ""
+		if err != nil {
+			msg = err.Error()
+		}
+		if !reflect.DeepEqual(values, test.values) || msg != test.err {
+			t.Errorf("%d. Parse(%q)\nreturned %v and %q\nexpected %v and %q",
+				i, test.args, values, msg, test.values, test.err)
+		}
+	}
+	if s := std.usageArgs(); s != "a [b] [c ...]" {
+		t.Errorf("usage arguments are %q, expected %q", s, "a [b] [c ...]")
+	}
+	if err := Validate(); err != nil {
+		t.Errorf("Validate returned %v", err)
+	}
+	SetVariadic(Int, Int.Default("2"))
+	if err := Validate(); err == nil {
+		t.Error("Validate accepted a default for the repeated argument")
+	}
+}
+
+func TestParseMultiError(t *testing.T) {
+	defer SetEveryParser(Parser{})
+	SetParsers(Int, Parser{}, Float64, Int)
+	_, err := Parse([]string{"x", "y", "1.5", "9e9999"})
+	errs, ok := err.(MultiError)
+	if !ok || len(errs) != 2 || errs[0].Index != 0 || errs[1].Index != 3 {
+		t.Fatalf("Parse returned error %#v\nexpected MultiError for "+
+			"arguments 0 and 3", err)
+	}
+	msg := `"x" is not a whole number` + "\n" + `"9e9999" is too large (max ` +
+		maxInt + ")"
+	if err.Error() != msg {
+		t.Errorf("err.Error() = %q\nexpected %q", err.Error(), msg)
+	}
+	if _, err := Parse([]string{"1"}); err != errTooFew {
+		t.Errorf("Parse with one argument returned %v\nexpected %v",
+			err, errTooFew)
+	}
+	values, err := Parse([]string{"1", "y", "1.5", "2"})
+	if err != nil || !reflect.DeepEqual(values, []interface{}{1, "y", 1.5, 2}) {
+		t.Errorf("Parse returned %v and %v", values, err)
+	}
+}
+
 var scanTests = []struct {
 	input string
 	lines []string
@@ -101,7 +238,7 @@ var scanTests = []struct {
 
 func TestLineScanner(t *testing.T) {
 	for i, test := range scanTests {
-		scanner := newLineScanner(strings.NewReader(test.input))
+		scanner := std.newLineScanner(strings.NewReader(test.input))
 		lines := make([]string, 0, len(test.lines))
 		for scanner.Scan() {
 			lines = append(lines, scanner.Text())
@@ -165,3 +302,176 @@ func TestTokenize(t *testing.T) {
 		}
 	}
 }
+
+var tokenizeNTests = []struct {
+	input string
+	n     int
+	count int
+	rest  string
+}{
+	{"", 0, 0, ""},
+	{"a b c", 0, 0, "a b c"},
+	{"a b c", 2, 2, "c"},
+	{"a b c", 3, 3, ""},
+	{"a b c  ", 3, 3, ""},
+	{`a\ b "c d"  'e  f' g`, 2, 2, `'e  f' g`},
+	{"x\t\t\\y z", 1, 1, "\\y z"},
+}
+
+func TestTokenizeN(t *testing.T) {
+	for i, test := range tokenizeNTests {
+		tokens, rest := tokenizeN([]byte(test.input), test.n)
+		if len(tokens) != test.count || string(rest) != test.rest ||
+			(rest == nil) != (test.rest == "") {
+			t.Errorf("%d. tokenizeN([]byte(%#q), %d)\nreturned %q and %q\n"+
+				"expected %d tokens and %q", i, test.input, test.n, tokens,
+				rest, test.count, test.rest)
+		}
+	}
+}
+
+// emptyArgTests pairs lines of input in various formats with the command-line
+// arguments that they should be equivalent to.
+var emptyArgTests = []struct {
+	format InputFormat
+	line   string
+	argv   []string
+}{
+	{Shell, `''`, []string{""}},
+	{Shell, `a "" b`, []string{"a", "", "b"}},
+	{Shell, `'' ''`, []string{"", ""}},
+	{Shell, `a ''`, []string{"a", ""}},
+	{Shell, `'''' x`, []string{"", "x"}},
+	{CSV, `a,,b`, []string{"a", "", "b"}},
+	{CSV, `"",x`, []string{"", "x"}},
+	{TSV, "a\t\tb", []string{"a", "", "b"}},
+	{JSONLines, `["", "x", null]`, []string{"", "x", ""}},
+}
+
+func TestEmptyArgsAcrossModes(t *testing.T) {
+	defer func() {
+		SetInputFormat(Shell)
+		SetRejectEmpty(false)
+	}()
+	for _, reject := range []bool{false, true} {
+		SetRejectEmpty(reject)
+		for i, test := range emptyArgTests {
+			SetInputFormat(test.format)
+			var fromLine []interface{}
+			fn := func(args []interface{}) { fromLine = args }
+			r := strings.NewReader(test.line + "\n")
+			l := log.New(io.Discard, "", 0)
+			lineOK := std.newRun().mapReader(fn, r, l, 0)
+			fromArgv, err := Parse(test.argv)
+			if lineOK != (err == nil) || lineOK == reject {
+				t.Errorf("%d. with reject = %t: line ok = %t, argv error = %v",
+					i, reject, lineOK, err)
+			}
+			if !reflect.DeepEqual(fromLine, fromArgv) {
+				t.Errorf("%d. line %q gave %q\nargv %q gave %q", i, test.line,
+					fromLine, test.argv, fromArgv)
+			}
+		}
+	}
+}
+
+func TestRejectEmptyError(t *testing.T) {
+	defer func() {
+		SetRejectEmpty(false)
+		SetEveryParser(Parser{})
+	}()
+	SetRejectEmpty(true)
+	SetParsers(Parser{}, Parser{}, Int.Default("3"))
+	_, err := Parse([]string{"a", ""})
+	if err == nil || err.Error() != "argument 2 is empty" {
+		t.Errorf("Parse returned %v, expected an empty argument error", err)
+	}
+	SetParsers(Parser{}, Parser{}.Default(""))
+	if _, err := Parse([]string{"a"}); err != nil {
+		t.Errorf("Parse rejected an empty default: %v", err)
+	}
+}
+
+func TestZeroParsers(t *testing.T) {
+	defer SetEveryParser(Parser{})
+	SetParsers(Parser{}, Int)
+	if p := std.parserAt(0); p.isZero() || p.info().typ != "string" {
+		t.Error("zero parser was not replaced with String")
+	}
+	values, err := Parse([]string{" x ", "1"})
+	if err != nil || values[0] != " x " || values[1] != 1 {
+		t.Errorf("Parse returned %v, %v", values, err)
+	}
+	nonEmpty := Parser{}.Restrict(func(x interface{}) error {
+		if x.(string) == "" {
+			return ErrEmpty
+		}
+		return nil
+	})
+	if x, err := nonEmpty.Parse("a"); x != "a" || err != nil {
+		t.Errorf("Restrict on the zero Parser returned %v, %v", x, err)
+	}
+	if _, err := nonEmpty.Parse(""); err != ErrEmpty {
+		t.Errorf("Restrict on the zero Parser returned error %v", err)
+	}
+	a := Parser{}.Help("first").Default("a")
+	b := Parser{}.Help("second")
+	if a.info().help != "first" || a.info().def != "a" ||
+		b.info().help != "second" || b.info().hasDefault {
+		t.Errorf("zero-based Parsers share metadata: %+v and %+v", a.info(),
+			b.info())
+	}
+}
+
+func TestParserInfo(t *testing.T) {
+	ex := Int.Example("42")
+	if ex.info().example != "42" || Int.info().example != "" ||
+		ex.info().typ != "int" {
+		t.Errorf("Example changed the metadata of Int: %+v", Int.info())
+	}
+	if x, err := ex.Parse("7"); x != 7 || err != nil {
+		t.Errorf("Int.Example returned %v, %v", x, err)
+	}
+	custom := NewParser(func(s string) (interface{}, error) {
+		t.Errorf("custom Parser was called with %q", s)
+		return s, nil
+	})
+	if info := custom.info(); !reflect.DeepEqual(info, parserInfo{}) {
+		t.Errorf("custom Parser has metadata %+v", info)
+	}
+}
+
+var preTests = []struct {
+	p        Parser
+	s        string
+	expected interface{}
+	err      string
+}{
+	{Int.Pre(stripBrackets), "[42]", 42, ""},
+	{Int.Pre(stripBrackets), "[x]", nil, `"x" is not a whole number`},
+	{Parser{}.Pre(stripBrackets), "[a b]", "a b", ""},
+	{Int.Pre(stripBrackets).Restrict(positive), "[-1]", nil,
+		"cannot be negative"},
+	{Int.Restrict(positive).Pre(stripBrackets), "[-1]", nil,
+		"cannot be negative"},
+	{Int.Restrict(positive).Pre(stripBrackets), "[1]", 1, ""},
+	{Int.Pre(stripBrackets).Pre(strings.TrimSpace), " [7] ", 7, ""},
+}
+
+func stripBrackets(s string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+}
+
+func TestPre(t *testing.T) {
+	for i, test := range preTests {
+		x, err := test.p.Parse(test.s)
+		if x != test.expected || err == nil && test.err != "" ||
+			err != nil && err.Error() != test.err {
+			t.Errorf("%d. parsing %q returned %v, %v; expected %v, %q", i,
+				test.s, x, err, test.expected, test.err)
+		}
+	}
+	if ex := Int.Example("5").Pre(stripBrackets).info().example; ex != "5" {
+		t.Errorf("Pre lost the example, got %q", ex)
+	}
+}
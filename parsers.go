@@ -0,0 +1,151 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// withPrefix returns the elements of list that begin with prefix.
+func withPrefix(list []string, prefix string) []string {
+	var matches []string
+	for _, s := range list {
+		if strings.HasPrefix(s, prefix) {
+			matches = append(matches, s)
+		}
+	}
+	return matches
+}
+
+// choiceError returns an error for s not being one of choices.
+func choiceError(s string, choices []string) error {
+	return fmt.Errorf("%q is not one of %s", s, strings.Join(choices, ", "))
+}
+
+// Choice returns a Parser that accepts only the given strings. It returns the
+// string unchanged. Its Suggest method returns the matching choices.
+func Choice(choices ...string) Parser {
+	choices = append([]string(nil), choices...)
+	p := NewParser(func(s string) (interface{}, error) {
+		for _, c := range choices {
+			if s == c {
+				return s, nil
+			}
+		}
+		return nil, choiceError(s, choices)
+	})
+	p = p.withInfo(parserInfo{name: strings.Join(choices, "|"),
+		literal: true, typ: "choice"})
+	return p.WithSuggest(func(prefix string) []string {
+		return withPrefix(choices, prefix)
+	})
+}
+
+// Enum returns a Parser that accepts the keys of values and returns the value
+// associated with the key. It is useful for arguments that select one of a few
+// named constants. Its Suggest method returns the matching keys.
+func Enum(values map[string]interface{}) Parser {
+	names := make([]string, 0, len(values))
+	copied := make(map[string]interface{}, len(values))
+	for name, v := range values {
+		names = append(names, name)
+		copied[name] = v
+	}
+	sort.Strings(names)
+	p := NewParser(func(s string) (interface{}, error) {
+		if v, ok := copied[s]; ok {
+			return v, nil
+		}
+		return nil, choiceError(s, names)
+	})
+	p = p.withInfo(parserInfo{name: strings.Join(names, "|"), literal: true,
+		typ: "enum"})
+	return p.WithSuggest(func(prefix string) []string {
+		return withPrefix(names, prefix)
+	})
+}
+
+// ExistingFile is a Parser that accepts the path of a file or directory that
+// exists. It returns the path unchanged. Its Suggest method returns the paths
+// that begin with the prefix, with a slash after the names of directories.
+var ExistingFile = NewParser(func(s string) (interface{}, error) {
+	if _, err := os.Stat(s); err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%q does not exist", s)
+		}
+		return nil, err
+	}
+	return s, nil
+}).withInfo(parserInfo{name: "file", typ: "file"}).
+	WithSuggest(suggestFiles)
+
+// suggestFiles returns the paths that begin with prefix.
+func suggestFiles(prefix string) []string {
+	matches, _ := filepath.Glob(globEscape(prefix) + "*")
+	for i, m := range matches {
+		if info, err := os.Stat(m); err == nil && info.IsDir() {
+			matches[i] = m + string(filepath.Separator)
+		}
+	}
+	return matches
+}
+
+// globEscape escapes the special characters of filepath.Match in s.
+func globEscape(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		if strings.ContainsRune(`*?[\`, c) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// Confirm is a Parser that parses "y" or "yes" as true and "n" or "no" as
+// false, ignoring case. It is meant to be the last argument of a program that
+// does something destructive. If the user runs the program at a terminal
+// without that argument, they are asked "Proceed? [y/N]" instead of being shown
+// the usage message, and an empty answer means no.
+var Confirm = NewParser(func(s string) (interface{}, error) {
+	switch strings.ToLower(s) {
+	case "y", "yes":
+		return true, nil
+	case "n", "no":
+		return false, nil
+	}
+	return nil, fmt.Errorf("%q is not yes or no", s)
+}).withInfo(parserInfo{name: "y|n", literal: true, typ: "confirm",
+	question: "Proceed? [y/N] "})
+
+// Lower returns a Parser that converts the string to lower case before passing
+// it to p, so that p effectively ignores case. For example, Lower(Choice("red",
+// "green")) accepts "Red" and "GREEN", returning "red" and "green". If p is
+// the zero Parser, the lower-case string itself is returned. See also
+// SetNormalization.
+func Lower(p Parser) Parser {
+	return foldCase(p, strings.ToLower)
+}
+
+// Upper is like Lower, but it converts the string to upper case.
+func Upper(p Parser) Parser {
+	return foldCase(p, strings.ToUpper)
+}
+
+// foldCase does the work of Lower and Upper.
+func foldCase(p Parser, fold func(string) string) Parser {
+	q := p.orString()
+	return q.derive(func(s string) (interface{}, error) {
+		return q.Parse(fold(s))
+	}).with(func(info *parserInfo) {
+		if suggest := info.suggest; suggest != nil {
+			info.suggest = func(prefix string) []string {
+				return suggest(fold(prefix))
+			}
+		}
+	})
+}
@@ -0,0 +1,81 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"reflect"
+	"testing"
+)
+
+var color = Enum(map[string]interface{}{"red": 1, "green": 2, "grey": 3})
+
+var choiceTests = []struct {
+	parser Parser
+	name   string
+	input  string
+	value  interface{}
+	msg    string
+}{
+	{Choice("a", "b"), "Choice(a, b)", "a", "a", ""},
+	{Choice("a", "b"), "Choice(a, b)", "c", nil, `"c" is not one of a, b`},
+	{color, "color", "green", 2, ""},
+	{color, "color", "blue", nil, `"blue" is not one of green, grey, red`},
+	{ExistingFile, "ExistingFile", "parse.go", "parse.go", ""},
+	{ExistingFile, "ExistingFile", "nonexistent", nil,
+		`"nonexistent" does not exist`},
+	{Confirm, "Confirm", "YES", true, ""},
+	{Confirm, "Confirm", "n", false, ""},
+	{Confirm, "Confirm", "", nil, `"" is not yes or no`},
+	{Lower(Choice("a", "b")), "Lower(Choice(a, b))", "B", "b", ""},
+	{Lower(color), "Lower(color)", "Grey", 3, ""},
+	{Lower(Parser{}), "Lower(Parser{})", "ÀB", "àb", ""},
+	{Upper(Choice("A", "B")), "Upper(Choice(A, B))", "a", "A", ""},
+	{Upper(Choice("A", "B")), "Upper(Choice(A, B))", "c", nil,
+		`"C" is not one of A, B`},
+}
+
+func TestChoiceParsers(t *testing.T) {
+	for i, test := range choiceTests {
+		value, err := test.parser.Parse(test.input)
+		msg := ""
+		if err != nil {
+			msg = err.Error()
+		}
+		if value != test.value || msg != test.msg {
+			t.Errorf("%d. %s(%q)\nreturned %s and %q\nexpected %s and %q",
+				i, test.name, test.input, formatValue(value), msg,
+				formatValue(test.value), test.msg)
+		}
+	}
+}
+
+var suggestTests = []struct {
+	parser      Parser
+	name        string
+	prefix      string
+	suggestions []string
+}{
+	{Int, "Int", "1", nil},
+	{Parser{}, "nil", "", nil},
+	{Choice("ab", "ac", "b"), "Choice", "a", []string{"ab", "ac"}},
+	{Choice("ab", "ac", "b").Restrict(func(interface{}) error { return nil }),
+		"Choice.Restrict", "b", []string{"b"}},
+	{color, "color", "gr", []string{"green", "grey"}},
+	{ExistingFile, "ExistingFile", "parse_t", []string{"parse_test.go"}},
+	{Int.WithSuggest(func(string) []string { return []string{"42"} }),
+		"Int.WithSuggest", "", []string{"42"}},
+	{Lower(color), "Lower(color)", "GR", []string{"green", "grey"}},
+}
+
+func TestSuggest(t *testing.T) {
+	for i, test := range suggestTests {
+		suggestions := test.parser.Suggest(test.prefix)
+		if !reflect.DeepEqual(suggestions, test.suggestions) {
+			t.Errorf("%d. %s.Suggest(%q)\nreturned %q\nexpected %q", i,
+				test.name, test.prefix, suggestions, test.suggestions)
+		}
+	}
+	if Int.Suggest("") != nil {
+		t.Error("WithSuggest modified the original Parser")
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+// Package parsetest helps test custom parsers written for package parse, in
+// the same table-driven style that parse uses for its own parsers, and fuzz
+// them with inputs like those that parse produces.
+package parsetest
+
+import (
+	"fmt"
+	"github.com/mk12/parse"
+	"reflect"
+	"testing"
+)
+
+// A Case is a string to parse and the expected result of parsing it.
+type Case struct {
+	Input string      // the string passed to the Parser
+	Value interface{} // the expected value, compared with reflect.DeepEqual
+	Fail  bool        // whether an error is expected
+	Err   string      // the expected error message, if not empty
+}
+
+// TestParser runs p on the input of each case and reports the cases whose
+// result was not as expected using t.Errorf. A case expects an error if Fail is
+// true or Err is not empty, in which case the value must be nil. Failure
+// messages give the index of the case, its input, and the returned and expected
+// values and errors. For example:
+//
+//	func TestPort(t *testing.T) {
+//		parsetest.TestParser(t, parse.Port, []parsetest.Case{
+//			{Input: "80", Value: 80},
+//			{Input: "0", Err: "port 0 is reserved (ports are 1 to 65535)"},
+//			{Input: "http", Fail: true},
+//		})
+//	}
+func TestParser(t testing.TB, p parse.Parser, cases []Case) {
+	t.Helper()
+	for i, c := range cases {
+		value, err := p.Parse(c.Input)
+		fail := c.Fail || c.Err != ""
+		if !reflect.DeepEqual(value, c.Value) || (err != nil) != fail ||
+			err != nil && c.Err != "" && err.Error() != c.Err {
+			t.Errorf("%d. parsing %q\nreturned %s and %s\nexpected %s and %s",
+				i, c.Input, formatValue(value), formatErr(err),
+				formatValue(c.Value), formatExpectedErr(fail, c.Err))
+		}
+	}
+}
+
+// formatValue formats v with its type.
+func formatValue(v interface{}) string {
+	if v == nil {
+		return "nil"
+	}
+	return fmt.Sprintf("%T(%#v)", v, v)
+}
+
+// formatErr formats an error returned by a Parser.
+func formatErr(err error) string {
+	if err == nil {
+		return "no error"
+	}
+	return fmt.Sprintf("error %q", err)
+}
+
+// formatExpectedErr formats the error expected by a Case.
+func formatExpectedErr(fail bool, msg string) string {
+	switch {
+	case msg != "":
+		return fmt.Sprintf("error %q", msg)
+	case fail:
+		return "an error"
+	}
+	return "no error"
+}
+
+// fuzzSeeds are inputs shaped like the tokens that parse passes to parsers:
+// empty and blank strings, numbers at the edges of their ranges, quotation
+// marks and backslashes left over from tokenizing, and unusual Unicode.
+var fuzzSeeds = []string{
+	"", " ", "\t", "\n", "0", "-0", "1", "-1", "+1", "007", "0x1f", "1_000",
+	"1e999", "-1e999", "NaN", "Inf", "-Inf", "9223372036854775808",
+	"-9223372036854775809", "0.1", ".5", "5.", "1,000", "1.000,5", "a", "abc",
+	"a b", " a ", "'a'", `"a"`, `a\ b`, `\`, "''", `""`, "\x00", "\xff",
+	"\ufeff1", "é", "e\u0301", "１２", "\u00a01", "🎉", "--", "-", "true", "y",
+}
+
+// FuzzParser fuzzes p, starting from inputs shaped like the tokens that parse
+// passes to parsers, and fails if p panics or if it does not return exactly
+// one of a value and an error. It is meant to be called from a fuzz test:
+//
+//	func FuzzPort(f *testing.F) {
+//		parsetest.FuzzParser(f, parse.Port)
+//	}
+//
+// Running it with go test checks the seed inputs, and running it with
+// go test -fuzz explores others.
+func FuzzParser(f *testing.F, p parse.Parser) {
+	for _, s := range fuzzSeeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		value, panicked, err := call(p, s)
+		switch {
+		case panicked != nil:
+			t.Fatalf("parsing %q panicked: %v", s, panicked)
+		case value != nil && err != nil:
+			t.Fatalf("parsing %q returned both %s and error %q", s,
+				formatValue(value), err)
+		case value == nil && err == nil:
+			t.Fatalf("parsing %q returned neither a value nor an error", s)
+		}
+	})
+}
+
+// call calls p with s, recovering from a panic.
+func call(p parse.Parser, s string) (value, panicked interface{},
+	err error) {
+	defer func() {
+		panicked = recover()
+	}()
+	value, err = p.Parse(s)
+	return value, nil, err
+}
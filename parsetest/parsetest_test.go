@@ -0,0 +1,57 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parsetest
+
+import (
+	"fmt"
+	"github.com/mk12/parse"
+	"reflect"
+	"testing"
+)
+
+// recorder is a testing.TB that records the messages passed to Errorf.
+type recorder struct {
+	testing.TB
+	msgs []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.msgs = append(r.msgs, fmt.Sprintf(format, args...))
+}
+
+func TestTestParser(t *testing.T) {
+	TestParser(t, parse.Int, []Case{
+		{Input: "1", Value: 1},
+		{Input: "x", Fail: true},
+		{Input: "x", Err: `"x" is not a whole number`},
+	})
+	r := &recorder{}
+	TestParser(r, parse.Int, []Case{
+		{Input: "1", Value: 2},
+		{Input: "1", Fail: true},
+		{Input: "x", Err: "wrong"},
+		{Input: "2", Value: 2},
+	})
+	expected := []string{
+		"0. parsing \"1\"\nreturned int(1) and no error\n" +
+			"expected int(2) and no error",
+		"1. parsing \"1\"\nreturned int(1) and no error\n" +
+			"expected nil and an error",
+		"2. parsing \"x\"\nreturned nil and error " +
+			"\"\\\"x\\\" is not a whole number\"\n" +
+			"expected nil and error \"wrong\"",
+	}
+	if !reflect.DeepEqual(r.msgs, expected) {
+		t.Errorf("TestParser reported %q\nexpected %q", r.msgs, expected)
+	}
+}
+
+func FuzzInt(f *testing.F) {
+	FuzzParser(f, parse.Int)
+}
+
+func FuzzChoice(f *testing.F) {
+	FuzzParser(f, parse.Choice("a", "b"))
+}
@@ -0,0 +1,105 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"io"
+	"log"
+	"strconv"
+	"sync"
+)
+
+// pipelineDepth is the number of records that the reading goroutine used by
+// mapPipelined can get ahead of fn. It bounds the memory used for buffering no
+// matter how slow fn is.
+const pipelineDepth = 64
+
+// A pipelineItem is a record passing through the pipeline, along with its
+// line number.
+type pipelineItem struct {
+	rec     record
+	n       int
+	readErr error
+}
+
+// mapPipelined does the work of mapReader in two stages connected by a bounded
+// channel: another goroutine reads records, and the calling goroutine parses
+// them, prints errors, and calls fn in the original order. When fn is slow, the
+// reading goroutine waits for it rather than buffering the whole input. Only
+// reading happens ahead of fn, so parsers never run concurrently with it or on
+// records after it stops. When mapPipelined returns early, it calls interrupt
+// to end a read that is waiting for input that might never come, such as the
+// next line from an idle pipe (see interruptible), and then waits for the
+// reading goroutine to finish.
+func (r *run) mapPipelined(fn func([]interface{}), records recordReader,
+	interrupt func(), l *log.Logger, first int) bool {
+	done := make(chan struct{})
+	read := make(chan pipelineItem, pipelineDepth)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(read)
+		for n := 1; ; n++ {
+			rec, err := records.next()
+			if err == io.EOF {
+				return
+			}
+			item := pipelineItem{rec: detach(rec), n: n, readErr: err}
+			if rec.n > 0 {
+				item.n = rec.n
+			}
+			select {
+			case read <- item:
+			case <-done:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	defer func() {
+		close(done)
+		interrupt()
+		for range read {
+		}
+		wg.Wait()
+	}()
+	success := true
+	prefix := l.Prefix()
+	for item := range read {
+		line := lineNumber(first, item.n)
+		if first > 0 {
+			l.SetPrefix(prefix + strconv.Itoa(line) + ": ")
+		}
+		if item.readErr != nil {
+			r.noteError(l.Prefix(), item.readErr)
+			l.Println(item.readErr)
+			return false
+		}
+		parsed, err := r.parseRecord(item.rec)
+		if !r.applyRecord(fn, item.rec, parsed, err, line, l) {
+			success = false
+			if !r.keepGoing {
+				break
+			}
+		}
+		if r.stopping() {
+			break
+		}
+	}
+	return success
+}
+
+// detach copies the parts of rec that refer to the reader's buffer, which is
+// reused when the next record is read.
+func detach(rec record) record {
+	if rec.rest != nil {
+		rec.rest = append([]byte(nil), rec.rest...)
+	}
+	if rec.raw != nil {
+		rec.raw = append([]byte(nil), rec.raw...)
+	}
+	return rec
+}
@@ -0,0 +1,115 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"io"
+	"log"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingRecords is a recordReader that produces n records of one token each
+// and counts how many have been read.
+type countingRecords struct {
+	n, read int64
+}
+
+func (r *countingRecords) next() (record, error) {
+	i := atomic.AddInt64(&r.read, 1)
+	if i > r.n {
+		return record{}, io.EOF
+	}
+	return record{tokens: []string{"x"}}, nil
+}
+
+func TestPipelineBackPressure(t *testing.T) {
+	records := &countingRecords{n: 10000}
+	calls := 0
+	fn := func(args []interface{}) {
+		calls++
+		if calls == 1 {
+			// Wait for the reading stage to fill its buffer.
+			for i := 0; i < 100; i++ {
+				runtime.Gosched()
+			}
+			if read := atomic.LoadInt64(&records.read); read > 2*pipelineDepth {
+				t.Errorf("read %d records ahead of fn", read)
+			}
+		}
+	}
+	l := log.New(io.Discard, "", 0)
+	if !std.newRun().mapPipelined(fn, records, func() {}, l, 0) {
+		t.Error("mapPipelined returned false")
+	}
+	if calls != 10000 {
+		t.Errorf("fn was called %d times, expected 10000", calls)
+	}
+}
+
+func TestPipelineOrder(t *testing.T) {
+	defer func() {
+		SetEveryParser(Parser{})
+		SetLineLimit(0, DropExtra)
+	}()
+	SetEveryParser(Int)
+	SetLineLimit(1, RawExtra)
+	var input strings.Builder
+	var expected []interface{}
+	for i := 0; i < 1000; i++ {
+		n := strings.Repeat("7", i%5+1)
+		rest := "rest " + strings.Repeat("z", i)
+		input.WriteString(n + " " + rest + "\n")
+		v, _ := strconv.Atoi(n)
+		expected = append(expected, []interface{}{v, rest})
+	}
+	var got []interface{}
+	fn := func(args []interface{}) { got = append(got, args) }
+	r := strings.NewReader(input.String())
+	if !std.newRun().mapReader(fn, r, log.New(io.Discard, "", 0), 0) {
+		t.Error("mapReader returned false")
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Error("mapReader passed the lines out of order or corrupted them")
+	}
+}
+
+func TestPipelineStop(t *testing.T) {
+	defer SetEveryParser(Parser{})
+	calls := 0
+	SetEveryParser(NewParser(func(s string) (interface{}, error) {
+		calls++
+		return s, nil
+	}))
+	fn := func(args []interface{}) { Stop() }
+	r := strings.NewReader(strings.Repeat("x\n", 1000))
+	std.newRun().mapReader(fn, r, log.New(io.Discard, "", 0), 0)
+	if calls != 1 {
+		t.Errorf("the parser was called %d times, expected 1", calls)
+	}
+}
+
+func TestPipelineStopIdle(t *testing.T) {
+	in, w := io.Pipe()
+	defer w.Close()
+	go io.WriteString(w, "x\n")
+	done := make(chan int)
+	go func() {
+		code, _ := Run(func([]interface{}) { Stop() }, WithArgs([]string{}),
+			WithInput(in))
+		done <- code
+	}()
+	select {
+	case code := <-done:
+		if code != 0 {
+			t.Errorf("Run returned %d, expected 0", code)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not return after Stop while the input was idle")
+	}
+}
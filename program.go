@@ -0,0 +1,312 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// A Program holds the configuration of a program that uses parse: its name,
+// usage message, parsers, input and output, and every other setting. Programs
+// make it possible to build several tools into one binary, choosing one at run
+// time, and to configure a program in a test without affecting other tests.
+//
+// The package-level functions such as SetParsers and Main configure and run
+// the default program, which is the one used by code written before Programs
+// existed. Each Program is independent of the others, so different programs
+// can run at the same time, and a program can run another from the function
+// passed to its Main. The settings of a Program must not be changed while it
+// is running; the ones that the user changes with built-in flags, such as
+// "--keep-going", only change for that run.
+type Program struct {
+	name      string
+	usage     string // the arguments given to SetUsage
+	hasUsage  bool   // whether SetUsage was called
+	parsers   []Parser
+	repeat    bool // see SetVariadic and SetRepeated
+	restIndex int  // index in parsers of the repeated argument
+	names     []string
+	arity     Arity
+	derived   []derivedArg
+
+	lineLimit        int
+	extraPolicy      ExtraPolicy
+	rejectEmpty      bool
+	normalization    Normalization
+	trimPolicy       TrimPolicy
+	utf8Policy       UTF8Policy
+	maxTokens        int
+	repeatStyle      RepeatStyle
+	placeholderStyle PlaceholderStyle
+
+	inputFormat         InputFormat
+	headerMode          HeaderMode
+	columns             []int
+	widths              []int
+	lengthPrefix        LengthPrefix
+	decoder             func([]byte) ([]string, error)
+	gobType             reflect.Type
+	splitter            Splitter
+	tokenizer           Tokenizer
+	filesMode           bool
+	inputURL            string
+	opener              Opener
+	jobs                int
+	splitFiles          bool
+	mmapMode            bool
+	shuffleSeed         int64
+	checksums           bool
+	stdinGuard          bool
+	stdinOverride       io.Reader
+	isTerminal          func() bool
+	commandLineOverride []string
+	prompt              string
+	contPrompt          string
+	lineEditing         bool
+	historyFile         string
+	historySet          bool
+
+	output           io.Writer
+	diagnostics      io.Writer
+	rejects          io.Writer
+	logger           *log.Logger // prints errors, or nil to make one
+	tee              io.Writer
+	outputPrefix     string
+	deterministic    bool
+	keepGoing        bool
+	verbosity        Level
+	verbosityFlagsOn bool
+	builtinFlagsOn   bool
+	exitUsage        int
+	exitParse        int
+	exitRuntime      int
+	reduction        Reduction
+	histogramBuckets int
+	reportPath       string
+	lockPath         string
+	recorder         *recorder
+	sums             *checksumLog
+	beforeHooks      []func(args []string)
+	afterHooks       []func(parsed []interface{}, err error)
+	parseErrorHooks  []func(arg string, err error)
+
+	// Settings that only the built-in flags change.
+	jobsFlag     int
+	reference    []string
+	schemaMode   bool
+	benchMode    bool
+	typesMode    bool
+	minimizeMode bool
+
+	self *Program // the program that this is a copy of, or itself
+}
+
+// New returns a new Program with the same defaults as the default program: it
+// is named after the executable, it accepts any number of string arguments,
+// and it writes to standard output and standard error.
+func New() *Program {
+	p := &Program{
+		name:        filepath.Base(os.Args[0]),
+		parsers:     []Parser{String},
+		repeat:      true,
+		contPrompt:  "> ",
+		jobs:        1,
+		output:      os.Stdout,
+		diagnostics: os.Stderr,
+		keepGoing:   true,
+		exitUsage:   1,
+		exitParse:   1,
+		exitRuntime: 1,
+		recorder:    &recorder{},
+		sums:        &checksumLog{},
+	}
+	p.self = p
+	return p
+}
+
+// std is the default program, which the package-level functions configure and
+// run. It prints errors using the standard logger (see SetStreams).
+var std = func() *Program {
+	p := New()
+	p.logger = log.Default()
+	return p
+}()
+
+// SetName sets the name of the program, which is used in its usage message and
+// error messages. It is the name of the executable by default.
+func (p *Program) SetName(name string) {
+	p.name = name
+}
+
+// A run is a single run of a program by Main, by one of the functions like it,
+// or by Collect and the other functions that read the program's input. It has
+// its own copy of the program's settings, which the built-in flags change for
+// that run only, along with the state that the run builds up.
+type run struct {
+	Program
+	log         *log.Logger
+	ctx         context.Context // passed to MainContext, or nil
+	source      SourceKind
+	invoking    bool // run by Invoke
+	returning   bool // exit ends the run instead of the program
+	stopped     atomic.Bool
+	parseFailed atomic.Bool
+	signalled   atomic.Int32 // the exit status for a signal that ended it
+	counts      struct{ lines, failed, skipped atomic.Int64 }
+	reduced     *reducer
+	histo       *histogram
+
+	errs struct {
+		sync.Mutex
+		on   bool // whether errors are collected for Run
+		list []error
+	}
+	report struct {
+		sync.Mutex
+		start         time.Time
+		inputs        []string
+		errors        []string
+		errorsOmitted int
+		written       bool
+	}
+}
+
+// newRun returns a new run of p.
+func (p *Program) newRun() *run {
+	r := &run{Program: *p}
+	r.log = p.logger
+	if r.log == nil {
+		r.log = log.New(p.diagnostics, p.name+": ", 0)
+	}
+	return r
+}
+
+// runs holds the runs that are in progress, so that Output and the other
+// functions that fn calls can find the run that called fn.
+var runs struct {
+	sync.Mutex
+	live  []*run         // in the order they started
+	calls map[int64]*run // the run calling fn on each goroutine
+}
+
+// enter adds r to the runs in progress and returns a function that removes it.
+func (r *run) enter() func() {
+	runs.Lock()
+	runs.live = append(runs.live, r)
+	runs.Unlock()
+	return func() {
+		runs.Lock()
+		defer runs.Unlock()
+		for i, other := range runs.live {
+			if other == r {
+				runs.live = append(runs.live[:i], runs.live[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// calling records that r is calling fn on the current goroutine, and returns a
+// function that restores the run that was calling fn on it before, so that a
+// program can run another, or itself, from fn.
+func (r *run) calling() func() {
+	g := goroutineID()
+	runs.Lock()
+	defer runs.Unlock()
+	prev := runs.calls[g]
+	if runs.calls == nil {
+		runs.calls = make(map[int64]*run)
+	}
+	runs.calls[g] = r
+	return func() {
+		runs.Lock()
+		defer runs.Unlock()
+		if prev != nil {
+			runs.calls[g] = prev
+		} else {
+			delete(runs.calls, g)
+		}
+	}
+}
+
+// current returns the run of p that fn is called from: the one calling fn on
+// the current goroutine, or else the last one to start. It returns nil if p is
+// not running.
+func (p *Program) current() *run {
+	g := goroutineID()
+	runs.Lock()
+	defer runs.Unlock()
+	if r := runs.calls[g]; r != nil && r.self == p.self {
+		return r
+	}
+	for i := len(runs.live) - 1; i >= 0; i-- {
+		if runs.live[i].self == p.self {
+			return runs.live[i]
+		}
+	}
+	return nil
+}
+
+// activeRun returns the run that the package-level functions called from fn
+// refer to: the one calling fn on the current goroutine, whichever program it
+// belongs to, or else the current run of the default program. It returns nil
+// if there is none.
+func activeRun() *run {
+	runs.Lock()
+	r := runs.calls[goroutineID()]
+	runs.Unlock()
+	if r != nil {
+		return r
+	}
+	return std.current()
+}
+
+// active returns the settings of the run that activeRun returns, or of the
+// default program if there is none.
+func active() *Program {
+	if r := activeRun(); r != nil {
+		return &r.Program
+	}
+	return std
+}
+
+// liveRuns returns the runs that are in progress.
+func liveRuns() []*run {
+	runs.Lock()
+	defer runs.Unlock()
+	return append([]*run(nil), runs.live...)
+}
+
+// othersRunning returns true if a run other than r is in progress.
+func (r *run) othersRunning() bool {
+	for _, other := range liveRuns() {
+		if other != r {
+			return true
+		}
+	}
+	return false
+}
+
+// goroutineID returns the ID of the current goroutine, which the runtime
+// prints at the start of its stack trace, as in "goroutine 7 [running]:".
+func goroutineID() int64 {
+	var buf [64]byte
+	s := buf[:runtime.Stack(buf[:], false)]
+	s = bytes.TrimPrefix(s, []byte("goroutine "))
+	if i := bytes.IndexByte(s, ' '); i >= 0 {
+		s = s[:i]
+	}
+	id, _ := strconv.ParseInt(string(s), 10, 64)
+	return id
+}
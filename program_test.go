@@ -0,0 +1,108 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestProgramParse(t *testing.T) {
+	ints := New()
+	ints.SetEveryParser(Int)
+	pair := New()
+	pair.SetParsers(Float64, Parser{})
+	if x, err := ints.Parse([]string{"1", "2"}); err != nil ||
+		!reflect.DeepEqual(x, []interface{}{1, 2}) {
+		t.Errorf("ints.Parse returned %v, %v", x, err)
+	}
+	if x, err := pair.Parse([]string{"1", "2"}); err != nil ||
+		!reflect.DeepEqual(x, []interface{}{1.0, "2"}) {
+		t.Errorf("pair.Parse returned %v, %v", x, err)
+	}
+	if x, err := Parse([]string{"a"}); err != nil ||
+		!reflect.DeepEqual(x, []interface{}{"a"}) {
+		t.Errorf("default program's Parse returned %v, %v", x, err)
+	}
+}
+
+func TestProgramMain(t *testing.T) {
+	defer func(args []string) { os.Args = args }(os.Args)
+	before := std.name
+	var out, diag bytes.Buffer
+	p := New()
+	p.SetName("adder")
+	p.SetParsers(Int, Int)
+	p.SetNames("a", "b")
+	p.SetStreams(Streams{Results: &out, Diagnostics: &diag})
+	fn := func(args []interface{}) {
+		fmt.Fprintln(Output(), args[0].(int)+args[1].(int))
+	}
+	os.Args = []string{"x", "1", "2"}
+	p.Main(fn)
+	os.Args = []string{"x", "1"}
+	code := catchExit(func() { p.Main(fn) })
+	if code != 1 {
+		t.Errorf("exit status %d, expected 1", code)
+	}
+	if out.String() != "3\n" || diag.String() != "usage: adder a b\n" {
+		t.Errorf("output %q and diagnostics %q", out.String(), diag.String())
+	}
+	if std.name != before || Output() != os.Stdout {
+		t.Errorf("default program changed to %q writing to %v", std.name,
+			Output())
+	}
+}
+
+func TestProgramsConcurrently(t *testing.T) {
+	run := func(p *Program, input string, fn func([]interface{})) string {
+		var out bytes.Buffer
+		code, err := p.Run(fn, WithArgs([]string{}), WithOutput(&out),
+			WithInput(strings.NewReader(input)))
+		if code != 0 || err != nil {
+			t.Errorf("Run returned %d, %v", code, err)
+		}
+		return out.String()
+	}
+	double := New()
+	double.SetEveryParser(Int)
+	upper := New()
+	upper.SetInputFormat(CSV)
+	// Make sure that the programs are both calling fn at some point.
+	var overlap sync.WaitGroup
+	overlap.Add(2)
+	var doubleOnce, upperOnce sync.Once
+	results := make(chan string, 2)
+	go func() {
+		results <- run(double, "1\n2\n", func(args []interface{}) {
+			doubleOnce.Do(func() { overlap.Done(); overlap.Wait() })
+			// Methods of another program can be called from fn.
+			if _, err := upper.Parse([]string{"x"}); err != nil {
+				t.Error(err)
+			}
+			fmt.Fprintln(double.Output(), 2*args[0].(int))
+		})
+	}()
+	go func() {
+		results <- run(upper, "a,b\n", func(args []interface{}) {
+			upperOnce.Do(func() { overlap.Done(); overlap.Wait() })
+			// So can the package-level functions, which refer to the run
+			// that called fn.
+			fmt.Fprintln(Output(), strings.ToUpper(args[1].(string)))
+		})
+	}()
+	got := []string{<-results, <-results}
+	if !(got[0] == "2\n4\n" && got[1] == "B\n" ||
+		got[0] == "B\n" && got[1] == "2\n4\n") {
+		t.Errorf("the programs printed %q", got)
+	}
+	if Output() != os.Stdout || Source() != ArgvSource {
+		t.Errorf("after the runs, Output() = %v and Source() = %v",
+			Output(), Source())
+	}
+}
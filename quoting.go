@@ -0,0 +1,39 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+// A quoteState keeps track of backslashes and quotation marks while scanning
+// input one byte at a time. It is the single definition of quoting shared by
+// scanLines, tokenize, and promptReader, so that they always agree on where a
+// quotation begins and ends. A backslash escapes the next byte, even inside
+// quotation marks, and a quotation is closed by the same unescaped mark that
+// opened it.
+type quoteState struct {
+	escaped bool // the next byte is escaped by a backslash
+	quote   byte // the quotation mark of the open quotation, or 0
+}
+
+// bare returns true if the next byte is neither escaped nor quoted, so that it
+// can have a special meaning, such as separating tokens or ending a line.
+func (s quoteState) bare() bool {
+	return !s.escaped && s.quote == 0
+}
+
+// step advances the state past c. It returns true if c is syntax rather than
+// content: an unescaped backslash, or a quotation mark that opens or closes a
+// quotation. Tokenizing removes these bytes.
+func (s *quoteState) step(c byte) bool {
+	syntax := false
+	if !s.escaped {
+		if s.quote == 0 && (c == '\'' || c == '"') {
+			s.quote = c
+			syntax = true
+		} else if s.quote != 0 && c == s.quote {
+			s.quote = 0
+			syntax = true
+		}
+	}
+	// An unescaped backslash escapes the next character.
+	s.escaped = !s.escaped && c == '\\'
+	return syntax || s.escaped
+}
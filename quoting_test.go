@@ -0,0 +1,132 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// referenceRecords splits input into records of tokens in a single pass, as a
+// reference for the combination of newLineScanner and tokenize. It follows the
+// documented rules directly rather than sharing any code with them.
+func referenceRecords(input string) [][]string {
+	var records [][]string
+	tokens := []string{}
+	var token []byte
+	inToken := false
+	escaped := false
+	quote := byte(0)
+	pending := false // whether there is input since the last record
+	endToken := func() {
+		if inToken {
+			tokens = append(tokens, string(token))
+			token = nil
+			inToken = false
+		}
+	}
+	for i := 0; i < len(input); i++ {
+		c := input[i]
+		if !escaped && c == '\\' && i+1 < len(input) && input[i+1] == '\n' {
+			// An escaped newline is removed entirely.
+			i++
+			continue
+		}
+		pending = true
+		switch {
+		case escaped:
+			escaped = false
+			token = append(token, c)
+		case c == '\\':
+			escaped = true
+			inToken = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				token = append(token, c)
+			}
+		case c == '\n':
+			endToken()
+			records = append(records, tokens)
+			tokens = []string{}
+			pending = false
+		case c == ' ':
+			endToken()
+		case c == '\'' || c == '"':
+			quote = c
+			inToken = true
+		default:
+			token = append(token, c)
+			inToken = true
+		}
+	}
+	if pending {
+		endToken()
+		records = append(records, tokens)
+	}
+	return records
+}
+
+// scannedRecords splits input into records using newLineScanner and tokenize.
+func scannedRecords(input string) [][]string {
+	var records [][]string
+	scanner := std.newLineScanner(strings.NewReader(input))
+	for scanner.Scan() {
+		records = append(records, tokenize(scanner.Bytes()).strings())
+	}
+	return records
+}
+
+func TestQuotingDifferential(t *testing.T) {
+	const alphabet = "a '\"\\\n"
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 20000; i++ {
+		b := make([]byte, rng.Intn(12))
+		for j := range b {
+			b[j] = alphabet[rng.Intn(len(alphabet))]
+		}
+		input := string(b)
+		got, expected := scannedRecords(input), referenceRecords(input)
+		if !reflect.DeepEqual(got, expected) {
+			t.Fatalf("scanning and tokenizing %q\nreturned %q\nexpected %q",
+				input, got, expected)
+		}
+		mapped := readRecords(t, &mappedRecords{p: std, data: []byte(input)})
+		if !reflect.DeepEqual(mapped, expected) {
+			t.Fatalf("mapping and tokenizing %q\nreturned %q\nexpected %q",
+				input, mapped, expected)
+		}
+	}
+}
+
+// TestQuotingRecordsClosed checks that every record produced by the scanner,
+// except possibly the last, ends outside of any quotation, so that tokenize
+// never sees a quotation that the scanner considered closed as open.
+func TestQuotingRecordsClosed(t *testing.T) {
+	inputs := []string{
+		"'a\nb' c\nd",
+		"\"a\\\"\nb\"\n'c\\'\nd'\n",
+		"a\\\nb 'c\"\nd' \"e'\nf\"\n",
+		"'unterminated\nstill\n",
+	}
+	for _, input := range inputs {
+		scanner := std.newLineScanner(strings.NewReader(input))
+		var records []string
+		for scanner.Scan() {
+			records = append(records, scanner.Text())
+		}
+		for i, rec := range records[:len(records)-1] {
+			var state quoteState
+			for j := 0; j < len(rec); j++ {
+				state.step(rec[j])
+			}
+			if !state.bare() {
+				t.Errorf("record %d of %q, %q, ends inside a quotation", i,
+					input, rec)
+			}
+		}
+	}
+}
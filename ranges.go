@@ -0,0 +1,94 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// A TimeInterval is a range of times, as returned by the TimeRange parser.
+type TimeInterval struct {
+	Start, End time.Time
+}
+
+// A DurationInterval is a range of durations, as returned by the DurationRange
+// parser.
+type DurationInterval struct {
+	Start, End time.Duration
+}
+
+// timeLayouts are the layouts accepted for each end of a TimeRange, from most
+// to least precise. Times without a time zone are in UTC.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// parseTime parses s using the first layout in timeLayouts that matches.
+func parseTime(s string) (time.Time, bool) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// splitRange splits s into the two ends of a range separated by "..".
+func splitRange(s, what string) (start, end string, err error) {
+	start, end, ok := strings.Cut(s, "..")
+	if !ok || start == "" || end == "" {
+		return "", "", fmt.Errorf("%q is not a %s range (expected start..end)",
+			s, what)
+	}
+	return start, end, nil
+}
+
+// TimeRange is a Parser that parses two times separated by "..", such as
+// "2024-01-01..2024-02-01", as a TimeInterval. Each time is either a date, a
+// date and time such as "2024-01-01T09:30", or an RFC 3339 timestamp with a
+// time zone. Times without a time zone are in UTC. The start cannot be after
+// the end.
+var TimeRange = NewParser(func(s string) (interface{}, error) {
+	start, end, err := splitRange(s, "time")
+	if err != nil {
+		return nil, err
+	}
+	var r TimeInterval
+	var ok bool
+	if r.Start, ok = parseTime(start); !ok {
+		return nil, fmt.Errorf("%q is not a date or time", start)
+	}
+	if r.End, ok = parseTime(end); !ok {
+		return nil, fmt.Errorf("%q is not a date or time", end)
+	}
+	if r.Start.After(r.End) {
+		return nil, fmt.Errorf("%q starts after it ends", s)
+	}
+	return r, nil
+}).withInfo(parserInfo{name: "start..end", typ: "time range"})
+
+// DurationRange is a Parser that parses two durations separated by "..", such
+// as "5m..1h", as a DurationInterval. Each duration has the syntax accepted by
+// time.ParseDuration. The start cannot be greater than the end.
+var DurationRange = NewParser(func(s string) (interface{}, error) {
+	start, end, err := splitRange(s, "duration")
+	if err != nil {
+		return nil, err
+	}
+	var r DurationInterval
+	if r.Start, err = time.ParseDuration(start); err != nil {
+		return nil, fmt.Errorf("%q is not a duration", start)
+	}
+	if r.End, err = time.ParseDuration(end); err != nil {
+		return nil, fmt.Errorf("%q is not a duration", end)
+	}
+	if r.Start > r.End {
+		return nil, fmt.Errorf("%q starts after it ends", s)
+	}
+	return r, nil
+}).withInfo(parserInfo{name: "min..max", typ: "duration range"})
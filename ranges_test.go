@@ -0,0 +1,67 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"testing"
+	"time"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+var rangeTests = []struct {
+	parser Parser
+	input  string
+	value  interface{}
+	msg    string
+}{
+	{TimeRange, "2024-01-01..2024-02-01",
+		TimeInterval{date(2024, 1, 1), date(2024, 2, 1)}, ""},
+	{TimeRange, "2024-01-01..2024-01-01",
+		TimeInterval{date(2024, 1, 1), date(2024, 1, 1)}, ""},
+	{TimeRange, "2024-01-01T09:30..2024-01-01T17:00:00",
+		TimeInterval{time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC),
+			time.Date(2024, 1, 1, 17, 0, 0, 0, time.UTC)}, ""},
+	{TimeRange, "2024-02-01..2024-01-01", nil,
+		`"2024-02-01..2024-01-01" starts after it ends`},
+	{TimeRange, "2024-01-01", nil,
+		`"2024-01-01" is not a time range (expected start..end)`},
+	{TimeRange, "2024-01-01..", nil,
+		`"2024-01-01.." is not a time range (expected start..end)`},
+	{TimeRange, "2024-13-01..2024-12-01", nil,
+		`"2024-13-01" is not a date or time`},
+	{DurationRange, "5m..1h", DurationInterval{5 * time.Minute, time.Hour}, ""},
+	{DurationRange, "0s..0s", DurationInterval{}, ""},
+	{DurationRange, "1h..5m", nil, `"1h..5m" starts after it ends`},
+	{DurationRange, "5..1h", nil, `"5" is not a duration`},
+	{DurationRange, "5m", nil,
+		`"5m" is not a duration range (expected start..end)`},
+}
+
+func TestRanges(t *testing.T) {
+	for i, test := range rangeTests {
+		value, err := test.parser.Parse(test.input)
+		msg := ""
+		if err != nil {
+			msg = err.Error()
+		}
+		if value != test.value || msg != test.msg {
+			t.Errorf("%d. %q\nreturned %v and %q\nexpected %v and %q",
+				i, test.input, value, msg, test.value, test.msg)
+		}
+	}
+}
+
+func TestTimeRangeZones(t *testing.T) {
+	value, err := TimeRange.Parse(
+		"2024-01-01T10:00:00+02:00..2024-01-01T09:00:00Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := value.(TimeInterval)
+	if !r.Start.Equal(time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("start is %v", r.Start)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"fmt"
+	"sync"
+)
+
+// A Reduction combines all the numbers that a program receives into one, which
+// is printed once the input has been processed. It makes trivial statistics
+// tools possible without any code of their own.
+type Reduction int
+
+const (
+	// NoReduction passes the numbers to fn and nothing else. It is the
+	// default.
+	NoReduction Reduction = iota
+	// Sum prints the sum of the numbers, which is 0 if there are none.
+	Sum
+	// Mean prints the arithmetic mean of the numbers.
+	Mean
+	// Min prints the smallest number.
+	Min
+	// Max prints the largest number.
+	Max
+)
+
+// SetReduction makes Main combine the numbers that it parses, on the command
+// line or across all the lines of input, using r, and print the result to the
+// output stream (see SetStreams) on its own line at the end. It requires a
+// single numeric parser, such as SetEveryParser(Float64) or SetParsers(Int).
+// Main still calls fn for each invocation, but fn can be nil if the program has
+// nothing else to do. The numbers of lines that fail or that fn skips (see
+// Skip) are left out, and nothing is printed if there are no numbers, except
+// for Sum. Results are ints for Sum, Min, and Max when the parser returns ints.
+//
+// The user can choose a reduction with the built-in flags "--sum", "--mean",
+// "--min", and "--max", which are only recognized when the program has a
+// single numeric parser.
+//
+// Deprecated: Use Program.SetReduction instead.
+func SetReduction(r Reduction) {
+	std.SetReduction(r)
+}
+
+// SetReduction is like the package-level SetReduction, but for p.
+func (p *Program) SetReduction(r Reduction) {
+	p.reduction = r
+}
+
+// reductionFlags maps the built-in flags that choose a Reduction to it.
+var reductionFlags = map[string]Reduction{
+	"--sum":  Sum,
+	"--mean": Mean,
+	"--min":  Min,
+	"--max":  Max,
+}
+
+// numeric returns true if the program has a single parser for ints or
+// float64s, which is what reductions work with.
+func (p *Program) numeric() bool {
+	if len(p.parsers) != 1 {
+		return false
+	}
+	typ := p.parsers[0].info().typ
+	return typ == "int" || typ == "float64"
+}
+
+// A reducer accumulates the numbers for a Reduction.
+type reducer struct {
+	mutex     sync.Mutex
+	reduction Reduction
+	count     int
+	ints      bool    // whether all the numbers are ints
+	sum       float64 // sum of the numbers
+	isum      int     // sum of the numbers, if they are all ints
+	best      interface{}
+	bestF     float64 // best converted to a float64
+}
+
+// reduceFn returns a function that calls fn, unless it is nil, and then adds
+// the numbers in its arguments to the reducer of r.
+func (r *run) reduceFn(fn func([]interface{})) func([]interface{}) {
+	return func(args []interface{}) {
+		if fn != nil {
+			fn(args)
+		}
+		r.reduced.add(args[:len(args)-len(r.derived)])
+	}
+}
+
+// add adds the numbers in values to r, ignoring other values.
+func (r *reducer) add(values []interface{}) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, v := range values {
+		var f float64
+		switch n := v.(type) {
+		case int:
+			f = float64(n)
+			r.isum += n
+		case float64:
+			f = n
+			r.ints = false
+		default:
+			continue
+		}
+		if r.count == 0 || r.reduction == Min && f < r.bestF ||
+			r.reduction == Max && f > r.bestF {
+			r.best, r.bestF = v, f
+		}
+		r.count++
+		r.sum += f
+	}
+}
+
+// result returns the result of the reduction, or false if there is none.
+func (r *reducer) result() (interface{}, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	switch {
+	case r.reduction == Sum && r.ints:
+		return r.isum, true
+	case r.reduction == Sum:
+		return r.sum, true
+	case r.count == 0:
+		return nil, false
+	case r.reduction == Mean:
+		return r.sum / float64(r.count), true
+	}
+	return r.best, true
+}
+
+// printReduction prints the result of the reduction, if there is one.
+func (r *run) printReduction() {
+	if r.reduced == nil {
+		return
+	}
+	if v, ok := r.reduced.result(); ok {
+		fmt.Fprintln(r.output, v)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+var reductionTests = []struct {
+	parser    Parser
+	reduction Reduction
+	args      []string
+	input     string
+	output    string
+}{
+	{Int, Sum, []string{}, "1 2\n3\n", "6\n"},
+	{Int, Sum, []string{}, "", "0\n"},
+	{Float64, Sum, []string{}, "1.5\n2\n", "3.5\n"},
+	{Int, Mean, []string{}, "1 2\n", "1.5\n"},
+	{Int, Mean, []string{}, "", ""},
+	{Int, Min, []string{}, "3 -1\n2\n", "-1\n"},
+	{Float64, Max, []string{}, "3 -1\n2.5\n", "3\n"},
+	{Int, Max, []string{"4", "9", "2"}, "", "9\n"},
+	{Int, NoReduction, []string{"--sum", "4", "5"}, "", "9\n"},
+	{Int, NoReduction, []string{"--max"}, "4 5\n", "5\n"},
+	{Int, Sum, []string{}, "1\nx\n2\n", "3\n"},
+}
+
+func TestReduction(t *testing.T) {
+	defer func() {
+		SetArgs(nil)
+		SetInput(nil)
+		SetOutput(os.Stdout)
+		SetErrorOutput(os.Stderr)
+		SetEveryParser(Parser{})
+		SetReduction(NoReduction)
+	}()
+	SetErrorOutput(nil)
+	for i, test := range reductionTests {
+		var out bytes.Buffer
+		SetOutput(&out)
+		SetEveryParser(test.parser)
+		SetReduction(test.reduction)
+		SetArgs(test.args)
+		SetInput(strings.NewReader(test.input))
+		catchExit(func() { Main(nil) })
+		if out.String() != test.output {
+			t.Errorf("%d. Main with %q and %q printed %q, expected %q", i,
+				test.args, test.input, out.String(), test.output)
+		}
+	}
+}
+
+func TestReductionSkip(t *testing.T) {
+	defer func() {
+		SetArgs(nil)
+		SetInput(nil)
+		SetOutput(os.Stdout)
+		SetEveryParser(Parser{})
+		SetReduction(NoReduction)
+	}()
+	var out bytes.Buffer
+	SetOutput(&out)
+	SetEveryParser(Int)
+	SetReduction(Sum)
+	SetArgs([]string{})
+	SetInput(strings.NewReader("1\n-5\n2\n"))
+	Main(func(args []interface{}) {
+		if args[0].(int) < 0 {
+			Skip()
+		}
+	})
+	if out.String() != "3\n" {
+		t.Errorf("Main printed %q, expected %q", out.String(), "3\n")
+	}
+}
+
+func TestReductionInvalid(t *testing.T) {
+	defer func() {
+		SetEveryParser(Parser{})
+		SetReduction(NoReduction)
+	}()
+	SetReduction(Sum)
+	if Validate() == nil {
+		t.Error("Validate accepted a reduction of strings")
+	}
+	if rest := std.stripFlags([]string{"--sum"}); len(rest) != 1 {
+		t.Errorf("stripFlags recognized --sum for strings: %q", rest)
+	}
+	SetEveryParser(Float64)
+	if err := Validate(); err != nil {
+		t.Errorf("Validate failed: %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// runReference reads all the input with readInputs and processes it with fn
+// like lines of standard input, but instead of writing the output of fn, it
+// compares it line by line with the output of the reference command given the
+// same input. This is useful when porting a shell pipeline to a program that
+// uses parse: the old pipeline becomes the reference, as in
+//
+//	prog --reference="sh -c 'cut -f2 | sort -n'" < data.tsv
+//
+// Each mismatch is printed with its line number in the output. It returns true
+// if all the input was processed successfully and the outputs were identical.
+func (r *run) runReference(fn func([]interface{}), args []string) (bool,
+	error) {
+	data, err := r.readInputs(args)
+	if err != nil {
+		return false, err
+	}
+	cmd := exec.Command(r.reference[0], r.reference[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stderr = r.diagnostics
+	expected, err := cmd.Output()
+	if err != nil {
+		return false, fmt.Errorf("%s: %v", r.reference[0], err)
+	}
+	var got bytes.Buffer
+	defer func(w io.Writer) { r.output = w }(r.output)
+	r.output = &got
+	success := r.mapReader(fn, bytes.NewReader(data), r.log, 0)
+	mismatches := compareLines(got.String(), string(expected))
+	for _, m := range mismatches {
+		r.log.Println(m)
+	}
+	return success && mismatches == nil, nil
+}
+
+// compareLines compares got and expected line by line and returns a message
+// for each line that differs.
+func compareLines(got, expected string) []string {
+	g, e := splitLines(got), splitLines(expected)
+	var mismatches []string
+	for i := 0; i < len(g) || i < len(e); i++ {
+		var msg string
+		switch {
+		case i >= len(g):
+			msg = fmt.Sprintf("missing %q", e[i])
+		case i >= len(e):
+			msg = fmt.Sprintf("extra %q", g[i])
+		case g[i] != e[i]:
+			msg = fmt.Sprintf("got %q, expected %q", g[i], e[i])
+		default:
+			continue
+		}
+		mismatches = append(mismatches, fmt.Sprintf("line %d: %s", i+1, msg))
+	}
+	return mismatches
+}
+
+// splitLines splits s after each newline.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
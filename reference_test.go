@@ -0,0 +1,72 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var compareLinesTests = []struct {
+	got, expected string
+	mismatches    []string
+}{
+	{"", "", nil},
+	{"a\nb\n", "a\nb\n", nil},
+	{"a\nx\n", "a\nb\n", []string{`line 2: got "x\n", expected "b\n"`}},
+	{"a\n", "a\nb\n", []string{`line 2: missing "b\n"`}},
+	{"a\nb", "a\n", []string{`line 2: extra "b"`}},
+}
+
+func TestCompareLines(t *testing.T) {
+	for i, test := range compareLinesTests {
+		m := compareLines(test.got, test.expected)
+		if !reflect.DeepEqual(m, test.mismatches) {
+			t.Errorf("%d. compareLines(%q, %q) = %q, expected %q", i,
+				test.got, test.expected, m, test.mismatches)
+		}
+	}
+}
+
+func TestReference(t *testing.T) {
+	if _, err := exec.LookPath("cat"); err != nil {
+		t.Skip("cat not found")
+	}
+	defer func(w io.Writer, prefix string) {
+		std.reference = nil
+		log.SetOutput(w)
+		log.SetPrefix(prefix)
+	}(log.Writer(), log.Prefix())
+	var diag bytes.Buffer
+	log.SetOutput(&diag)
+	log.SetPrefix("")
+	path := filepath.Join(t.TempDir(), "input")
+	if err := os.WriteFile(path, []byte("a\nb\nc\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	std.reference = []string{"cat"}
+	fn := func(args []interface{}) {
+		if args[0] == "b" {
+			args[0] = "B"
+		}
+		fmt.Fprintln(Output(), args...)
+	}
+	r := std.newRun()
+	defer r.enter()()
+	success, err := r.runReference(fn, []string{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "line 2: got \"B\\n\", expected \"b\\n\"\n"
+	if success || diag.String() != expected {
+		t.Errorf("runReference = %v, printed %q, expected false, %q",
+			success, diag.String(), expected)
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// A replayEntry is one invocation in a file written by RecordTo.
+type replayEntry struct {
+	Source string   `json:"source"`
+	Line   int      `json:"line,omitempty"`
+	Raw    *string  `json:"raw,omitempty"`
+	Args   []string `json:"args"`
+	Values []string `json:"values,omitempty"`
+	Error  string   `json:"error,omitempty"`
+}
+
+// A recorder holds the file that invocations are recorded to, which is shared
+// by the copies of a program made for each run.
+type recorder struct {
+	sync.Mutex
+	file io.WriteCloser // or nil
+}
+
+// on returns true if invocations are being recorded.
+func (c *recorder) on() bool {
+	c.Lock()
+	defer c.Unlock()
+	return c.file != nil
+}
+
+// RecordTo makes the program record every invocation to the file at path, so
+// that a user who runs into a data-dependent failure can send the file to the
+// program's author, who can then run the same invocations again with Replay
+// without needing the original input. Each invocation is written as a line of
+// JSON with its source (see Source), its line number, the line it came from,
+// its arguments, their parsed values formatted with fmt, and its error if it
+// failed. An empty path stops recording and closes the file.
+//
+// Deprecated: Use Program.RecordTo instead.
+func RecordTo(path string) error {
+	return std.RecordTo(path)
+}
+
+// RecordTo is like the package-level RecordTo, but for p.
+func (p *Program) RecordTo(path string) error {
+	c := p.recorder
+	c.Lock()
+	defer c.Unlock()
+	if c.file != nil {
+		c.file.Close()
+		c.file = nil
+	}
+	if path == "" {
+		return nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	c.file = f
+	return nil
+}
+
+// recordInvocation writes an invocation to the recording file, if there is
+// one. The line number n is zero for the command-line arguments.
+func (r *run) recordInvocation(rec record, parsed []interface{}, err error,
+	n int) {
+	c := r.recorder
+	c.Lock()
+	defer c.Unlock()
+	if c.file == nil {
+		return
+	}
+	e := replayEntry{Source: r.source.String(), Line: n, Args: rec.tokens}
+	if rec.raw != nil {
+		raw := string(rec.raw)
+		e.Raw = &raw
+	}
+	for _, v := range parsed {
+		e.Values = append(e.Values, fmt.Sprint(v))
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	data, _ := json.Marshal(e)
+	c.file.Write(append(data, '\n'))
+}
+
+// Replay runs the invocations recorded by RecordTo in the file at path again,
+// parsing their arguments with the current parsers and passing them to fn. It
+// does not print anything or exit. Like Collect, it returns a LineErrors for
+// the invocations that fail to parse or that make fn call Fail, where the line
+// numbers refer to the file, unless the file cannot be read.
+//
+// Deprecated: Use Program.Replay instead.
+func Replay(path string, fn func([]interface{})) error {
+	return std.Replay(path, fn)
+}
+
+// Replay is like the package-level Replay, but for p.
+func (p *Program) Replay(path string, fn func([]interface{})) error {
+	r := p.newRun()
+	defer r.enter()()
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var errs LineErrors
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, maxRecordSize)
+	for n := 1; scanner.Scan(); n++ {
+		var e replayEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return fmt.Errorf("%s:%d: %v", path, n, err)
+		}
+		rec := record{tokens: e.Args}
+		if e.Raw != nil {
+			rec.raw = []byte(*e.Raw)
+		}
+		parsed, err := r.parseRecord(rec)
+		if err != nil {
+			errs = append(errs, &LineError{path, n, err})
+			if !r.keepGoing {
+				break
+			}
+			continue
+		}
+		if _, err := r.callFn(fn, parsed); err != nil {
+			errs = append(errs, &LineError{path, n, err})
+			if !r.keepGoing {
+				break
+			}
+		}
+		if r.stopping() {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if errs != nil {
+		return errs
+	}
+	return nil
+}
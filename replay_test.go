@@ -0,0 +1,57 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	defer func() {
+		SetEveryParser(Parser{})
+		RecordTo("")
+	}()
+	path := filepath.Join(t.TempDir(), "replay.jsonl")
+	if err := RecordTo(path); err != nil {
+		t.Fatal(err)
+	}
+	SetEveryParser(Int)
+	fn := func(args []interface{}) {}
+	r := std.newRun()
+	r.source = PipeSource
+	r.mapReader(fn, strings.NewReader("1 '2'\nx\n3\n"),
+		log.New(io.Discard, "", 0), 0)
+	r.apply(fn, []string{"4"})
+	RecordTo("")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"source":"pipe","line":1,"raw":"1 '2'","args":["1","2"],` +
+		`"values":["1","2"]}
+{"source":"pipe","line":2,"raw":"x","args":["x"],` +
+		`"error":"\"x\" is not a whole number"}
+{"source":"pipe","line":3,"raw":"3","args":["3"],"values":["3"]}
+{"source":"pipe","args":["4"],"values":["4"]}
+`
+	if string(data) != expected {
+		t.Errorf("recorded\n%s\nexpected\n%s", data, expected)
+	}
+
+	var got []interface{}
+	err = Replay(path, func(args []interface{}) { got = append(got, args...) })
+	if expected := []interface{}{1, 2, 3, 4}; !reflect.DeepEqual(got,
+		expected) {
+		t.Errorf("Replay passed %v, expected %v", got, expected)
+	}
+	msg := path + `:2: "x" is not a whole number`
+	if err == nil || err.Error() != msg {
+		t.Errorf("Replay returned %v, expected %s", err, msg)
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// SetReport makes the program write a report of its run to the file at path
+// when it exits, giving orchestration systems a machine-readable record of what
+// each run did. The report is a JSON object with the program name, its
+// arguments, the source and names of its inputs and their checksums (see
+// SetChecksums), the numbers of lines processed, failed, and skipped, the error
+// messages, the start time and duration of the run, and its exit status. Only
+// the first 100 errors are listed, followed by a count of the rest. The start
+// time and duration are left out when the output is deterministic (see
+// SetDeterministic). An empty path, the default, turns the report off.
+//
+// Deprecated: Use Program.SetReport instead.
+func SetReport(path string) {
+	std.SetReport(path)
+}
+
+// SetReport is like the package-level SetReport, but for p.
+func (p *Program) SetReport(path string) {
+	p.reportPath = path
+}
+
+// maxReportErrors is the maximum number of errors listed in the report.
+const maxReportErrors = 100
+
+// A runReport is the report written by writeReport.
+type runReport struct {
+	Program       string      `json:"program"`
+	Args          []string    `json:"args"`
+	Source        string      `json:"source"`
+	Inputs        []string    `json:"inputs"`
+	Checksums     []reportSum `json:"checksums,omitempty"`
+	Lines         int64       `json:"lines"`
+	Failed        int64       `json:"failed"`
+	Skipped       int64       `json:"skipped"`
+	Errors        []string    `json:"errors"`
+	ErrorsOmitted int         `json:"errors_omitted,omitempty"`
+	Start         *time.Time  `json:"start,omitempty"`
+	Duration      *float64    `json:"duration_seconds,omitempty"`
+	ExitCode      int         `json:"exit_code"`
+}
+
+// A reportSum is an InputChecksum in the report.
+type reportSum struct {
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256"`
+	Bytes  int64  `json:"bytes"`
+	Lines  int64  `json:"lines"`
+}
+
+// startReport records the start of r.
+func (r *run) startReport() {
+	r.report.Lock()
+	defer r.report.Unlock()
+	r.report.start = time.Now()
+}
+
+// reportInput adds the input called name to the report.
+func (r *run) reportInput(name string) {
+	if r.reportPath == "" {
+		return
+	}
+	r.report.Lock()
+	defer r.report.Unlock()
+	r.report.inputs = append(r.report.inputs, name)
+}
+
+// noteError records err for the report and for Run, with the given log prefix,
+// which holds the input name and line number if there are any. The program name
+// is left out. Each error in a MultiError is added to the report separately, as
+// logError prints them.
+func (r *run) noteError(prefix string, err error) {
+	prefix = strings.TrimPrefix(prefix, r.name+": ")
+	r.collectError(prefix, err)
+	if r.reportPath == "" {
+		return
+	}
+	r.report.Lock()
+	defer r.report.Unlock()
+	errs := []error{err}
+	if m, ok := err.(MultiError); ok {
+		errs = errs[:0]
+		for _, e := range m {
+			errs = append(errs, e)
+		}
+	}
+	for _, e := range errs {
+		if len(r.report.errors) == maxReportErrors {
+			r.report.errorsOmitted++
+			continue
+		}
+		r.report.errors = append(r.report.errors, prefix+e.Error())
+	}
+}
+
+// writeReport writes the report to reportPath, if it is set, given the exit
+// status. It only writes the report once.
+func (r *run) writeReport(code int) error {
+	if r.reportPath == "" {
+		return nil
+	}
+	r.report.Lock()
+	defer r.report.Unlock()
+	if r.report.written {
+		return nil
+	}
+	r.report.written = true
+	rep := runReport{
+		Program:       r.name,
+		Args:          append([]string{}, r.commandLine()...),
+		Source:        r.source.String(),
+		Inputs:        append([]string{}, r.report.inputs...),
+		Lines:         r.counts.lines.Load(),
+		Failed:        r.counts.failed.Load(),
+		Skipped:       r.counts.skipped.Load(),
+		Errors:        append([]string{}, r.report.errors...),
+		ErrorsOmitted: r.report.errorsOmitted,
+		ExitCode:      code,
+	}
+	for _, c := range r.Checksums() {
+		rep.Checksums = append(rep.Checksums, reportSum{c.Name,
+			fmt.Sprintf("%x", c.SHA256), c.Bytes, c.Lines})
+	}
+	if !r.deterministic && !r.report.start.IsZero() {
+		start := r.report.start
+		duration := time.Since(start).Seconds()
+		rep.Start, rep.Duration = &start, &duration
+	}
+	data, err := json.MarshalIndent(rep, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.reportPath, append(data, '\n'), 0o644)
+}
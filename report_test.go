@@ -0,0 +1,66 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReport(t *testing.T) {
+	defer func(args []string, w, d io.Writer) {
+		os.Args = args
+		std.output, std.diagnostics = w, d
+		SetEveryParser(Parser{})
+		SetReport("")
+		SetDeterministic(false)
+		log.SetOutput(os.Stderr)
+	}(os.Args, std.output, std.diagnostics)
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input")
+	if err := os.WriteFile(input, []byte("1\nx\n3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "report.json")
+	SetReport(path)
+	SetDeterministic(true)
+	SetEveryParser(Int)
+	std.output, std.diagnostics = io.Discard, io.Discard
+	log.SetOutput(io.Discard)
+	os.Args = []string{"prog", "-", "extra"}
+	r := std.newRun()
+	r.source = FileSource
+	code := catchExit(func() {
+		r.finishInput(r.mapFiles(func([]interface{}) {}, []string{input}))
+	})
+	if code != 1 {
+		t.Fatalf("exit status %d, expected 1", code)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"program":   std.name,
+		"args":      []interface{}{"-", "extra"},
+		"source":    "file",
+		"inputs":    []interface{}{input},
+		"lines":     3.0,
+		"failed":    1.0,
+		"skipped":   0.0,
+		"errors":    []interface{}{input + ":2: \"x\" is not a whole number"},
+		"exit_code": 1.0,
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("report = %v\nexpected %v", got, expected)
+	}
+}
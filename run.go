@@ -0,0 +1,132 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// invokeExit is the value that exit panics with for a run by Run or Invoke, so
+// that they can return instead of exiting.
+type invokeExit int
+
+// A RunError is returned by Run when the program fails. It holds the errors
+// that were printed, with the name of the input and the line number if there
+// are any, except that it only holds the first 100 of them.
+type RunError struct {
+	Code int     // the suggested exit status
+	Errs []error // the errors, in the order they were printed
+}
+
+// Error returns the messages of the errors, one per line, or the exit status if
+// there are none.
+func (e *RunError) Error() string {
+	if len(e.Errs) == 0 {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	msgs := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns the errors, for errors.Is and errors.As.
+func (e *RunError) Unwrap() []error {
+	return e.Errs
+}
+
+// maxRunErrors is the maximum number of errors in a RunError.
+const maxRunErrors = 100
+
+// collectError adds err to the errors of r if it is run by Run, with the given
+// prefix, which holds the input name and line number if there are any. Each
+// error in a MultiError is added separately, as logError prints them.
+func (r *run) collectError(prefix string, err error) {
+	r.errs.Lock()
+	defer r.errs.Unlock()
+	if !r.errs.on {
+		return
+	}
+	errs := []error{err}
+	if m, ok := err.(MultiError); ok {
+		errs = errs[:0]
+		for _, e := range m {
+			errs = append(errs, e)
+		}
+	}
+	for _, e := range errs {
+		if len(r.errs.list) == maxRunErrors {
+			return
+		}
+		if prefix != "" {
+			e = &prefixedError{prefix, e}
+		}
+		r.errs.list = append(r.errs.list, e)
+	}
+}
+
+// A prefixedError is an error with a prefix, such as "file:3: ", added to its
+// message.
+type prefixedError struct {
+	prefix string
+	err    error
+}
+
+func (e *prefixedError) Error() string {
+	return e.prefix + e.err.Error()
+}
+
+func (e *prefixedError) Unwrap() error {
+	return e.err
+}
+
+// Run is like Main, but instead of exiting when the program fails, it returns
+// the exit status that Main would have used and a RunError, leaving it to the
+// caller to end the program. It still prints errors and the usage message like
+// Main. It returns 0 and nil if the program succeeds. The report (see
+// SetReport), the temporary directory (see TempDir), and the lock (see
+// Exclusive) are finished before Run returns, but the handlers registered with
+// OnExit are not called, since the program does not exit. Options configure
+// the run without changing the settings of later ones (see Option), and like
+// with Invoke, built-in flags in the arguments apply to the run only.
+//
+// Deprecated: Use Program.Run instead.
+func Run(fn func([]interface{}), opts ...Option) (code int, err error) {
+	return std.Run(fn, opts...)
+}
+
+// Run is like the package-level Run, but it runs p.
+func (p *Program) Run(fn func([]interface{}), opts ...Option) (code int,
+	err error) {
+	q := *p
+	for _, opt := range opts {
+		opt.set(&q)
+	}
+	r := q.newRun()
+	r.returning = true
+	r.errs.on = true
+	defer func() {
+		if v := recover(); v != nil {
+			exitCode, ok := v.(invokeExit)
+			if !ok {
+				panic(v)
+			}
+			code = int(exitCode)
+			if sig := r.signalled.Load(); sig != 0 {
+				code = int(sig)
+			}
+			r.errs.Lock()
+			errs := r.errs.list
+			r.errs.Unlock()
+			if err := r.end(code); err != nil {
+				errs = append(errs, err)
+			}
+			err = &RunError{code, errs}
+		}
+	}()
+	r.main(fn)
+	r.exitIfSignalled()
+	return 0, nil
+}
@@ -0,0 +1,44 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	defer func(args []string) {
+		os.Args = args
+		SetEveryParser(Parser{})
+		SetStreams(Streams{Results: os.Stdout, Diagnostics: os.Stderr})
+	}(os.Args)
+	SetStreams(Streams{Results: io.Discard, Diagnostics: io.Discard})
+	SetEveryParser(Int)
+	var sum int
+	fn := func(args []interface{}) {
+		for _, n := range AssertInts(args) {
+			sum += n
+		}
+	}
+	os.Args = []string{"prog", "1", "2"}
+	if code, err := Run(fn); code != 0 || err != nil || sum != 3 {
+		t.Errorf("Run returned %d, %v and summed %d", code, err, sum)
+	}
+	os.Args = []string{"prog", "x", "4", "y"}
+	code, err := Run(fn)
+	var runErr *RunError
+	if code != 1 || !errors.As(err, &runErr) || len(runErr.Errs) != 2 {
+		t.Fatalf("Run returned %d, %v", code, err)
+	}
+	expected := "\"x\" is not a whole number\n\"y\" is not a whole number"
+	if err.Error() != expected {
+		t.Errorf("Run returned error %q, expected %q", err, expected)
+	}
+	var argErr *ArgError
+	if !errors.As(err, &argErr) || argErr.Arg != "x" {
+		t.Errorf("RunError does not unwrap to the first ArgError: %v", argErr)
+	}
+}
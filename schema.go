@@ -0,0 +1,254 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"text/tabwriter"
+)
+
+// A Schema describes the arguments that a program accepts. It is printed as
+// JSON when the program is invoked with the hidden flag "--schema=json" (see
+// SetBuiltinFlags), so that external tools such as GUIs, documentation
+// generators, and completion engines can inspect any program that uses parse.
+type Schema struct {
+	Program   string      `json:"program" yaml:"program"`
+	Usage     string      `json:"usage" yaml:"usage"`
+	Repeat    bool        `json:"repeat" yaml:"repeat"`
+	Arguments []ArgSchema `json:"arguments" yaml:"arguments"`
+}
+
+// An ArgSchema describes a single argument in a Schema.
+type ArgSchema struct {
+	Name        string   `json:"name" yaml:"name"`
+	Type        string   `json:"type" yaml:"type"`
+	Description string   `json:"description,omitempty" yaml:"description"`
+	Default     *string  `json:"default,omitempty" yaml:"default"`
+	Example     string   `json:"example,omitempty" yaml:"example"`
+	Choices     []string `json:"choices,omitempty" yaml:"choices"`
+	Repeated    bool     `json:"repeated,omitempty" yaml:"repeated"`
+}
+
+// CurrentSchema returns the Schema for the arguments declared by the program.
+// The type of an argument is "string" unless its Parser provides one, like
+// "int" for Int or "choice" for Choice. The choices are listed for parsers
+// whose Suggest method offers a fixed set of values.
+//
+// Deprecated: Use Program.CurrentSchema instead.
+func CurrentSchema() Schema {
+	return std.CurrentSchema()
+}
+
+// CurrentSchema is like the package-level CurrentSchema, but for p.
+func (p *Program) CurrentSchema() Schema {
+	s := Schema{
+		Program:   p.name,
+		Usage:     p.usageMessage(),
+		Repeat:    p.repeat,
+		Arguments: make([]ArgSchema, p.numDeclared()),
+	}
+	for i := range s.Arguments {
+		info := p.parsers[i].info()
+		arg := ArgSchema{
+			Name:        p.argName(i),
+			Type:        info.typ,
+			Description: info.help,
+			Example:     info.example,
+			Repeated:    p.isRest(i),
+		}
+		if arg.Type == "" {
+			arg.Type = "string"
+		}
+		if info.hasDefault && !p.isRest(i) {
+			def := info.def
+			arg.Default = &def
+		}
+		switch arg.Type {
+		case "choice", "enum", "bool", "confirm":
+			arg.Choices = p.parsers[i].Suggest("")
+		}
+		s.Arguments[i] = arg
+	}
+	return s
+}
+
+// FromSchema declares the program's arguments as described by s, so that large
+// tools can keep their definitions in data that is reviewed separately from the
+// code. It sets the parsers, names, defaults, descriptions, and examples of the
+// arguments, and the usage message if s has one, in which case the program
+// name in it is replaced by the actual one. The name of the program is not
+// changed. If s.Repeat is true, the argument whose Repeated field is true, or
+// the last one if there is none, takes all the remaining arguments (see
+// SetRepeated).
+//
+// The types of the arguments are the ones that can be used with FromSpec,
+// "choice" and "enum", which accept the values in Choices, and the types of the
+// other parsers provided by the package, such as "port" for Port and "money"
+// for Money, so that a schema printed by the program declares the same parsers
+// when it is loaded again. This is not true of every program, since the schema
+// only records the type: custom parsers and restrictions are not described,
+// parsers that return ints or float64s, like Roman and SI, load back as Int
+// and Float64, and an Enum loads back as a Choice of its names. FromSchema
+// returns an error if a type is not supported or s has no arguments, without
+// changing anything.
+//
+// Deprecated: Use Program.FromSchema instead.
+func FromSchema(s Schema) error {
+	return std.FromSchema(s)
+}
+
+// FromSchema is like the package-level FromSchema, but for p.
+func (p *Program) FromSchema(s Schema) error {
+	sp, err := compileSchema(s)
+	if err != nil {
+		return err
+	}
+	p.applySpec(sp)
+	return nil
+}
+
+// FromSchemaFile is like FromSchema, but it reads the Schema from the file
+// called name in fsys, which is usually an embed.FS. The file is in the YAML
+// format if its name ends in ".yaml" or ".yml", and otherwise in the JSON
+// format printed by the hidden built-in flag "--schema=json". The fields have
+// the same names in both. Unknown fields are rejected, to catch misspellings.
+//
+// Deprecated: Use Program.FromSchemaFile instead.
+func FromSchemaFile(fsys fs.FS, name string) error {
+	return std.FromSchemaFile(fsys, name)
+}
+
+// FromSchemaFile is like the package-level FromSchemaFile, but for p.
+func (p *Program) FromSchemaFile(fsys fs.FS, name string) error {
+	s, err := readSchema(fsys, name)
+	if err != nil {
+		return err
+	}
+	return p.FromSchema(s)
+}
+
+// readSchema reads a Schema in the YAML or JSON format, depending on its
+// extension, from the file called name in fsys.
+func readSchema(fsys fs.FS, name string) (Schema, error) {
+	var s Schema
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return s, err
+	}
+	switch path.Ext(name) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&s)
+	default:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&s)
+	}
+	if err != nil {
+		return s, fmt.Errorf("%s: %v", name, err)
+	}
+	return s, nil
+}
+
+// schemaParsers are the parsers provided by the package whose types can be
+// used in a Schema, besides the ones in specTypes.
+var schemaParsers = []Parser{
+	UnprivilegedPort, Confirm, Money, TimeRange, DurationRange, MIMEType,
+	FileExt, LanguageTag, HTTPMethod, HTTPStatus,
+}
+
+// schemaParser returns the parser in schemaParsers of the given type.
+func schemaParser(typ string) (Parser, bool) {
+	for _, p := range schemaParsers {
+		if p.info().typ == typ {
+			return p, true
+		}
+	}
+	return Parser{}, false
+}
+
+// compileSchema converts a Schema to a spec.
+func compileSchema(s Schema) (spec, error) {
+	sp := spec{rest: -1}
+	if len(s.Arguments) == 0 {
+		return sp, errEmptySpec
+	}
+	for i, arg := range s.Arguments {
+		var p Parser
+		t, ok := specTypes[arg.Type]
+		if !ok {
+			t.parser, ok = schemaParser(arg.Type)
+		}
+		switch {
+		case arg.Type == "choice" || arg.Type == "enum":
+			if len(arg.Choices) == 0 {
+				return sp, fmt.Errorf("argument %d (%q): no choices", i+1,
+					arg.Name)
+			}
+			p = Choice(arg.Choices...)
+		case ok:
+			p = t.parser
+		case arg.Type == "":
+			p = String
+		default:
+			return sp, fmt.Errorf("argument %d (%q): unknown type %q", i+1,
+				arg.Name, arg.Type)
+		}
+		if arg.Default != nil {
+			p = p.Default(*arg.Default)
+		}
+		if arg.Description != "" {
+			p = p.Help(arg.Description)
+		}
+		if arg.Example != "" {
+			p = p.Example(arg.Example)
+		}
+		sp.parsers = append(sp.parsers, p)
+		sp.names = append(sp.names, arg.Name)
+	}
+	sp.rest = -1
+	for i, arg := range s.Arguments {
+		if arg.Repeated || s.Repeat && sp.rest < 0 && i == len(s.Arguments)-1 {
+			sp.rest = i
+		}
+	}
+	if u := strings.TrimSpace(strings.TrimPrefix(s.Usage, "usage:")); u != "" {
+		// Leave out the program name.
+		_, sp.usage, _ = strings.Cut(u, " ")
+		sp.usage = strings.TrimSpace(sp.usage)
+	}
+	return sp, nil
+}
+
+// writeSchema writes the schema of p to w as indented JSON.
+func (p *Program) writeSchema(w io.Writer) error {
+	data, err := json.MarshalIndent(p.CurrentSchema(), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// writeTypes writes a table to w with the name, type, and example of each
+// argument, as given by the Describe method of its Parser. It is a quicker
+// alternative to the schema for people exploring a program.
+func (p *Program) writeTypes(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	for i := 0; i < p.numDeclared(); i++ {
+		typ, example := p.parsers[i].Describe()
+		if typ == "" {
+			typ = "string"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", p.argName(i), typ, example)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,203 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestWriteSchema(t *testing.T) {
+	defer func(name string) {
+		std.name = name
+		SetEveryParser(Parser{})
+		SetNames()
+	}(std.name)
+	std.name = "sleep"
+	std.usage, std.hasUsage = "", false
+	SetParsers(Float64.Help("seconds to sleep").Example("1.5"),
+		Choice("s", "m").Default("s"))
+	SetNames("duration", "unit")
+	var b bytes.Buffer
+	if err := std.writeSchema(&b); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{
+  "program": "sleep",
+  "usage": "usage: sleep duration [unit]",
+  "repeat": false,
+  "arguments": [
+    {
+      "name": "duration",
+      "type": "float64",
+      "description": "seconds to sleep",
+      "example": "1.5"
+    },
+    {
+      "name": "unit",
+      "type": "choice",
+      "default": "s",
+      "choices": [
+        "s",
+        "m"
+      ]
+    }
+  ]
+}
+`
+	if b.String() != expected {
+		t.Errorf("writeSchema wrote\n%s\nexpected\n%s", b.String(), expected)
+	}
+}
+
+// weekday is an ArgType that implements Describer and Suggester.
+type weekday struct{}
+
+var weekdays = []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}
+
+func (weekday) Parse(s string) (interface{}, error) {
+	for i, d := range weekdays {
+		if s == d {
+			return i, nil
+		}
+	}
+	return nil, choiceError(s, weekdays)
+}
+
+func (weekday) Describe() (string, string) {
+	return "weekday", "mon"
+}
+
+func (weekday) Suggest(prefix string) []string {
+	return withPrefix(weekdays, prefix)
+}
+
+func TestWriteTypes(t *testing.T) {
+	defer func() {
+		SetEveryParser(Parser{})
+		SetNames()
+	}()
+	day := FromArgType(weekday{})
+	SetParsers(Float64.Example("1.5"), day, Parser{})
+	SetNames("duration")
+	var b bytes.Buffer
+	if err := std.writeTypes(&b); err != nil {
+		t.Fatal(err)
+	}
+	expected := "" +
+		"duration  float64  1.5\n" +
+		"weekday   weekday  mon\n" +
+		"arg       string   \n"
+	if b.String() != expected {
+		t.Errorf("writeTypes wrote\n%q\nexpected\n%q", b.String(), expected)
+	}
+	if s := day.Suggest("t"); len(s) != 2 || s[0] != "tue" {
+		t.Errorf("Suggest returned %v", s)
+	}
+	if x, err := day.Parse("wed"); x != 2 || err != nil {
+		t.Errorf("parsed %v, %v", x, err)
+	}
+	var _ Describer = day
+	var _ Suggester = day
+	_, err := Parse([]string{"1", "noday", "x"})
+	if err == nil || !strings.Contains(err.Error(), "mon") {
+		t.Errorf("error %v does not include the example", err)
+	}
+}
+
+func TestFromSchemaFile(t *testing.T) {
+	defer func(name string) {
+		std.name = name
+		SetEveryParser(Parser{})
+		SetNames()
+	}(std.name)
+	std.name = "sleep"
+	std.usage, std.hasUsage = "", false
+	SetParsers(Float64.Help("seconds to sleep").Example("1.5"),
+		Choice("s", "m").Default("s"))
+	SetNames("duration", "unit")
+	var b bytes.Buffer
+	if err := std.writeSchema(&b); err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{
+		"sleep.json": {Data: b.Bytes()},
+		"bad.json":   {Data: []byte(`{"arguments": [{"typ": "int"}]}`)},
+		"day.json":   {Data: []byte(`{"arguments": [{"type": "weekday"}]}`)},
+		"sleep.yaml": {Data: []byte("usage: sleep duration\narguments:\n" +
+			"  - name: duration\n    type: float64\n    default: \"1\"\n")},
+		"bad.yml": {Data: []byte("arguments:\n  - typ: int\n")},
+	}
+	p := New()
+	p.SetName("nap")
+	if err := p.FromSchemaFile(fsys, "sleep.json"); err != nil {
+		t.Fatal(err)
+	}
+	s := p.CurrentSchema()
+	values, err := p.Parse([]string{"2"})
+	if s.Usage != "usage: nap duration [unit]" || len(s.Arguments) != 2 ||
+		s.Arguments[0].Description != "seconds to sleep" ||
+		s.Arguments[1].Choices == nil {
+		t.Errorf("FromSchemaFile gave schema %+v", s)
+	}
+	if expected := []interface{}{2.0, "s"}; err != nil ||
+		!reflect.DeepEqual(values, expected) {
+		t.Errorf("Parse returned %v, %v; expected %v", values, err, expected)
+	}
+	for name, msg := range map[string]string{
+		"bad.json":     `unknown field "typ"`,
+		"day.json":     `unknown type "weekday"`,
+		"bad.yml":      "field typ not found",
+		"missing.json": "file does not exist",
+	} {
+		err := p.FromSchemaFile(fsys, name)
+		if err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("FromSchemaFile(%q) returned %v, expected %q", name, err,
+				msg)
+		}
+	}
+	if err := p.FromSchemaFile(fsys, "sleep.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	values, err = p.Parse(nil)
+	if err != nil || !reflect.DeepEqual(values, []interface{}{1.0}) {
+		t.Errorf("Parse after loading YAML returned %v, %v", values, err)
+	}
+}
+
+func TestSchemaRoundTrip(t *testing.T) {
+	defer func() {
+		SetEveryParser(Parser{})
+		SetNames()
+	}()
+	ps := []Parser{String, Int, Float64, ExistingFile, Port, UnprivilegedPort,
+		Confirm, Money, TimeRange, DurationRange, MIMEType, FileExt,
+		LanguageTag, HTTPMethod, HTTPStatus, ForType(reflect.TypeOf(false)),
+		Choice("a", "b")}
+	SetParsers(ps...)
+	SetNames()
+	s := CurrentSchema()
+	p := New()
+	if err := p.FromSchema(s); err != nil {
+		t.Fatal(err)
+	}
+	for i, q := range p.parsers {
+		want, got := ps[i].info(), q.info()
+		if got.typ != want.typ || got.name != want.name {
+			t.Errorf("%d. type %q loaded back as %q", i, want.typ, got.typ)
+		}
+	}
+	// Check the parsers that were previously confused with Int and bool.
+	if _, err := p.parsers[4].Parse("0"); err == nil {
+		t.Error("Port loaded back accepting port 0")
+	}
+	if _, err := p.parsers[5].Parse("80"); err == nil {
+		t.Error("UnprivilegedPort loaded back accepting port 80")
+	}
+	if x, err := p.parsers[6].Parse("yes"); x != true || err != nil {
+		t.Errorf("Confirm loaded back parsing \"yes\" as %v, %v", x, err)
+	}
+}
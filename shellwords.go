@@ -0,0 +1,148 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultIFS is the default value of the shell's IFS variable: space, tab, and
+// newline.
+const DefaultIFS = " \t\n"
+
+// EnvIFS returns the value of the IFS environment variable, or DefaultIFS if it
+// is not set. (An empty IFS is different from an unset one: it disables field
+// splitting altogether.)
+func EnvIFS() string {
+	if ifs, ok := os.LookupEnv("IFS"); ok {
+		return ifs
+	}
+	return DefaultIFS
+}
+
+// POSIXTokenizer returns a Tokenizer that matches the quoting and field
+// splitting rules of POSIX shells, for programs that need strict compatibility
+// with shell scripts. It differs from ShellTokenizer in a few ways:
+//
+//   - Inside double quotation marks, a backslash only escapes "$", "`", `"`,
+//     a backslash, or a newline. Before any other character, it is kept.
+//   - A backslash followed by a newline is removed entirely.
+//   - Fields are split on the unquoted characters in ifs. Consecutive IFS
+//     whitespace characters (space, tab, and newline) count as one separator,
+//     and leading and trailing ones are ignored, but each other IFS character
+//     separates fields on its own, so "a,,b" has an empty field in the middle
+//     when ifs contains a comma.
+//   - An unterminated quotation is an error, rather than extending to the end
+//     of the record.
+//
+// There is no expansion of variables, commands, or file name patterns: "$",
+// "`", "*", and so on are ordinary characters.
+func POSIXTokenizer(ifs string) Tokenizer {
+	return TokenizerFunc(func(record []byte) ([]string, error) {
+		return splitPOSIX(string(record), ifs)
+	})
+}
+
+// Errors returned by POSIXTokenizer.
+var (
+	errSingleQuote = errors.New("unterminated single quote")
+	errDoubleQuote = errors.New("unterminated double quote")
+)
+
+// isIFSWhite returns true if c is an IFS whitespace character.
+func isIFSWhite(c rune) bool {
+	return c == ' ' || c == '\t' || c == '\n'
+}
+
+// splitPOSIX does the work of POSIXTokenizer.
+func splitPOSIX(s, ifs string) ([]string, error) {
+	fields := []string{}
+	var field strings.Builder
+	started := false    // whether the current field has begun
+	afterWhite := false // whether IFS whitespace just ended a field
+	emit := func() {
+		fields = append(fields, field.String())
+		field.Reset()
+		started = false
+	}
+	for i := 0; i < len(s); {
+		c, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case c == '\\':
+			i++
+			if i < len(s) && s[i] == '\n' {
+				i++
+				continue
+			}
+			if i < len(s) {
+				_, size = utf8.DecodeRuneInString(s[i:])
+				field.WriteString(s[i : i+size])
+				i += size
+			} else {
+				field.WriteByte('\\')
+			}
+			started, afterWhite = true, false
+			continue
+		case c == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, errSingleQuote
+			}
+			field.WriteString(s[i+1 : i+1+end])
+			i += end + 2
+			started, afterWhite = true, false
+			continue
+		case c == '"':
+			n, err := readDoubleQuoted(s[i+1:], &field)
+			if err != nil {
+				return nil, err
+			}
+			i += n + 2
+			started, afterWhite = true, false
+			continue
+		case strings.ContainsRune(ifs, c) && isIFSWhite(c):
+			if started {
+				emit()
+				afterWhite = true
+			}
+		case strings.ContainsRune(ifs, c):
+			if started || !afterWhite {
+				emit()
+			}
+			afterWhite = false
+		default:
+			field.WriteString(s[i : i+size])
+			started, afterWhite = true, false
+		}
+		i += size
+	}
+	if started {
+		emit()
+	}
+	return fields, nil
+}
+
+// readDoubleQuoted writes the contents of a double-quoted string to field,
+// starting just after the opening quotation mark in s. It returns the number of
+// bytes before the closing quotation mark.
+func readDoubleQuoted(s string, field *strings.Builder) (int, error) {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			return i, nil
+		case '\\':
+			if i+1 < len(s) && strings.IndexByte("$`\"\\\n", s[i+1]) >= 0 {
+				i++
+				if s[i] != '\n' {
+					field.WriteByte(s[i])
+				}
+				continue
+			}
+		}
+		field.WriteByte(s[i])
+	}
+	return 0, errDoubleQuote
+}
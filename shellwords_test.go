@@ -0,0 +1,110 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// shellCorpus is a list of records that are tokenized the same way by
+// POSIXTokenizer with the default IFS and by eval in a POSIX shell. They avoid
+// characters that would cause expansions or other shell syntax.
+var shellCorpus = []string{
+	``,
+	`   `,
+	`a`,
+	`  a   b	c  `,
+	`''`,
+	`"" ''`,
+	`a''b`,
+	`'a b' "c d"`,
+	`'it''s'`,
+	`"it's"`,
+	`'a\b'`,
+	`"a\b"`,
+	`"a\\b"`,
+	`"a\"b"`,
+	`"a\$b"`,
+	`"a\` + "`" + `b"`,
+	`a\ b`,
+	`a\\b`,
+	`a\'b`,
+	`a\"b`,
+	`\a\b\c`,
+	`x"y"'z'`,
+	`"a` + "\n" + `b"`,
+	"a\\\nb",
+	`"a\` + "\n" + `b"`,
+	`'"' "'"`,
+	`"a  b" c\  d`,
+	`é 'ü ö' "ß"`,
+}
+
+// ifsTests are records split with a custom IFS, checked by hand against the
+// POSIX field splitting rules.
+var ifsTests = []struct {
+	ifs    string
+	input  string
+	fields []string
+}{
+	{",", "a,b", []string{"a", "b"}},
+	{",", "a,,b", []string{"a", "", "b"}},
+	{",", ",a,", []string{"", "a"}},
+	{",", "a b,c", []string{"a b", "c"}},
+	{" ,", "a , b", []string{"a", "b"}},
+	{" ,", "a, ,b", []string{"a", "", "b"}},
+	{" ,", "  ,a", []string{"", "a"}},
+	{" ,", "a,", []string{"a"}},
+	{":", `a:'b:c':"d:e"`, []string{"a", "b:c", "d:e"}},
+	{":", `a\:b`, []string{"a:b"}},
+	{"", "a b c", []string{"a b c"}},
+	{"", "", []string{}},
+}
+
+func TestPOSIXTokenizerIFS(t *testing.T) {
+	for i, test := range ifsTests {
+		fields, err := POSIXTokenizer(test.ifs).Tokenize([]byte(test.input))
+		if err != nil || !reflect.DeepEqual(fields, test.fields) {
+			t.Errorf("%d. splitting %q on %q\nreturned %q, %v\nexpected %q", i,
+				test.input, test.ifs, fields, err, test.fields)
+		}
+	}
+}
+
+func TestPOSIXTokenizerErrors(t *testing.T) {
+	for _, input := range []string{`'a`, `"a`, `a "b\"`, `'a'"`} {
+		_, err := POSIXTokenizer(DefaultIFS).Tokenize([]byte(input))
+		if err == nil {
+			t.Errorf("tokenizing %q succeeded, expected an error", input)
+		}
+	}
+}
+
+// TestPOSIXTokenizerShell compares POSIXTokenizer to a real shell.
+func TestPOSIXTokenizerShell(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no shell found")
+	}
+	for i, input := range shellCorpus {
+		out, err := exec.Command(sh, "-c",
+			`eval "set -- $1"; for a; do printf '%s\0' "$a"; done`, "sh",
+			input).Output()
+		if err != nil {
+			t.Fatalf("%d. sh failed on %q: %v", i, input, err)
+		}
+		expected := []string{}
+		if len(out) > 0 {
+			expected = strings.Split(strings.TrimSuffix(string(out), "\x00"),
+				"\x00")
+		}
+		got, err := POSIXTokenizer(DefaultIFS).Tokenize([]byte(input))
+		if err != nil || !reflect.DeepEqual(got, expected) {
+			t.Errorf("%d. tokenizing %q\nreturned %q, %v\nsh gives %q", i,
+				input, got, err, expected)
+		}
+	}
+}
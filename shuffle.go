@@ -0,0 +1,71 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"io"
+	"math/rand"
+)
+
+// SetShuffle makes the program process the records of its input in a
+// pseudo-random order determined by seed, to flush out bugs in fn that depend
+// on the order of the input. The same seed always gives the same order for the
+// same input, so that a failure can be reproduced. All the records are read
+// before the first one is processed, so interactive input is never shuffled.
+// Line numbers in error messages still refer to the input. A seed of zero turns
+// shuffling off, which is the default.
+//
+// Deprecated: Use Program.SetShuffle instead.
+func SetShuffle(seed int64) {
+	std.SetShuffle(seed)
+}
+
+// SetShuffle is like the package-level SetShuffle, but for p.
+func (p *Program) SetShuffle(seed int64) {
+	p.shuffleSeed = seed
+}
+
+// shuffleRecords reads all the records from source and returns them in the
+// order given by seed, each with its line number in n. An error from source is
+// returned after all the records that were read before it.
+type shuffleRecords struct {
+	seed   int64
+	source recordReader
+	recs   []record
+	err    error
+	loaded bool
+}
+
+func (r *shuffleRecords) next() (record, error) {
+	if !r.loaded {
+		r.load()
+	}
+	if len(r.recs) == 0 {
+		return record{}, r.err
+	}
+	rec := r.recs[0]
+	r.recs = r.recs[1:]
+	return rec, nil
+}
+
+// load reads and shuffles the records.
+func (r *shuffleRecords) load() {
+	r.loaded = true
+	for n := 1; ; n++ {
+		rec, err := r.source.next()
+		if err != nil {
+			r.err = err
+			break
+		}
+		rec = detach(rec)
+		rec.n = n
+		r.recs = append(r.recs, rec)
+	}
+	rng := rand.New(rand.NewSource(r.seed))
+	rng.Shuffle(len(r.recs), func(i, j int) {
+		r.recs[i], r.recs[j] = r.recs[j], r.recs[i]
+	})
+	if r.err == nil {
+		r.err = io.EOF
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bytes"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestShuffle(t *testing.T) {
+	defer func() {
+		SetEveryParser(Parser{})
+		SetShuffle(0)
+	}()
+	SetEveryParser(Int)
+	input := "1\n2\n3\n4\nx\n6\n7\n8\n"
+	run := func(seed int64) ([]int, string) {
+		SetShuffle(seed)
+		var got []int
+		var diag bytes.Buffer
+		r := std.newRun()
+		r.source = PipeSource
+		r.mapReader(func(args []interface{}) {
+			got = append(got, args[0].(int))
+		}, strings.NewReader(input), log.New(&diag, "", 0), 1)
+		return got, diag.String()
+	}
+	got, diag := run(42)
+	again, _ := run(42)
+	if !reflect.DeepEqual(got, again) {
+		t.Errorf("seed 42 gave %v and then %v", got, again)
+	}
+	sorted := append([]int(nil), got...)
+	sort.Ints(sorted)
+	expected := []int{1, 2, 3, 4, 6, 7, 8}
+	if !reflect.DeepEqual(sorted, expected) || reflect.DeepEqual(got,
+		expected) {
+		t.Errorf("seed 42 gave %v, expected a shuffle of %v", got, expected)
+	}
+	if msg := "5: \"x\" is not a whole number\n"; diag != msg {
+		t.Errorf("seed 42 printed %q, expected %q", diag, msg)
+	}
+	if got, _ := run(0); !reflect.DeepEqual(got, expected) {
+		t.Errorf("seed 0 gave %v, expected %v", got, expected)
+	}
+}
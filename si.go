@@ -0,0 +1,58 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// siPrefixes maps SI prefixes to their powers of ten. Both "u" and the micro
+// sign are accepted for micro, and "K" is accepted for kilo since it is common
+// in practice.
+var siPrefixes = map[rune]int{
+	'y': -24, 'z': -21, 'a': -18, 'f': -15, 'p': -12, 'n': -9,
+	'u': -6, 'µ': -6, 'μ': -6, 'm': -3,
+	'k': 3, 'K': 3, 'M': 6, 'G': 9, 'T': 12, 'P': 15, 'E': 18, 'Z': 21,
+	'Y': 24,
+}
+
+// SI returns a Parser that parses a number with an optional SI prefix and an
+// optional unit as a float64 in that unit. For example, SI("Ω") parses "4.7k",
+// "4.7kΩ", and "4.7 kΩ" as 4700, and SI("") parses "10M" as 1e7 and "3u" as
+// 3e-6. Numbers can also be written in scientific notation, such as "4.7e3".
+// The unit is case sensitive, as are the prefixes, so "m" is milli and "M" is
+// mega. When the unit itself is "m", "5m" is 5 and "5mm" is 0.005.
+func SI(unit string) Parser {
+	return NewParser(func(s string) (interface{}, error) {
+		num := strings.TrimSuffix(s, unit)
+		exp := 0
+		if r, size := utf8.DecodeLastRuneInString(num); size > 0 {
+			if e, ok := siPrefixes[r]; ok {
+				num, exp = num[:len(num)-size], e
+			}
+		}
+		num = strings.TrimSuffix(num, " ")
+		x, err := strconv.ParseFloat(num, 64)
+		if err == nil && exp != 0 {
+			// Parsing the prefix as an exponent avoids rounding twice, so
+			// that "4.7k" is exactly 4700. That only works if the number
+			// does not already have one or is not infinity or NaN.
+			if strings.ContainsAny(num, "eEpPnN") {
+				if x *= math.Pow10(exp); math.IsInf(x, 0) {
+					err = &strconv.NumError{Func: "ParseFloat", Num: num,
+						Err: strconv.ErrRange}
+				}
+			} else {
+				x, err = strconv.ParseFloat(num+"e"+strconv.Itoa(exp), 64)
+			}
+		}
+		if err != nil {
+			return nil, &ValueError{s, "number", err.(*strconv.NumError).Err,
+				minFloat64, maxFloat64}
+		}
+		return x, nil
+	}).withInfo(parserInfo{name: "number" + unit, typ: "float64"})
+}
@@ -0,0 +1,47 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import "testing"
+
+var siTests = []struct {
+	unit  string
+	input string
+	value interface{}
+	msg   string
+}{
+	{"", "10M", 1e7, ""},
+	{"", "3u", 3e-6, ""},
+	{"", "3µ", 3e-6, ""},
+	{"", "2.5", 2.5, ""},
+	{"", "4.7e3", 4.7e3, ""},
+	{"", "4.7e3k", 4.7e6, ""},
+	{"", "-1.5G", -1.5e9, ""},
+	{"", "1K", 1e3, ""},
+	{"Ω", "4.7k", 4700.0, ""},
+	{"Ω", "4.7kΩ", 4700.0, ""},
+	{"Ω", "4.7 kΩ", 4700.0, ""},
+	{"Ω", "220 Ω", 220.0, ""},
+	{"m", "5m", 5.0, ""},
+	{"m", "5mm", 0.005, ""},
+	{"Hz", "2.4GHz", 2.4e9, ""},
+	{"", "k", nil, `"k" is not a number`},
+	{"", "5x", nil, `"5x" is not a number`},
+	{"", "5  k", nil, `"5  k" is not a number`},
+	{"", "1e300Y", nil, `"1e300Y" is too large (max 1.7976931348623157e+308)`},
+}
+
+func TestSI(t *testing.T) {
+	for i, test := range siTests {
+		value, err := SI(test.unit).Parse(test.input)
+		msg := ""
+		if err != nil {
+			msg = err.Error()
+		}
+		if value != test.value || msg != test.msg {
+			t.Errorf("%d. SI(%q)(%q)\nreturned %s and %q\nexpected %s and %q",
+				i, test.unit, test.input, formatValue(value), msg,
+				formatValue(test.value), test.msg)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// A specType is a type of argument that can be named in a spec.
+type specType struct {
+	parser  Parser
+	zero    string // parsed for an optional argument with no default
+	hasZero bool   // whether zero is used
+}
+
+// specTypes maps the type names that can be used in a spec or a Schema (see
+// FromSchema) to their types.
+var specTypes = map[string]specType{
+	"string":  {String, "", true},
+	"path":    {String.with(func(i *parserInfo) { i.typ = "path" }), "", true},
+	"int":     {Int, "0", true},
+	"float":   {Float64, "0", true},
+	"float64": {Float64, "0", true},
+	"bool":    {ForType(reflect.TypeOf(false)), "false", true},
+	"file":    {ExistingFile, "", false},
+	"port":    {Port, "", false},
+}
+
+// A spec is the result of compiling the string passed to FromSpec or the Schema
+// passed to FromSchema.
+type spec struct {
+	parsers []Parser
+	names   []string
+	rest    int    // index of the repeated argument, or -1 for none
+	usage   string // the arguments for SetUsage, or "" to generate them
+}
+
+// applySpec declares the arguments in sp for p.
+func (p *Program) applySpec(sp spec) {
+	p.SetParsers(sp.parsers...)
+	if sp.rest >= 0 {
+		p.SetRepeated(sp.rest)
+	}
+	p.SetNames(sp.names...)
+	p.usage, p.hasUsage = sp.usage, sp.usage != ""
+}
+
+// FromSpec declares the program's arguments with a compact spec, setting their
+// parsers, names, and which ones are optional, and replacing the usage message
+// with one generated from them. It makes quick tools nearly declaration-free.
+// For example,
+//
+//	parse.FromSpec("seconds:int count:int? files:path...")
+//
+// declares an int named seconds, an optional int named count, and any number
+// of paths named files, with the usage message "usage: prog seconds [count]
+// [files ...]".
+//
+// The spec is a list of arguments separated by spaces. Each one is a name,
+// optionally followed by a colon and a type, which is "string" if it is left
+// out. The types are string, path, int, float, float64, bool, file (see
+// ExistingFile), and port (see Port), and a list of choices separated by
+// vertical bars, such as "mode:fast|slow" (see Choice). An argument followed by
+// "?" is optional, and when it is missing, its type's zero value is parsed in
+// its place. One followed by "=" and a value, such as "count:int=10", is
+// optional with that value as its default. Types without a zero value, namely
+// file, port, and choices, need an explicit default to be optional. One
+// argument can be followed by "..." to make it take all the remaining
+// arguments (see SetRepeated), as in "src:file... dest:path". Values can be
+// quoted like arguments on a line of standard input.
+//
+// FromSpec panics if the spec is invalid.
+//
+// Deprecated: Use Program.FromSpec instead.
+func FromSpec(s string) {
+	std.FromSpec(s)
+}
+
+// FromSpec is like the package-level FromSpec, but for p.
+func (p *Program) FromSpec(s string) {
+	sp, err := compileSpec(s)
+	if err != nil {
+		panic("parse: FromSpec: " + err.Error())
+	}
+	p.applySpec(sp)
+}
+
+// errEmptySpec is returned by compileSpec for a spec with no arguments.
+var errEmptySpec = errors.New("no arguments")
+
+// compileSpec converts the string passed to FromSpec to a spec.
+func compileSpec(s string) (spec, error) {
+	sp := spec{rest: -1}
+	args := tokenize([]byte(s)).strings()
+	if len(args) == 0 {
+		return sp, errEmptySpec
+	}
+	for i, arg := range args {
+		p, name, repeated, err := compileSpecArg(arg)
+		if err == nil && repeated && sp.rest >= 0 {
+			err = errors.New("only one argument can be repeated")
+		}
+		if err != nil {
+			return sp, fmt.Errorf("argument %d (%q): %s", i+1, arg, err)
+		}
+		if repeated {
+			sp.rest = i
+		}
+		sp.parsers = append(sp.parsers, p)
+		sp.names = append(sp.names, name)
+	}
+	return sp, nil
+}
+
+// compileSpecArg converts a single argument in a spec to a Parser and a name,
+// and returns whether it is repeated.
+func compileSpecArg(arg string) (Parser, string, bool, error) {
+	decl, def, hasDefault := strings.Cut(arg, "=")
+	repeated := strings.HasSuffix(decl, "...")
+	decl = strings.TrimSuffix(decl, "...")
+	optional := strings.HasSuffix(decl, "?")
+	decl = strings.TrimSuffix(decl, "?")
+	name, typeName, _ := strings.Cut(decl, ":")
+	if typeName == "" {
+		typeName = "string"
+	}
+	t, ok := specTypes[typeName]
+	if strings.Contains(typeName, "|") {
+		t, ok = specType{parser: Choice(strings.Split(typeName, "|")...)}, true
+	}
+	var err error
+	switch {
+	case name == "":
+		err = errors.New("missing name")
+	case !ok:
+		err = fmt.Errorf("unknown type %q", typeName)
+	case repeated && (optional || hasDefault):
+		err = errors.New("a repeated argument cannot be optional")
+	case optional && hasDefault:
+		err = errors.New("both \"?\" and a default")
+	case optional && !t.hasZero:
+		err = fmt.Errorf("type %q needs a default to be optional", typeName)
+	case optional:
+		def, hasDefault = t.zero, true
+	}
+	if err != nil {
+		return Parser{}, "", false, err
+	}
+	p := t.parser
+	if hasDefault {
+		p = p.Default(def)
+	}
+	return p, name, repeated, nil
+}
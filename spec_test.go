@@ -0,0 +1,76 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var specTests = []struct {
+	spec   string
+	usage  string
+	args   []string
+	values []interface{}
+}{
+	{"name", "usage: prog name", []string{"x"}, []interface{}{"x"}},
+	{"seconds:int count:int? files:path...",
+		"usage: prog seconds [count] [files ...]",
+		[]string{"5"}, []interface{}{5, 0}},
+	{"seconds:int count:int? files:path...",
+		"usage: prog seconds [count] [files ...]",
+		[]string{"5", "2", "a", "b"}, []interface{}{5, 2, "a", "b"}},
+	{"x:float=1.5 on:bool?", "usage: prog [x] [on]",
+		nil, []interface{}{1.5, false}},
+	{"mode:fast|slow 'greeting=hello world'", "usage: prog mode [greeting]",
+		[]string{"slow"}, []interface{}{"slow", "hello world"}},
+	{"words...", "usage: prog words ...", []string{"a", "b"},
+		[]interface{}{"a", "b"}},
+	{"src... dest:path", "usage: prog [src ...] dest", []string{"a", "b", "c"},
+		[]interface{}{"a", "b", "c"}},
+}
+
+func TestFromSpec(t *testing.T) {
+	for i, test := range specTests {
+		p := New()
+		p.SetName("prog")
+		p.FromSpec(test.spec)
+		usage := p.usageMessage()
+		values, err := p.Parse(test.args)
+		if usage != test.usage || err != nil ||
+			!reflect.DeepEqual(values, test.values) {
+			t.Errorf("%d. FromSpec(%q)\nusage %q, Parse(%q) = %v, %v\n"+
+				"expected %q and %v", i, test.spec, usage, test.args, values,
+				err, test.usage, test.values)
+		}
+	}
+}
+
+var badSpecTests = []struct {
+	spec string
+	err  string
+}{
+	{"", "no arguments"},
+	{":int", "missing name"},
+	{"n:integer", `unknown type "integer"`},
+	{"a... b...", "only one argument can be repeated"},
+	{"a...=x", "cannot be optional"},
+	{"a?=x", `both "?" and a default`},
+	{"f:file?", `type "file" needs a default`},
+}
+
+func TestFromSpecInvalid(t *testing.T) {
+	for i, test := range badSpecTests {
+		func() {
+			defer func() {
+				msg, _ := recover().(string)
+				if !strings.Contains(msg, test.err) {
+					t.Errorf("%d. FromSpec(%q) panicked with %q\n"+
+						"expected %q", i, test.spec, msg, test.err)
+				}
+			}()
+			New().FromSpec(test.spec)
+		}()
+	}
+}
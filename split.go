@@ -0,0 +1,188 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"log"
+	"os"
+	"sync"
+)
+
+// minChunkSize is the smallest chunk worth giving to a separate worker.
+const minChunkSize = 1 << 20
+
+// SetSplitFiles enables or disables splitting a single large input file into
+// chunks that are processed in parallel. It applies in files mode (see
+// SetFilesMode) when there is exactly one file and the job limit (see SetJobs)
+// is greater than 1. The chunks begin and end on line boundaries, and error
+// messages have the same line numbers as they would otherwise, but fn is
+// called concurrently and in no particular order.
+//
+// Splitting only happens for regular, uncompressed files in the Shell, CSV,
+// TSV, JSONLines, and FixedWidth formats, and not when there is a header (see
+// SetHeader), or with a custom Splitter. Since chunks are split at newlines, it
+// must not be used if a record can span multiple lines, as with quoted or
+// escaped newlines.
+//
+// Deprecated: Use Program.SetSplitFiles instead.
+func SetSplitFiles(on bool) {
+	std.SetSplitFiles(on)
+}
+
+// SetSplitFiles is like the package-level SetSplitFiles, but for p.
+func (p *Program) SetSplitFiles(on bool) {
+	p.splitFiles = on
+}
+
+// canSplit returns true if the current input format can be split into chunks
+// on line boundaries.
+func (p *Program) canSplit() bool {
+	if p.headerMode != NoHeader || p.splitter != nil {
+		return false
+	}
+	switch p.inputFormat {
+	case Shell, CSV, TSV, JSONLines, FixedWidth:
+		return true
+	}
+	return false
+}
+
+// A chunk is a range of a file that begins at the start of a line.
+type chunk struct {
+	offset, size int64
+	line         int // number of the first line in the chunk
+}
+
+// mapSplit processes the file called name in chunks in parallel. If the file
+// cannot be split, it returns ok = false without processing anything.
+// Otherwise, success is true if all the lines were parsed successfully.
+func (r *run) mapSplit(fn func([]interface{}), name string) (success,
+	ok bool) {
+	if !r.canSplit() || name == "-" || isURL(name) {
+		return false, false
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return false, false
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || !info.Mode().IsRegular() || info.Size() < 2*minChunkSize {
+		return false, false
+	}
+	var magic [2]byte
+	if _, err := f.ReadAt(magic[:], 0); err != nil ||
+		magic[0] == 0x1f && magic[1] == 0x8b {
+		return false, false
+	}
+	chunks, err := splitChunks(f, info.Size(), r.jobLimit())
+	if err != nil {
+		return false, false
+	}
+	r.reportInput(name)
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	success = true
+	for _, c := range chunks {
+		wg.Add(1)
+		go func(c chunk) {
+			defer wg.Done()
+			l := log.New(r.diagnostics, r.name+": "+name+":", 0)
+			in := io.NewSectionReader(f, c.offset, c.size)
+			if !r.mapReader(fn, in, l, c.line) {
+				mu.Lock()
+				success = false
+				mu.Unlock()
+			}
+		}(c)
+	}
+	wg.Wait()
+	return success, true
+}
+
+// splitChunks divides the first size bytes of r into at most n chunks of about
+// the same size, each beginning at the start of a line, and numbers their first
+// lines by counting the newlines in each one.
+func splitChunks(r io.ReaderAt, size int64, n int) ([]chunk, error) {
+	if max := size / minChunkSize; int64(n) > max {
+		n = int(max)
+	}
+	var chunks []chunk
+	var offset int64
+	for i := 1; i <= n && offset < size; i++ {
+		end := size
+		if i < n {
+			var err error
+			end, err = nextLine(r, size, size*int64(i)/int64(n))
+			if err != nil {
+				return nil, err
+			}
+		}
+		if end > offset {
+			chunks = append(chunks, chunk{offset: offset, size: end - offset})
+			offset = end
+		}
+	}
+	counts := make([]int, len(chunks))
+	errs := make([]error, len(chunks))
+	var wg sync.WaitGroup
+	for i, c := range chunks {
+		wg.Add(1)
+		go func(i int, c chunk) {
+			defer wg.Done()
+			counts[i], errs[i] = countLines(io.NewSectionReader(r, c.offset,
+				c.size))
+		}(i, c)
+	}
+	wg.Wait()
+	line := 1
+	for i := range chunks {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		chunks[i].line = line
+		line += counts[i]
+	}
+	return chunks, nil
+}
+
+// nextLine returns the offset of the start of the first line that begins at or
+// after offset, or size if there is none.
+func nextLine(r io.ReaderAt, size, offset int64) (int64, error) {
+	if offset == 0 {
+		return 0, nil
+	}
+	// Start one byte early, in case offset is already the start of a line.
+	br := bufio.NewReader(io.NewSectionReader(r, offset-1, size-offset+1))
+	skipped, err := br.ReadSlice('\n')
+	n := int64(len(skipped))
+	for err == bufio.ErrBufferFull {
+		skipped, err = br.ReadSlice('\n')
+		n += int64(len(skipped))
+	}
+	if err == io.EOF {
+		return size, nil
+	}
+	return offset - 1 + n, err
+}
+
+// countLines counts the newlines in r.
+func countLines(r io.Reader) (int, error) {
+	buf := make([]byte, 64*1024)
+	count := 0
+	for {
+		n, err := r.Read(buf)
+		count += bytes.Count(buf[:n], []byte{'\n'})
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+	}
+}
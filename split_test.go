@@ -0,0 +1,93 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// numberedLines returns n lines, each containing its own line number.
+func numberedLines(n int) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&b, "%d\n", i)
+	}
+	return b.String()
+}
+
+func TestSplitChunks(t *testing.T) {
+	data := numberedLines(500000)
+	chunks, err := splitChunks(strings.NewReader(data), int64(len(data)), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 3 {
+		t.Errorf("got %d chunks, expected 3", len(chunks))
+	}
+	var offset int64
+	for i, c := range chunks {
+		if c.offset != offset {
+			t.Errorf("%d. chunk starts at %d, expected %d", i, c.offset, offset)
+		}
+		offset += c.size
+		first, _, _ := strings.Cut(data[c.offset:], "\n")
+		if first != fmt.Sprint(c.line) {
+			t.Errorf("%d. chunk starts with line %q, numbered %d", i, first,
+				c.line)
+		}
+	}
+	if offset != int64(len(data)) {
+		t.Errorf("chunks end at %d, expected %d", offset, len(data))
+	}
+}
+
+func TestNextLine(t *testing.T) {
+	r := strings.NewReader("ab\ncd\nef")
+	for offset, expected := range []int64{0, 3, 3, 3, 6, 6, 6, 8, 8} {
+		if got, err := nextLine(r, 8, int64(offset)); got != expected ||
+			err != nil {
+			t.Errorf("nextLine(%d) = %d, %v\nexpected %d", offset, got, err,
+				expected)
+		}
+	}
+}
+
+func TestMapSplit(t *testing.T) {
+	defer func() {
+		SetSplitFiles(false)
+		SetJobs(1)
+		SetEveryParser(Parser{})
+		SetStreams(Streams{os.Stdout, os.Stderr, nil})
+	}()
+	const n = 400000
+	data := strings.Replace(numberedLines(n), "\n123456\n", "\nx\n", 1)
+	name := writeTemp(t, data)
+	var diags bytes.Buffer
+	SetStreams(Streams{os.Stdout, &diags, nil})
+	SetEveryParser(Int)
+	SetSplitFiles(true)
+	SetJobs(4)
+	var mu sync.Mutex
+	sum, calls := 0, 0
+	fn := func(args []interface{}) {
+		mu.Lock()
+		sum += args[0].(int)
+		calls++
+		mu.Unlock()
+	}
+	if std.newRun().mapFiles(fn, []string{name}) {
+		t.Error("mapFiles returned true, expected false")
+	}
+	if expected := n*(n+1)/2 - 123456; sum != expected || calls != n-1 {
+		t.Errorf("got sum %d from %d calls, expected %d from %d", sum, calls,
+			expected, n-1)
+	}
+	if s := diags.String(); !strings.Contains(s, name+":123456: ") {
+		t.Errorf("error %q does not have the right line number", s)
+	}
+}
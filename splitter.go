@@ -0,0 +1,57 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bufio"
+	"io"
+)
+
+// A Splitter divides input into records, which are then split into tokens
+// according to the input format. Its Split method has the same contract as
+// bufio.SplitFunc: it returns the number of bytes to advance the input, the
+// next record (without its terminator), and an error, if any.
+type Splitter interface {
+	Split(data []byte, atEOF bool) (advance int, record []byte, err error)
+}
+
+// SplitFunc is an adapter that allows ordinary functions, including bufio
+// split functions such as bufio.ScanLines, to be used as Splitters.
+type SplitFunc func(data []byte, atEOF bool) (int, []byte, error)
+
+// Split calls f(data, atEOF).
+func (f SplitFunc) Split(data []byte, atEOF bool) (int, []byte, error) {
+	return f(data, atEOF)
+}
+
+// QuotedLines is the default Splitter for the Shell format. It splits input
+// into lines, except that newlines inside single or double quotation marks do
+// not end a line. Newlines escaped with a backslash are removed before it sees
+// them, but only when it is installed by default, not by SetSplitter.
+var QuotedLines Splitter = SplitFunc(scanLines)
+
+// SetSplitter installs s to divide the input into records for all the formats
+// that read lines, in place of the default: QuotedLines for the Shell format,
+// and bufio.ScanLines for the others. For example, a Splitter that splits on
+// zero bytes could read the output of "find -print0". Calling SetSplitter(nil)
+// restores the defaults.
+//
+// Deprecated: Use Program.SetSplitter instead.
+func SetSplitter(s Splitter) {
+	std.SetSplitter(s)
+}
+
+// SetSplitter is like the package-level SetSplitter, but for p.
+func (p *Program) SetSplitter(s Splitter) {
+	p.splitter = s
+}
+
+// newScanner returns a bufio.Scanner that reads lines from r, or records if a
+// custom Splitter has been installed.
+func (p *Program) newScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	if p.splitter != nil {
+		scanner.Split(p.splitter.Split)
+	}
+	return scanner
+}
@@ -0,0 +1,53 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bufio"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// scanNull splits data on zero bytes.
+func scanNull(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+var splitterTests = []struct {
+	splitter Splitter
+	format   InputFormat
+	input    string
+	records  [][]string
+}{
+	{nil, Shell, "'a\nb' c\n", [][]string{{"a\nb", "c"}}},
+	{QuotedLines, Shell, "'a\nb' c\n", [][]string{{"a\nb", "c"}}},
+	{SplitFunc(bufio.ScanLines), Shell, "a b\nc\n",
+		[][]string{{"a", "b"}, {"c"}}},
+	{SplitFunc(scanNull), Shell, "a b\x00c\nd\x00", [][]string{{"a", "b"},
+		{"c", "d"}}},
+	{SplitFunc(scanNull), TSV, "a\tb\x00c", [][]string{{"a", "b"}, {"c"}}},
+}
+
+func TestSetSplitter(t *testing.T) {
+	defer func() {
+		SetSplitter(nil)
+		SetInputFormat(Shell)
+	}()
+	for i, test := range splitterTests {
+		SetSplitter(test.splitter)
+		SetInputFormat(test.format)
+		got := readRecords(t, newRecordReader(strings.NewReader(test.input)))
+		if !reflect.DeepEqual(got, test.records) {
+			t.Errorf("%d. reading %q\nreturned %q\nexpected %q", i,
+				test.input, got, test.records)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// SetStdinGuard sets whether parse reads all of standard input before passing
+// any of it to fn, when standard input is piped or redirected. Afterwards, it
+// replaces os.Stdin with the null device, so that subprocesses started by fn
+// with os.Stdin as their input, such as ssh or ffmpeg, cannot swallow the
+// remaining records, which would make the program stop after the first one. It
+// costs as much memory as the input and delays the first call to fn until the
+// input ends. It has no effect on interactive input. It is false by default.
+//
+// Deprecated: Use Program.SetStdinGuard instead.
+func SetStdinGuard(on bool) {
+	std.SetStdinGuard(on)
+}
+
+// SetStdinGuard is like the package-level SetStdinGuard, but for p.
+func (p *Program) SetStdinGuard(on bool) {
+	p.stdinGuard = on
+}
+
+// stdin returns a reader for standard input, which is stdinOverride if it is
+// set, or else opened by the Opener set by SetOpener if there is one.
+// Otherwise, if stdinGuard is true, it reads all of standard input first and
+// replaces os.Stdin with the null device.
+func (r *run) stdin() (io.Reader, error) {
+	if r.stdinOverride != nil {
+		return r.stdinOverride, nil
+	}
+	if r.opener != nil {
+		return r.opener("-")
+	}
+	if !r.stdinGuard || r.source == InteractiveSource {
+		return os.Stdin, nil
+	}
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, err
+	}
+	null, err := os.Open(os.DevNull)
+	if err != nil {
+		return nil, err
+	}
+	os.Stdin = null
+	return bytes.NewReader(data), nil
+}
+
+// SetInput makes the program read r instead of standard input, so that tests
+// can run the whole of Main in-process. Unlike standard input, r is never
+// treated as a terminal, so when there are no command-line arguments, Main
+// reads lines from it as if it were a pipe. If r is nil, which is the default,
+// standard input is used.
+//
+// Deprecated: Use Program.SetInput instead.
+func SetInput(r io.Reader) {
+	std.SetInput(r)
+}
+
+// SetInput is like the package-level SetInput, but for p.
+func (p *Program) SetInput(r io.Reader) {
+	p.stdinOverride = r
+}
+
+// SetStdinIsTerminal makes parse call fn to decide whether standard input is a
+// terminal, rather than asking the operating system. This decides whether Main
+// reads lines from standard input when there are no command-line arguments, and
+// whether it treats them as interactive (see Source). Programs can use it when
+// the usual detection gets it wrong, such as in daemons or CI systems that
+// leave standard input attached to something unusual, and tests can use it to
+// pretend that standard input is a terminal. Input set by SetInput is still
+// never a terminal. If fn is nil, which is the default, the operating system
+// is asked, unless the package is built with the parse_noterm tag.
+//
+// Deprecated: Use Program.SetStdinIsTerminal instead.
+func SetStdinIsTerminal(fn func() bool) {
+	std.SetStdinIsTerminal(fn)
+}
+
+// SetStdinIsTerminal is like the package-level SetStdinIsTerminal, but for p.
+func (p *Program) SetStdinIsTerminal(fn func() bool) {
+	p.isTerminal = fn
+}
+
+// stdinIsTerminal returns true if standard input is a terminal.
+func (p *Program) stdinIsTerminal() bool {
+	if p.stdinOverride != nil {
+		return false
+	}
+	if p.isTerminal != nil {
+		return p.isTerminal()
+	}
+	return termIsTerminal()
+}
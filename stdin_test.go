@@ -0,0 +1,137 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestStdinGuard(t *testing.T) {
+	defer func(f *os.File) {
+		if os.Stdin != f {
+			os.Stdin.Close()
+		}
+		os.Stdin = f
+		SetStdinGuard(false)
+	}(os.Stdin)
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	go func() {
+		w.Write([]byte("a\nb\n"))
+		w.Close()
+	}()
+	os.Stdin = r
+	SetStdinGuard(true)
+	run := std.newRun()
+	run.source = PipeSource
+	in, err := run.stdin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A subprocess reading os.Stdin now sees nothing.
+	if rest, err := io.ReadAll(os.Stdin); err != nil || len(rest) != 0 {
+		t.Errorf("os.Stdin has %q, %v; expected nothing", rest, err)
+	}
+	if data, _ := io.ReadAll(in); string(data) != "a\nb\n" {
+		t.Errorf("stdin() has %q, expected %q", data, "a\nb\n")
+	}
+}
+
+var inProcessTests = []struct {
+	args   []string
+	input  string
+	code   int
+	output string
+	errors string
+}{
+	{[]string{"1", "2"}, "", -1, "3\n", ""},
+	{[]string{}, "1 2\n3 4\n", -1, "3\n7\n", ""},
+	{[]string{}, "1 x\n2 2\n", 1, "4\n", "\"x\" is not a whole number\n"},
+	{[]string{"1"}, "", 1, "", "usage: "},
+}
+
+func TestInProcessMain(t *testing.T) {
+	defer func() {
+		SetArgs(nil)
+		SetInput(nil)
+		SetOutput(os.Stdout)
+		SetErrorOutput(os.Stderr)
+		SetEveryParser(Parser{})
+		SetNames()
+	}()
+	SetParsers(Int, Int)
+	SetNames("a", "b")
+	fn := func(args []interface{}) {
+		fmt.Fprintln(Output(), args[0].(int)+args[1].(int))
+	}
+	for i, test := range inProcessTests {
+		var out, errs bytes.Buffer
+		SetArgs(test.args)
+		SetInput(strings.NewReader(test.input))
+		SetOutput(&out)
+		SetErrorOutput(&errs)
+		code := catchExit(func() { Main(fn) })
+		if code != test.code || out.String() != test.output ||
+			!strings.Contains(errs.String(), test.errors) {
+			t.Errorf("%d. Main with %q and %q exited with %d and wrote %q "+
+				"and %q\nexpected %d, %q, and %q", i, test.args, test.input,
+				code, out.String(), errs.String(), test.code, test.output,
+				test.errors)
+		}
+	}
+}
+
+func TestSetStdinIsTerminal(t *testing.T) {
+	defer func() {
+		SetArgs(nil)
+		SetInput(nil)
+		SetErrorOutput(os.Stderr)
+		SetStdinIsTerminal(nil)
+	}()
+	terminal := true
+	SetStdinIsTerminal(func() bool { return terminal })
+	if !std.stdinIsTerminal() || std.stdinSource() != InteractiveSource {
+		t.Error("standard input is not a terminal")
+	}
+	var errs bytes.Buffer
+	SetErrorOutput(&errs)
+	SetArgs([]string{})
+	if code := catchExit(func() { Main(func([]interface{}) {}) }); code != 1 ||
+		!strings.Contains(errs.String(), "usage:") {
+		t.Errorf("Main exited with %d and wrote %q, expected the usage",
+			code, errs.String())
+	}
+	terminal = false
+	if std.stdinIsTerminal() {
+		t.Error("standard input is a terminal")
+	}
+	terminal = true
+	SetInput(strings.NewReader(""))
+	if std.stdinIsTerminal() {
+		t.Error("input set by SetInput is a terminal")
+	}
+}
+
+func TestProgramSetStdinIsTerminal(t *testing.T) {
+	p := New()
+	p.SetStdinIsTerminal(func() bool { return true })
+	if !p.stdinIsTerminal() {
+		t.Error("standard input of the program is not a terminal")
+	}
+	SetStdinIsTerminal(func() bool { return false })
+	defer SetStdinIsTerminal(nil)
+	if !p.stdinIsTerminal() {
+		t.Error("SetStdinIsTerminal changed another program")
+	}
+	if std.stdinIsTerminal() {
+		t.Error("standard input of the default program is a terminal")
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import "errors"
+
+// ErrStop is the value that Stop panics with to end the input early. A function
+// passed to HandleErrors can also return it to do the same.
+var ErrStop = errors.New("stop")
+
+// ErrSkip is the value that Skip panics with to ignore a line of input. A
+// function passed to HandleErrors can also return it to do the same.
+var ErrSkip = errors.New("skip")
+
+// Stop ends the processing of the program's input successfully, such as when
+// fn has found what it was looking for. It must be called from fn. It returns
+// from fn immediately by panicking with ErrStop, which parse recovers from, so
+// deferred calls in fn still run. No more lines are read, no more files are
+// started, and the program exits normally once the files being processed
+// concurrently (see SetJobs) reach the end of their current lines. Lines that
+// failed before Stop was called still make the exit status nonzero.
+func Stop() {
+	panic(ErrStop)
+}
+
+// Skip marks the line of input that fn is processing as intentionally ignored,
+// such as a comment or a record that does not apply. It must be called from
+// fn. Like Stop, it returns from fn immediately by panicking with ErrSkip,
+// which parse recovers from. Skipped lines never affect the exit status, and
+// they are counted separately from the ones that fail in the summary printed
+// at the Verbose level (see Verbosity).
+func Skip() {
+	panic(ErrSkip)
+}
+
+// A failure is the value that Fail panics with.
+type failure struct {
+	err error
+}
+
+// Fail marks the line of input that fn is processing as failed because of err,
+// such as an I/O error or a division by zero. It must be called from fn. Like
+// Stop, it returns from fn immediately by panicking, and parse recovers. The
+// error is printed with the name of the input and the line number, like a parse
+// error, and it counts toward the exit status in the same way: the program
+// exits with the runtime status (see SetExitCodes) once the input is processed,
+// or right away if SetKeepGoing is off. If err is nil, Fail does nothing.
+func Fail(err error) {
+	if err != nil {
+		panic(failure{err})
+	}
+}
+
+// HandleErrors returns a function to pass to Main in place of fn, for programs
+// that would rather return errors than call Fail. When fn returns a non-nil
+// error, it calls Fail with it, except that ErrStop and ErrSkip, or errors
+// wrapping them, have the effect of calling Stop and Skip.
+func HandleErrors(fn func([]interface{}) error) func([]interface{}) {
+	return func(args []interface{}) {
+		err := fn(args)
+		switch {
+		case errors.Is(err, ErrStop):
+			Stop()
+		case errors.Is(err, ErrSkip):
+			Skip()
+		}
+		Fail(err)
+	}
+}
+
+// callFn calls fn with args, recovering from a call to Stop, Skip, or Fail. It
+// returns true if fn called Skip, and the error that fn passed to Fail. A call
+// to Stop stops r.
+func (r *run) callFn(fn func([]interface{}), args []interface{}) (skipped bool,
+	err error) {
+	defer r.calling()()
+	defer func() {
+		if v := recover(); v != nil {
+			switch v {
+			case ErrStop:
+				r.stopped.Store(true)
+			case ErrSkip:
+				skipped = true
+			default:
+				f, ok := v.(failure)
+				if !ok {
+					panic(v)
+				}
+				err = f.err
+			}
+		}
+	}()
+	fn(args)
+	return false, nil
+}
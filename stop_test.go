@@ -0,0 +1,171 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStop(t *testing.T) {
+	defer SetEveryParser(Parser{})
+	SetEveryParser(Int)
+	var got []int
+	deferred := 0
+	fn := func(args []interface{}) {
+		defer func() { deferred++ }()
+		got = append(got, args[0].(int))
+		if args[0] == 2 {
+			Stop()
+		}
+		got = append(got, -1)
+	}
+	input := strings.NewReader("1\n2\n3\n4\n")
+	if !std.newRun().mapReader(fn, input, log.New(io.Discard, "", 0), 0) {
+		t.Error("mapReader returned false after Stop")
+	}
+	if expected := []int{1, -1, 2}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("fn saw %v, expected %v", got, expected)
+	}
+	if deferred != 2 {
+		t.Errorf("deferred calls ran %d times, expected 2", deferred)
+	}
+}
+
+func TestStopFiles(t *testing.T) {
+	defer SetEveryParser(Parser{})
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a")
+	b := filepath.Join(dir, "b")
+	os.WriteFile(a, []byte("1\n2\n"), 0600)
+	os.WriteFile(b, []byte("3\n"), 0600)
+	SetEveryParser(Int)
+	var got []interface{}
+	fn := func(args []interface{}) {
+		got = append(got, args...)
+		Stop()
+	}
+	if !std.newRun().mapFiles(fn, []string{a, b}) {
+		t.Error("mapFiles returned false after Stop")
+	}
+	if expected := []interface{}{1}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("fn saw %v, expected %v", got, expected)
+	}
+}
+
+func TestOtherPanicsPropagate(t *testing.T) {
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Errorf("recovered %v, expected boom", r)
+		}
+	}()
+	std.newRun().callFn(func([]interface{}) { panic("boom") }, nil)
+}
+
+func TestSkip(t *testing.T) {
+	defer SetEveryParser(Parser{})
+	SetEveryParser(Int)
+	var got []interface{}
+	fn := func(args []interface{}) {
+		if args[0].(int) < 0 {
+			Skip()
+		}
+		got = append(got, args...)
+	}
+	res := std.newRun().benchmark(fn, []byte("1\n-2\nx\n-4\n5\n"))
+	if expected := []interface{}{1, 5}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("fn saw %v, expected %v", got, expected)
+	}
+	if res.lines != 5 || res.failed != 1 || res.skipped != 2 {
+		t.Errorf("benchmark counted %+v", res)
+	}
+	got = nil
+	r := std.newRun()
+	input := strings.NewReader("-1\n2\n")
+	if !r.mapReader(fn, input, log.New(io.Discard, "", 0), 0) {
+		t.Error("mapReader returned false for skipped lines")
+	}
+	if !strings.Contains(r.summary(), "1 skipped") {
+		t.Errorf("summary %q does not count skipped lines", r.summary())
+	}
+}
+
+func TestHandleErrors(t *testing.T) {
+	defer func(k bool) {
+		SetEveryParser(Parser{})
+		SetKeepGoing(k)
+	}(std.keepGoing)
+	SetEveryParser(Int)
+	SetKeepGoing(true)
+	var got []interface{}
+	fn := HandleErrors(func(args []interface{}) error {
+		switch n := args[0].(int); {
+		case n == 0:
+			return errors.New("division by zero")
+		case n < 0:
+			return ErrSkip
+		case n > 100:
+			return fmt.Errorf("too big: %w", ErrStop)
+		}
+		got = append(got, args[0])
+		return nil
+	})
+	var diags bytes.Buffer
+	l := log.New(&diags, "in:", 0)
+	input := strings.NewReader("1\n0\n-3\n4\n101\n5\n")
+	if std.newRun().mapReader(fn, input, l, 1) {
+		t.Error("mapReader returned true after an error")
+	}
+	if expected := []interface{}{1, 4}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("fn saw %v, expected %v", got, expected)
+	}
+	if s := diags.String(); s != "in:2: division by zero\n" {
+		t.Errorf("diagnostics were %q", s)
+	}
+}
+
+func TestFailArgs(t *testing.T) {
+	defer SetEveryParser(Parser{})
+	SetEveryParser(Int)
+	var diags bytes.Buffer
+	log.SetOutput(&diags)
+	defer log.SetOutput(os.Stderr)
+	fn := func([]interface{}) { Fail(errors.New("no space left")) }
+	if std.newRun().apply(fn, []string{"1"}) {
+		t.Error("apply returned true after Fail")
+	}
+	if !strings.Contains(diags.String(), "no space left") {
+		t.Errorf("diagnostics were %q", diags.String())
+	}
+	if !std.newRun().apply(func([]interface{}) { Fail(nil) }, []string{"1"}) {
+		t.Error("apply returned false after Fail(nil)")
+	}
+}
+
+func TestStopEachRun(t *testing.T) {
+	calls := 0
+	fn := func(args []interface{}) {
+		calls++
+		if calls == 1 {
+			Stop()
+		}
+	}
+	for _, expected := range []int{1, 4} {
+		_, err := Run(fn,
+			WithEveryParser(Int),
+			WithArgs([]string{}),
+			WithInput(strings.NewReader("1\n2\n3\n")))
+		if err != nil || calls != expected {
+			t.Errorf("Run returned %v after %d calls, expected %d", err,
+				calls, expected)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Streams holds the destinations of the program's three kinds of output. A
+// nil writer discards that kind of output.
+type Streams struct {
+	// Results receives the output of fn, which should write to Output rather
+	// than directly to standard output.
+	Results io.Writer
+	// Diagnostics receives error messages, the usage message when it is
+	// printed because of an error, and verbose tracing.
+	Diagnostics io.Writer
+	// Rejects receives each line of input that fails, exactly as it was read,
+	// so that it can be corrected and fed back to the program. Records that do
+	// not come from lines, such as those in the CSV and Binary formats, are
+	// written in the CSV format or tokenized like a line of standard input.
+	Rejects io.Writer
+}
+
+// rejectsMutex serializes writes to rejects, since Listen and HTTPHandler can
+// process lines concurrently.
+var rejectsMutex sync.Mutex
+
+// Output returns the writer that fn should write its results to. It is
+// standard output unless changed by SetStreams, except inside HTTPHandler,
+// where it is the response, and Listen, where it is the connection. Called
+// from fn, it returns the writer for the run that called fn, even when several
+// programs run at the same time. Goroutines started by fn get the writer of
+// the default program's latest run instead, so they should use the one that fn
+// got from Output, or the Output method of the program.
+func Output() io.Writer {
+	return active().output
+}
+
+// Output is like the package-level Output, but it returns the writer for the
+// run of p that is calling fn, or the latest run of p if fn is not being
+// called on this goroutine, or the one set for p if p is not running.
+func (p *Program) Output() io.Writer {
+	if r := p.current(); r != nil {
+		return r.output
+	}
+	return p.output
+}
+
+// CurrentStreams returns the program's current output streams.
+func CurrentStreams() Streams {
+	p := active()
+	return Streams{p.output, p.diagnostics, p.rejects}
+}
+
+// SetStreams sets the destinations of the program's output. By default, results
+// go to standard output, diagnostics go to standard error, and rejects are
+// discarded. Since diagnostics are printed using the log package, SetStreams
+// also changes the output of the standard logger.
+//
+// Deprecated: Use Program.SetStreams instead.
+func SetStreams(s Streams) {
+	std.SetStreams(s)
+	log.SetOutput(std.diagnostics)
+}
+
+// SetStreams is like the package-level SetStreams, but for p. Unless p is the
+// default program, it leaves the standard logger alone, and p prints its
+// errors using a logger of its own.
+func (p *Program) SetStreams(s Streams) {
+	p.output = orDiscard(s.Results)
+	p.diagnostics = orDiscard(s.Diagnostics)
+	p.rejects = s.Rejects
+}
+
+// SetOutput sets the writer that fn should write its results to, which is
+// returned by Output. It is like SetStreams, but it changes only the Results
+// stream. A nil writer discards the results.
+//
+// Deprecated: Use Program.SetOutput instead.
+func SetOutput(w io.Writer) {
+	std.SetOutput(w)
+}
+
+// SetOutput is like the package-level SetOutput, but for p.
+func (p *Program) SetOutput(w io.Writer) {
+	p.output = orDiscard(w)
+}
+
+// SetErrorOutput sets the writer that errors and the usage message are printed
+// to, including by the standard logger. It is like SetStreams, but it changes
+// only the Diagnostics stream. A nil writer discards them.
+//
+// Deprecated: Use Program.SetErrorOutput instead.
+func SetErrorOutput(w io.Writer) {
+	std.SetErrorOutput(w)
+	log.SetOutput(std.diagnostics)
+}
+
+// SetErrorOutput is like the package-level SetErrorOutput, but for p. Like
+// p.SetStreams, it leaves the standard logger alone.
+func (p *Program) SetErrorOutput(w io.Writer) {
+	p.diagnostics = orDiscard(w)
+}
+
+// SetDeterministic makes the program's output depend only on its input, so that
+// projects can compare the output of their tools with golden files byte for
+// byte. It processes files one at a time regardless of SetJobs, so that their
+// results are not interleaved; it turns off the line editor and its terminal
+// escape codes (see SetLineEditing); and it leaves out the timing and memory
+// measurements printed by the hidden built-in flag "--bench". It is false by
+// default.
+//
+// Deprecated: Use Program.SetDeterministic instead.
+func SetDeterministic(on bool) {
+	std.SetDeterministic(on)
+}
+
+// SetDeterministic is like the package-level SetDeterministic, but for p.
+func (p *Program) SetDeterministic(on bool) {
+	p.deterministic = on
+}
+
+// SetOutputPrefix makes every line that fn writes to Output start with the
+// given prefix when processing lines of input, to make it easy to correlate
+// the output with the input, like "sed =" and "grep -n" do. In the prefix,
+// "{line}" stands for the line number of the input. For example,
+// SetOutputPrefix("{line}: ") makes the output for the third line start with
+// "3: ". There is no prefix by default. While the prefix is in effect, calls
+// to fn are not concurrent, even with SetJobs.
+//
+// Deprecated: Use Program.SetOutputPrefix instead.
+func SetOutputPrefix(template string) {
+	std.SetOutputPrefix(template)
+}
+
+// SetOutputPrefix is like the package-level SetOutputPrefix, but for p.
+func (p *Program) SetOutputPrefix(template string) {
+	p.outputPrefix = template
+}
+
+// prefixMutex serializes calls to fn from withOutputPrefix, which changes the
+// writer returned by Output while fn runs.
+var prefixMutex sync.Mutex
+
+// withOutputPrefix returns a function that calls fn with Output changed to add
+// the prefix for line n to each line of output.
+func (r *run) withOutputPrefix(fn func([]interface{}),
+	n int) func([]interface{}) {
+	prefix := strings.ReplaceAll(r.outputPrefix, "{line}", strconv.Itoa(n))
+	return func(args []interface{}) {
+		prefixMutex.Lock()
+		defer prefixMutex.Unlock()
+		defer func(w io.Writer) { r.output = w }(r.output)
+		r.output = &prefixWriter{w: r.output, prefix: []byte(prefix),
+			start: true}
+		fn(args)
+	}
+}
+
+// A prefixWriter writes prefix to w at the start of each line.
+type prefixWriter struct {
+	w      io.Writer
+	prefix []byte
+	start  bool // whether the next byte starts a line
+}
+
+func (p *prefixWriter) Write(data []byte) (int, error) {
+	var buf []byte
+	for _, c := range data {
+		if p.start {
+			buf = append(buf, p.prefix...)
+		}
+		buf = append(buf, c)
+		p.start = c == '\n'
+	}
+	if _, err := p.w.Write(buf); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// orDiscard returns w, or io.Discard if w is nil.
+func orDiscard(w io.Writer) io.Writer {
+	if w == nil {
+		return io.Discard
+	}
+	return w
+}
+
+// writeReject copies the line that rec came from to rejects, if it is set.
+func (p *Program) writeReject(rec record) {
+	if p.rejects == nil {
+		return
+	}
+	line := p.formatRecord(rec)
+	rejectsMutex.Lock()
+	defer rejectsMutex.Unlock()
+	p.rejects.Write(line)
+}
+
+// formatRecord returns the line that rec came from, including the newline. For
+// records that do not come from lines, it returns the tokens in the CSV format
+// or escaped like a line of standard input.
+func (p *Program) formatRecord(rec record) []byte {
+	var buf bytes.Buffer
+	switch {
+	case rec.raw != nil:
+		buf.Write(rec.raw)
+		buf.WriteByte('\n')
+	case p.inputFormat == CSV:
+		w := csv.NewWriter(&buf)
+		w.Write(rec.tokens)
+		w.Flush()
+	default:
+		quoted := make([]string, len(rec.tokens))
+		for i, t := range rec.tokens {
+			quoted[i] = escapeArg(t)
+			if t == "" {
+				quoted[i] = "''"
+			}
+		}
+		buf.WriteString(strings.Join(quoted, " ") + "\n")
+	}
+	return buf.Bytes()
+}
+
+// keepRaw returns true if records need to keep the lines they came from.
+func (p *Program) keepRaw() bool {
+	return p.rejects != nil || p.tee != nil || p.recorder.on() ||
+		p.minimizeMode
+}
+
+// teeMutex serializes writes to tee, since files can be read concurrently.
+var teeMutex sync.Mutex
+
+// SetTee makes the program copy every record of its input to w as it is read,
+// before it is tokenized or parsed, so that tools can archive exactly what
+// they consumed. Records are copied whether or not they parse successfully,
+// in the same form as they are written to the rejects stream (see SetStreams).
+// A nil w turns this off, which is the default.
+//
+// Deprecated: Use Program.SetTee instead.
+func SetTee(w io.Writer) {
+	std.SetTee(w)
+}
+
+// SetTee is like the package-level SetTee, but for p.
+func (p *Program) SetTee(w io.Writer) {
+	p.tee = w
+}
+
+// teeRecords copies the records from source to the tee of p.
+type teeRecords struct {
+	p      *Program
+	source recordReader
+}
+
+func (r teeRecords) next() (record, error) {
+	rec, err := r.source.next()
+	if err == nil {
+		line := r.p.formatRecord(rec)
+		teeMutex.Lock()
+		r.p.tee.Write(line)
+		teeMutex.Unlock()
+	}
+	return rec, err
+}
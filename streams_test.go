@@ -0,0 +1,129 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStreams(t *testing.T) {
+	defer func() {
+		SetStreams(Streams{os.Stdout, os.Stderr, nil})
+		SetEveryParser(Parser{})
+		log.SetPrefix(std.name + ": ")
+	}()
+	var results, diags, rejected bytes.Buffer
+	SetStreams(Streams{&results, &diags, &rejected})
+	log.SetPrefix("")
+	SetEveryParser(Int)
+	fn := func(args []interface{}) { fmt.Fprintln(Output(), args...) }
+	input := "1 2\n3 x\n  'a b'  \n4\n"
+	if std.newRun().mapReader(fn, strings.NewReader(input), log.Default(), 0) {
+		t.Error("mapReader returned true, expected false")
+	}
+	if s := results.String(); s != "1 2\n4\n" {
+		t.Errorf("results were %q", s)
+	}
+	if s := diags.String(); !strings.Contains(s, `"x" is not`) {
+		t.Errorf("diagnostics were %q", s)
+	}
+	if s := rejected.String(); s != "3 x\n  'a b'  \n" {
+		t.Errorf("rejects were %q", s)
+	}
+}
+
+func TestRejectsWithoutLines(t *testing.T) {
+	defer func() {
+		SetStreams(Streams{os.Stdout, os.Stderr, nil})
+		SetInputFormat(Shell)
+	}()
+	var rejected bytes.Buffer
+	SetStreams(Streams{nil, nil, &rejected})
+	SetInputFormat(CSV)
+	std.writeReject(record{tokens: []string{"a,b", "c"}})
+	SetInputFormat(MessagePack)
+	std.writeReject(record{tokens: []string{"a b", ""}})
+	if s := rejected.String(); s != "\"a,b\",c\na\\ b ''\n" {
+		t.Errorf("rejects were %q", s)
+	}
+}
+
+func TestDeterministic(t *testing.T) {
+	defer func() {
+		SetDeterministic(false)
+		SetJobs(1)
+	}()
+	SetJobs(8)
+	SetDeterministic(true)
+	if n := std.jobLimit(); n != 1 {
+		t.Errorf("jobLimit() = %d, expected 1", n)
+	}
+	r := benchResult{lines: 4, failed: 1, bytes: 20, elapsed: time.Second,
+		allocs: 10, allocMem: 100, exact: std.deterministic}
+	if s := r.String(); s != "4 lines (1 failed, 0 skipped), 20 bytes\n" {
+		t.Errorf("benchResult.String() = %q", s)
+	}
+}
+
+func TestOutputPrefix(t *testing.T) {
+	defer func() {
+		SetEveryParser(Parser{})
+		SetOutputPrefix("")
+		SetStreams(Streams{os.Stdout, os.Stderr, nil})
+	}()
+	var out bytes.Buffer
+	SetStreams(Streams{&out, io.Discard, nil})
+	SetEveryParser(Int)
+	SetOutputPrefix("{line}: ")
+	fn := func(args []interface{}) {
+		fmt.Fprint(Output(), "a")
+		fmt.Fprintf(Output(), "b\n%d\n", args[0])
+	}
+	r := std.newRun()
+	defer r.enter()()
+	l := log.New(io.Discard, "", 0)
+	r.mapReader(fn, strings.NewReader("7\nx\n9\n"), l, 0)
+	if expected := "1: ab\n1: 7\n3: ab\n3: 9\n"; out.String() != expected {
+		t.Errorf("output is %q, expected %q", out.String(), expected)
+	}
+	out.Reset()
+	r.mapReader(fn, strings.NewReader("7\n"), l, 10)
+	if expected := "10: ab\n10: 7\n"; out.String() != expected {
+		t.Errorf("output is %q, expected %q", out.String(), expected)
+	}
+}
+
+func TestTee(t *testing.T) {
+	defer func() {
+		SetEveryParser(Parser{})
+		SetTee(nil)
+		SetInputFormat(Shell)
+	}()
+	var tee bytes.Buffer
+	SetTee(&tee)
+	SetEveryParser(Int)
+	var got []int
+	fn := func(args []interface{}) { got = append(got, AssertInts(args)...) }
+	input := "1  '2'\nx\n\"3\"\n"
+	l := log.New(io.Discard, "", 0)
+	std.newRun().mapReader(fn, strings.NewReader(input), l, 0)
+	if tee.String() != input {
+		t.Errorf("tee received %q, expected %q", tee.String(), input)
+	}
+	if len(got) != 3 || got[2] != 3 {
+		t.Errorf("fn received %v", got)
+	}
+	tee.Reset()
+	SetInputFormat(CSV)
+	std.newRun().mapReader(fn, strings.NewReader("4,\"5\"\n"), l, 0)
+	if expected := "4,5\n"; tee.String() != expected {
+		t.Errorf("tee received %q, expected %q", tee.String(), expected)
+	}
+}
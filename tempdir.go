@@ -0,0 +1,95 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// tempDir is the directory created by TempDir.
+var tempDir struct {
+	sync.Mutex
+	path string
+	once sync.Once // installs the signal handler
+}
+
+// TempDir returns the path of a temporary directory for the current run of the
+// program, creating it the first time it is called. Unlike a directory created
+// and removed with a deferred call in fn, it is removed on every path out of
+// the program: when Main returns, when parse exits because of invalid arguments
+// or failed input, and when the program is interrupted or terminated by a
+// signal, in which case it calls the handlers registered with OnExit and exits
+// with status 130 for an interrupt or 143 for termination, as shells do. Run
+// and Invoke stop processing the input and return that status instead. The
+// directory belongs to the process rather than to a Program, so programs that
+// run at the same time share it, and it is removed when the last of them ends.
+// Since it is shared by all calls to fn, which can be concurrent, files in it
+// should have unique names.
+func TempDir() (string, error) {
+	tempDir.Lock()
+	defer tempDir.Unlock()
+	if tempDir.path != "" {
+		return tempDir.path, nil
+	}
+	path, err := os.MkdirTemp("", active().name+"-")
+	if err != nil {
+		return "", err
+	}
+	tempDir.path = path
+	tempDir.once.Do(exitOnSignal)
+	return path, nil
+}
+
+// exitOnSignal makes the program exit using exit when it is interrupted or
+// terminated by a signal. The signal is delivered to every run of Run or Invoke
+// in progress instead: each stops like it would for Stop, and then returns the
+// exit status for the signal after cleaning up. The program still exits if a
+// run of Main or one of the functions like it is in progress.
+func exitOnSignal() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		for sig := range signals {
+			code := 128 + 15
+			if sig == os.Interrupt {
+				code = 128 + 2
+			}
+			live := liveRuns()
+			var main *run
+			for _, r := range live {
+				if r.returning {
+					r.signalled.Store(int32(code))
+				} else {
+					main = r
+				}
+			}
+			switch {
+			case main != nil:
+				main.exit(code)
+			case live == nil:
+				exit(code)
+			}
+		}
+	}()
+}
+
+// exitIfSignalled exits with the status for the signal that interrupted Run or
+// Invoke, if there was one.
+func (r *run) exitIfSignalled() {
+	if code := r.signalled.Load(); code != 0 {
+		r.exit(int(code))
+	}
+}
+
+// removeTempDir removes the directory created by TempDir, if there is one.
+func removeTempDir() {
+	tempDir.Lock()
+	defer tempDir.Unlock()
+	if tempDir.path != "" {
+		os.RemoveAll(tempDir.path)
+		tempDir.path = ""
+	}
+}
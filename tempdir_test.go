@@ -0,0 +1,97 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestTempDir(t *testing.T) {
+	dir, err := TempDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := TempDir(); again != dir {
+		t.Errorf("TempDir() = %q, then %q", dir, again)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		t.Fatalf("%q is not a directory: %v", dir, err)
+	}
+	if code := catchExit(func() { exit(3) }); code != 3 {
+		t.Errorf("exit status %d, expected 3", code)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("%q still exists after exit: %v", dir, err)
+	}
+}
+
+func TestTempDirSignal(t *testing.T) {
+	defer func(old func(int)) { osExit = old }(osExit)
+	codes := make(chan int, 1)
+	osExit = func(code int) { codes <- code }
+	dir, err := TempDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, _ := os.FindProcess(os.Getpid())
+	if err := p.Signal(syscall.SIGTERM); err != nil {
+		removeTempDir()
+		t.Skip("cannot send SIGTERM:", err)
+	}
+	select {
+	case code := <-codes:
+		if code != 143 {
+			t.Errorf("exit status %d, expected 143", code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("program did not exit after SIGTERM")
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("%q still exists after SIGTERM: %v", dir, err)
+	}
+}
+
+func TestTempDirSignalRun(t *testing.T) {
+	defer func(old func(int)) { osExit = old }(osExit)
+	osExit = func(code int) { t.Fatalf("exited with status %d", code) }
+	dir := ""
+	calls := 0
+	fn := func([]interface{}) {
+		calls++
+		if calls > 1 {
+			return
+		}
+		var err error
+		if dir, err = TempDir(); err != nil {
+			t.Fatal(err)
+		}
+		p, _ := os.FindProcess(os.Getpid())
+		if err := p.Signal(os.Interrupt); err != nil {
+			t.Skip("cannot send SIGINT:", err)
+		}
+		r := activeRun()
+		for i := 0; i < 500 && r.signalled.Load() == 0; i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	code, err := Run(fn,
+		WithArgs([]string{}),
+		WithInput(strings.NewReader(strings.Repeat("x\n", 1000))))
+	if code != 130 || err == nil {
+		t.Errorf("Run returned %d, %v; expected status 130", code, err)
+	}
+	if calls != 1 {
+		t.Errorf("fn was called %d times after the signal", calls-1)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("%q still exists after SIGINT: %v", dir, err)
+	}
+	code, err = Run(func([]interface{}) {}, WithArgs([]string{"x"}))
+	if code != 0 || err != nil {
+		t.Errorf("the next Run returned %d, %v; expected 0", code, err)
+	}
+}
@@ -0,0 +1,25 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+//go:build !parse_noterm
+
+package parse
+
+import "github.com/kless/term"
+
+// termIsTerminal returns true if standard input is a terminal.
+func termIsTerminal() bool {
+	return term.IsTerminal(term.InputFD)
+}
+
+// rawMode puts the terminal in raw mode and returns a function that restores
+// its previous state.
+func rawMode() (func(), error) {
+	t, err := term.New()
+	if err != nil {
+		return nil, err
+	}
+	if err := t.RawMode(); err != nil {
+		return nil, err
+	}
+	return func() { t.Restore() }, nil
+}
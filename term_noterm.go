@@ -0,0 +1,27 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+//go:build parse_noterm
+
+package parse
+
+import "errors"
+
+// notermAssume is what standard input is assumed to be when the package is
+// built with the parse_noterm tag, which leaves out terminal detection for
+// platforms where it does not build, such as js/wasm and plan9. It is "pipe"
+// by default, so that input is never treated as interactive. It can be changed
+// to "terminal" when linking:
+//
+//	go build -tags parse_noterm \
+//		-ldflags "-X github.com/mk12/parse.notermAssume=terminal"
+var notermAssume = "pipe"
+
+// termIsTerminal returns true if notermAssume is "terminal".
+func termIsTerminal() bool {
+	return notermAssume == "terminal"
+}
+
+// rawMode always fails, so the line editor reads lines without raw mode.
+func rawMode() (func(), error) {
+	return nil, errors.New("terminal support is not built in")
+}
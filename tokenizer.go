@@ -0,0 +1,99 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"regexp"
+	"strings"
+)
+
+// A Tokenizer splits a record of input in the Shell format into tokens. The
+// record does not include its terminating newline. Tokenize may modify the
+// record, but it must not retain it. If it returns an error, the record fails
+// like one with a parse error.
+type Tokenizer interface {
+	Tokenize(record []byte) ([]string, error)
+}
+
+// TokenizerFunc is an adapter that allows ordinary functions to be used as
+// Tokenizers.
+type TokenizerFunc func(record []byte) ([]string, error)
+
+// Tokenize calls f(record).
+func (f TokenizerFunc) Tokenize(record []byte) ([]string, error) {
+	return f(record)
+}
+
+// ShellTokenizer is the default Tokenizer. It splits on whitespace, except
+// where it is escaped with a backslash or enclosed in quotation marks, and it
+// removes the backslashes and quotation marks, similar to shells.
+var ShellTokenizer Tokenizer = TokenizerFunc(
+	func(record []byte) ([]string, error) {
+		return tokenize(record).strings(), nil
+	})
+
+// FieldsTokenizer splits on whitespace using strings.Fields. It gives no
+// special meaning to backslashes or quotation marks.
+var FieldsTokenizer Tokenizer = TokenizerFunc(
+	func(record []byte) ([]string, error) {
+		return strings.Fields(string(record)), nil
+	})
+
+// DelimiterTokenizer returns a Tokenizer that splits around each instance of
+// sep, like strings.Split. An empty record has no tokens.
+func DelimiterTokenizer(sep string) Tokenizer {
+	return TokenizerFunc(func(record []byte) ([]string, error) {
+		if len(record) == 0 {
+			return []string{}, nil
+		}
+		return strings.Split(string(record), sep), nil
+	})
+}
+
+// RegexpTokenizer returns a Tokenizer that splits around the matches of re,
+// like re.Split. Empty tokens at the beginning and end are dropped, so that
+// RegexpTokenizer(regexp.MustCompile(`[\s,]+`)) handles leading and trailing
+// separators like strings.Fields.
+func RegexpTokenizer(re *regexp.Regexp) Tokenizer {
+	return TokenizerFunc(func(record []byte) ([]string, error) {
+		tokens := re.Split(string(record), -1)
+		if len(tokens) > 0 && tokens[0] == "" {
+			tokens = tokens[1:]
+		}
+		if len(tokens) > 0 && tokens[len(tokens)-1] == "" {
+			tokens = tokens[:len(tokens)-1]
+		}
+		return tokens, nil
+	})
+}
+
+// MatchTokenizer returns a Tokenizer whose tokens are the matches of re, or if
+// re has a capturing group, the text matched by the first group.
+func MatchTokenizer(re *regexp.Regexp) Tokenizer {
+	return TokenizerFunc(func(record []byte) ([]string, error) {
+		tokens := []string{}
+		for _, m := range re.FindAllStringSubmatch(string(record), -1) {
+			if len(m) > 1 {
+				tokens = append(tokens, m[1])
+			} else {
+				tokens = append(tokens, m[0])
+			}
+		}
+		return tokens, nil
+	})
+}
+
+// SetTokenizer installs t to split records in the Shell format into tokens, in
+// place of ShellTokenizer. With a custom Tokenizer, the rest of a record after
+// the line limit (see SetLineLimit) is made by joining the extra tokens with
+// spaces. Calling SetTokenizer(nil) restores the default.
+//
+// Deprecated: Use Program.SetTokenizer instead.
+func SetTokenizer(t Tokenizer) {
+	std.SetTokenizer(t)
+}
+
+// SetTokenizer is like the package-level SetTokenizer, but for p.
+func (p *Program) SetTokenizer(t Tokenizer) {
+	p.tokenizer = t
+}
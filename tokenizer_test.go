@@ -0,0 +1,65 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var tokenizerTests = []struct {
+	tokenizer Tokenizer
+	input     string
+	tokens    []string
+}{
+	{ShellTokenizer, ` a 'b c' d\ e `, []string{"a", "b c", "d e"}},
+	{FieldsTokenizer, ` a 'b c' d\ e `, []string{"a", "'b", "c'", `d\`, "e"}},
+	{FieldsTokenizer, "", []string{}},
+	{DelimiterTokenizer("|"), "a||b c", []string{"a", "", "b c"}},
+	{DelimiterTokenizer("|"), "", []string{}},
+	{RegexpTokenizer(regexp.MustCompile(`[\s,]+`)), " a, b,c ",
+		[]string{"a", "b", "c"}},
+	{RegexpTokenizer(regexp.MustCompile(`,`)), "", []string{}},
+	{MatchTokenizer(regexp.MustCompile(`\d+`)), "a1b22c", []string{"1", "22"}},
+	{MatchTokenizer(regexp.MustCompile(`(\w+)=`)), "x=1 y=2",
+		[]string{"x", "y"}},
+}
+
+func TestTokenizers(t *testing.T) {
+	for i, test := range tokenizerTests {
+		tokens, err := test.tokenizer.Tokenize([]byte(test.input))
+		if err != nil || !reflect.DeepEqual(tokens, test.tokens) {
+			t.Errorf("%d. Tokenize(%q) = %q, %v\nexpected %q", i, test.input,
+				tokens, err, test.tokens)
+		}
+	}
+}
+
+func TestSetTokenizer(t *testing.T) {
+	defer func() {
+		SetTokenizer(nil)
+		SetLineLimit(0, DropExtra)
+		SetEveryParser(Parser{})
+	}()
+	SetTokenizer(TokenizerFunc(func(record []byte) ([]string, error) {
+		if len(record) == 0 {
+			return nil, errors.New("empty line")
+		}
+		return DelimiterTokenizer(";").Tokenize(record)
+	}))
+	SetEveryParser(Parser{})
+	SetLineLimit(2, RawExtra)
+	records := newRecordReader(strings.NewReader("a b;c;d;e\n\n"))
+	rec, err := records.next()
+	if err != nil || !reflect.DeepEqual(rec.tokens, []string{"a b", "c"}) ||
+		string(rec.rest) != "d e" {
+		t.Errorf("next() = %q with rest %q, %v", rec.tokens, rec.rest, err)
+	}
+	if rec, err = records.next(); err != nil || rec.err == nil {
+		t.Errorf("next() = %q, %v, expected the record to fail", rec.tokens,
+			err)
+	}
+}
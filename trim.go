@@ -0,0 +1,66 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"strings"
+	"unicode"
+)
+
+// A TrimPolicy determines which arguments have leading and trailing white space
+// removed before they are parsed.
+type TrimPolicy int
+
+const (
+	// TrimNone leaves all arguments as they are. It is the default.
+	TrimNone TrimPolicy = iota
+	// TrimInput trims arguments that come from input, such as standard input
+	// and files, but not command-line arguments. White space can only end up
+	// in a token there by quoting or by using a format like CSV, which is
+	// often accidental in files written by hand or by other programs.
+	TrimInput
+	// TrimAll trims all arguments, including command-line arguments. It is
+	// the same as SetNormalization with TrimSpace.
+	TrimAll
+)
+
+// SetTrimPolicy sets which arguments are trimmed before they are parsed, for
+// all parsers. For example, with TrimInput, the Int parser accepts the line
+// `" -5 "` on standard input. To trim the arguments of a single parser, use
+// its Trim method instead.
+//
+// Deprecated: Use Program.SetTrimPolicy instead.
+func SetTrimPolicy(policy TrimPolicy) {
+	std.SetTrimPolicy(policy)
+}
+
+// SetTrimPolicy is like the package-level SetTrimPolicy, but for p.
+func (p *Program) SetTrimPolicy(policy TrimPolicy) {
+	p.trimPolicy = policy
+}
+
+// trimSpace removes leading and trailing white space from s, including Unicode
+// spaces such as the no-break space.
+func trimSpace(s string) string {
+	return strings.TrimFunc(s, unicode.IsSpace)
+}
+
+// trimTokens trims the tokens of a record of input if the trim policy of p
+// requires it.
+func (p *Program) trimTokens(tokens []string) []string {
+	if p.trimPolicy == TrimNone {
+		return tokens
+	}
+	trimmed := make([]string, len(tokens))
+	for i, t := range tokens {
+		trimmed[i] = trimSpace(t)
+	}
+	return trimmed
+}
+
+// Trim returns a new Parser that removes leading and trailing white space from
+// the string before passing it to p, regardless of the trim policy. If p is
+// the zero Parser, the trimmed string itself is returned.
+func (p Parser) Trim() Parser {
+	return p.Pre(trimSpace)
+}
@@ -0,0 +1,50 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTrimPolicy(t *testing.T) {
+	defer func() {
+		SetTrimPolicy(TrimNone)
+		SetEveryParser(Parser{})
+	}()
+	SetEveryParser(Int)
+	rec := record{tokens: []string{" -5 ", "\t3 "}}
+	tests := []struct {
+		policy          TrimPolicy
+		recordOK, argOK bool
+	}{
+		{TrimNone, false, false},
+		{TrimInput, true, false},
+		{TrimAll, true, true},
+	}
+	for _, test := range tests {
+		SetTrimPolicy(test.policy)
+		parsed, err := std.parseRecord(rec)
+		if (err == nil) != test.recordOK {
+			t.Errorf("policy %d: parseRecord returned %v", test.policy, err)
+		}
+		if err == nil && !reflect.DeepEqual(parsed, []interface{}{-5, 3}) {
+			t.Errorf("policy %d: parseRecord returned %v", test.policy, parsed)
+		}
+		if _, err := Parse(rec.tokens); (err == nil) != test.argOK {
+			t.Errorf("policy %d: Parse returned %v", test.policy, err)
+		}
+	}
+}
+
+func TestTrimParser(t *testing.T) {
+	if n, err := Int.Trim().Parse(" 12\n"); n != 12 || err != nil {
+		t.Errorf("Int.Trim() returned %v, %v", n, err)
+	}
+	if s, err := (Parser{}).Trim().Parse("  a b  "); s != "a b" || err != nil {
+		t.Errorf("Parser{}.Trim() returned %q, %v", s, err)
+	}
+	if ex := Int.Example("7").Trim().info().example; ex != "7" {
+		t.Errorf("Trim lost the example, got %q", ex)
+	}
+}
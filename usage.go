@@ -0,0 +1,218 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+)
+
+// SetNames sets the names of the program's arguments, in the same order as the
+// parsers passed to SetParsers. When using SetEveryParser, only the first name
+// is used. The names are used to generate the usage message unless SetUsage is
+// called. For example, a program that uses SetEveryParser(parse.Float64) and
+// SetNames("number") has the usage message "usage: prog number ...".
+//
+// Arguments without names are shown using a placeholder based on their Parser,
+// such as "integer" for Int, "file" for ExistingFile, or "a|b|c" for
+// Choice("a", "b", "c").
+//
+// Deprecated: Use Program.SetNames instead.
+func SetNames(ns ...string) {
+	std.SetNames(ns...)
+}
+
+// SetNames is like the package-level SetNames, but for p.
+func (p *Program) SetNames(ns ...string) {
+	p.names = ns
+}
+
+// A RepeatStyle is a way of showing an argument that can be repeated in the
+// usage message.
+type RepeatStyle int
+
+const (
+	// SpacedEllipsis shows a repeated argument as "number ...".
+	SpacedEllipsis RepeatStyle = iota
+	// Ellipsis shows a repeated argument as "number...".
+	Ellipsis
+	// OneOrMore shows a repeated argument as "number [number...]".
+	OneOrMore
+)
+
+// SetRepeatStyle sets the way the generated usage message shows arguments that
+// can be repeated. It is SpacedEllipsis by default. Using the same style across
+// a suite of tools keeps their usage messages consistent.
+//
+// Deprecated: Use Program.SetRepeatStyle instead.
+func SetRepeatStyle(style RepeatStyle) {
+	std.SetRepeatStyle(style)
+}
+
+// SetRepeatStyle is like the package-level SetRepeatStyle, but for p.
+func (p *Program) SetRepeatStyle(style RepeatStyle) {
+	p.repeatStyle = style
+}
+
+// A PlaceholderStyle is a way of writing argument placeholders in the usage
+// and help messages.
+type PlaceholderStyle int
+
+const (
+	// PlainPlaceholder writes placeholders as they are, as in "seconds".
+	PlainPlaceholder PlaceholderStyle = iota
+	// AngledPlaceholder writes placeholders in angle brackets, as in
+	// "<seconds>".
+	AngledPlaceholder
+	// UpperPlaceholder writes placeholders in upper case, as in "SECONDS".
+	// The values listed by parsers like Choice in place of a name, as in
+	// "start|stop", keep their case, since they must be typed that way.
+	UpperPlaceholder
+)
+
+// SetPlaceholderStyle sets the way placeholders for arguments are written in
+// the usage and help messages. It is PlainPlaceholder by default. Hand-written
+// text can use the same style by writing placeholders in double braces, as in
+// SetUsage("{{seconds}}") or Help("wait {{seconds}} at most"), since a word
+// in double braces is replaced by the placeholder in the current style. Text
+// in single braces, as in "{start|stop}", is left alone.
+//
+// Deprecated: Use Program.SetPlaceholderStyle instead.
+func SetPlaceholderStyle(style PlaceholderStyle) {
+	std.SetPlaceholderStyle(style)
+}
+
+// SetPlaceholderStyle is like the package-level SetPlaceholderStyle, but for
+// p.
+func (p *Program) SetPlaceholderStyle(style PlaceholderStyle) {
+	p.placeholderStyle = style
+}
+
+// placeholder writes name in the placeholder style of p.
+func (p *Program) placeholder(name string) string {
+	switch p.placeholderStyle {
+	case AngledPlaceholder:
+		return "<" + name + ">"
+	case UpperPlaceholder:
+		return strings.ToUpper(name)
+	}
+	return name
+}
+
+// argPlaceholder returns the placeholder for the declared argument at index i.
+// Names that list the accepted values, such as "start|stop" for a Choice, are
+// never put in upper case.
+func (p *Program) argPlaceholder(i int) string {
+	name := p.argName(i)
+	if p.placeholderStyle == UpperPlaceholder &&
+		(i >= len(p.names) || p.names[i] == "") &&
+		i < len(p.parsers) && p.parsers[i].info().literal {
+		return name
+	}
+	return p.placeholder(name)
+}
+
+// bracedPlaceholder matches a placeholder written in double braces in
+// hand-written text.
+var bracedPlaceholder = regexp.MustCompile(`\{\{([\w.-]+)\}\}`)
+
+// stylePlaceholders replaces the placeholders in double braces in s with
+// placeholders in the style of p.
+func (p *Program) stylePlaceholders(s string) string {
+	return bracedPlaceholder.ReplaceAllStringFunc(s, func(m string) string {
+		return p.placeholder(m[2 : len(m)-2])
+	})
+}
+
+// argName returns the name of the declared argument at index i.
+func (p *Program) argName(i int) string {
+	if i < len(p.names) && p.names[i] != "" {
+		return p.names[i]
+	}
+	if i < len(p.parsers) && p.parsers[i].info().name != "" {
+		return p.parsers[i].info().name
+	}
+	return "arg"
+}
+
+// repeated formats name as an argument that can be repeated.
+func (p *Program) repeated(name string) string {
+	switch p.repeatStyle {
+	case Ellipsis:
+		return name + "..."
+	case OneOrMore:
+		return name + " [" + name + "...]"
+	}
+	return name + " ..."
+}
+
+// usageArgs generates the part of the usage message that lists the arguments.
+func (p *Program) usageArgs() string {
+	args := make([]string, len(p.parsers))
+	min := p.minArgs()
+	for i, q := range p.parsers {
+		args[i] = p.argPlaceholder(i)
+		switch {
+		case p.isRest(i):
+			args[i] = p.repeated(args[i])
+			if len(p.parsers) > 1 {
+				args[i] = "[" + args[i] + "]"
+			}
+		case p.repeat && q.info().hasDefault, !p.repeat && i >= min:
+			args[i] = "[" + args[i] + "]"
+		}
+	}
+	return strings.Join(args, " ")
+}
+
+// usageMessage returns the usage message given to SetUsage, or if there is
+// none, a usage message generated from the argument names.
+func (p *Program) usageMessage() string {
+	if p.hasUsage {
+		return strings.TrimRightFunc("usage: "+p.name+" "+
+			p.stylePlaceholders(p.usage), unicode.IsSpace)
+	}
+	return strings.TrimSpace("usage: " + p.name + " " + p.usageArgs())
+}
+
+// helpMessage returns the usage message followed by a description of each
+// argument that has a description, default, or example.
+func (p *Program) helpMessage() string {
+	var b strings.Builder
+	b.WriteString(p.usageMessage() + "\n")
+	w := tabwriter.NewWriter(&b, 0, 8, 2, ' ', 0)
+	for i := 0; i < p.numDeclared(); i++ {
+		info := p.parsers[i].info()
+		var parts []string
+		if info.help != "" {
+			parts = append(parts, p.stylePlaceholders(info.help))
+		}
+		if info.hasDefault && !p.isRest(i) {
+			parts = append(parts, fmt.Sprintf("(default %q)", info.def))
+		}
+		if info.example != "" {
+			parts = append(parts, "e.g. "+info.example)
+		}
+		if parts != nil {
+			fmt.Fprintf(w, "  %s\t%s\n", p.argPlaceholder(i),
+				strings.Join(parts, " "))
+		}
+	}
+	w.Flush()
+	return b.String()
+}
+
+// numDeclared returns the number of arguments that have been declared. In
+// repeat mode, the last one stands for all the remaining arguments.
+func (p *Program) numDeclared() int {
+	return len(p.parsers)
+}
+
+// isRest returns true if the declared argument at index i is the one that is
+// repeated in repeat mode.
+func (p *Program) isRest(i int) bool {
+	return p.repeat && i == p.restIndex
+}
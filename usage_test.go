@@ -0,0 +1,118 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import "testing"
+
+var usageMessageTests = []struct {
+	parsers []Parser
+	repeat  bool
+	names   []string
+	style   RepeatStyle
+	usage   string
+}{
+	{[]Parser{Parser{}}, true, nil, SpacedEllipsis, "usage: prog arg ..."},
+	{[]Parser{Float64}, true, nil, Ellipsis, "usage: prog number..."},
+	{[]Parser{ExistingFile}, true, nil, OneOrMore,
+		"usage: prog file [file...]"},
+	{[]Parser{Int}, true, []string{"n", "m"}, SpacedEllipsis,
+		"usage: prog n ..."},
+	{[]Parser{}, false, nil, SpacedEllipsis, "usage: prog"},
+	{[]Parser{Int, Choice("a", "b"), Parser{}}, false, []string{"", "", "x"},
+		Ellipsis, "usage: prog integer a|b x"},
+}
+
+func TestUsageMessage(t *testing.T) {
+	defer func(name string) {
+		std.name = name
+		std.parsers, std.repeat, std.names = []Parser{Parser{}}, true, nil
+		std.usage, std.hasUsage = "", false
+		std.repeatStyle = SpacedEllipsis
+	}(std.name)
+	std.name = "prog"
+	std.usage, std.hasUsage = "", false
+	for i, test := range usageMessageTests {
+		std.parsers, std.repeat = test.parsers, test.repeat
+		std.names = test.names
+		SetRepeatStyle(test.style)
+		if msg := std.usageMessage(); msg != test.usage {
+			t.Errorf("%d. usageMessage() = %q\nexpected %q", i, msg,
+				test.usage)
+		}
+	}
+}
+
+func TestHelpMessage(t *testing.T) {
+	defer func(name string) {
+		std.name = name
+		std.parsers, std.repeat, std.names = []Parser{Parser{}}, true, nil
+		std.usage, std.hasUsage = "", false
+	}(std.name)
+	std.name = "prog"
+	std.usage, std.hasUsage = "", false
+	SetParsers(Int.Example("42"), Parser{}, Float64.Example("1.5"))
+	SetNames("count", "label", "x")
+	expected := "usage: prog count label x\n" +
+		"  count  e.g. 42\n" +
+		"  x      e.g. 1.5\n"
+	if msg := std.helpMessage(); msg != expected {
+		t.Errorf("helpMessage() = %q\nexpected %q", msg, expected)
+	}
+}
+
+var placeholderTests = []struct {
+	style PlaceholderStyle
+	usage string
+	help  string
+}{
+	{PlainPlaceholder, "usage: prog seconds [unit]",
+		"  seconds  wait seconds at most\n  unit     (default \"s\")\n"},
+	{AngledPlaceholder, "usage: prog <seconds> [<unit>]",
+		"  <seconds>  wait <seconds> at most\n  <unit>     (default \"s\")\n"},
+	{UpperPlaceholder, "usage: prog SECONDS [UNIT]",
+		"  SECONDS  wait SECONDS at most\n  UNIT     (default \"s\")\n"},
+}
+
+func TestPlaceholderStyle(t *testing.T) {
+	defer func(name string) {
+		std.name = name
+		std.parsers, std.repeat, std.names = []Parser{Parser{}}, true, nil
+		std.usage, std.hasUsage = "", false
+		SetPlaceholderStyle(PlainPlaceholder)
+	}(std.name)
+	std.name = "prog"
+	std.usage, std.hasUsage = "", false
+	SetParsers(Float64.Help("wait {{seconds}} at most"),
+		Choice("s").Default("s"))
+	SetNames("seconds", "unit")
+	for i, test := range placeholderTests {
+		SetPlaceholderStyle(test.style)
+		if msg := std.usageMessage(); msg != test.usage {
+			t.Errorf("%d. usageMessage() = %q\nexpected %q", i, msg,
+				test.usage)
+		}
+		expected := test.usage + "\n" + test.help
+		if msg := std.helpMessage(); msg != expected {
+			t.Errorf("%d. helpMessage() = %q\nexpected %q", i, msg, expected)
+		}
+	}
+	SetUsage("{{seconds}} [-- {{unit}}]")
+	SetPlaceholderStyle(AngledPlaceholder)
+	if msg := std.usageMessage(); msg != "usage: prog <seconds> [-- <unit>]" {
+		t.Errorf("usageMessage() = %q", msg)
+	}
+	std.usage, std.hasUsage = "", false
+	SetNames("seconds")
+	SetPlaceholderStyle(UpperPlaceholder)
+	if msg := std.usageMessage(); msg != "usage: prog SECONDS [s]" {
+		t.Errorf("usageMessage() = %q, expected the choice unchanged", msg)
+	}
+	styles := []PlaceholderStyle{PlainPlaceholder, UpperPlaceholder}
+	for _, style := range styles {
+		SetPlaceholderStyle(style)
+		SetUsage("{start|stop} {x}")
+		if msg := std.usageMessage(); msg != "usage: prog {start|stop} {x}" {
+			t.Errorf("usageMessage() = %q", msg)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// A UTF8Policy determines what happens to input that is not valid UTF-8.
+type UTF8Policy int
+
+const (
+	// AllowInvalidUTF8 passes invalid UTF-8 through to the parsers unchanged.
+	// It is the default.
+	AllowInvalidUTF8 UTF8Policy = iota
+	// RejectInvalidUTF8 makes a record with invalid UTF-8 fail with a
+	// UTF8Error, like a record with a parse error.
+	RejectInvalidUTF8
+	// ReplaceInvalidUTF8 replaces each invalid sequence with the Unicode
+	// replacement character, U+FFFD.
+	ReplaceInvalidUTF8
+)
+
+// SetUTF8Policy sets what happens to records of input that contain invalid
+// UTF-8. It applies to standard input, files, and every other source of
+// records, but not to command-line arguments.
+//
+// Deprecated: Use Program.SetUTF8Policy instead.
+func SetUTF8Policy(policy UTF8Policy) {
+	std.SetUTF8Policy(policy)
+}
+
+// SetUTF8Policy is like the package-level SetUTF8Policy, but for p.
+func (p *Program) SetUTF8Policy(policy UTF8Policy) {
+	p.utf8Policy = policy
+}
+
+// A UTF8Error describes invalid UTF-8 found in a record of input.
+type UTF8Error struct {
+	Line   int // line (or record) number, starting from 1
+	Index  int // position of the argument, starting from zero
+	Offset int // offset of the first invalid byte in the argument
+}
+
+func (e *UTF8Error) Error() string {
+	return fmt.Sprintf("invalid UTF-8 in argument %d at byte %d (line %d)",
+		e.Index+1, e.Offset, e.Line)
+}
+
+// utf8Records applies a UTF8Policy to the records of another recordReader.
+type utf8Records struct {
+	source recordReader
+	policy UTF8Policy
+	line   int
+}
+
+func (r *utf8Records) next() (record, error) {
+	rec, err := r.source.next()
+	if err != nil {
+		return rec, err
+	}
+	r.line++
+	for i, token := range rec.tokens {
+		if utf8.ValidString(token) {
+			continue
+		}
+		if r.policy == ReplaceInvalidUTF8 {
+			rec.tokens[i] = strings.ToValidUTF8(token, "\uFFFD")
+			continue
+		}
+		err := &UTF8Error{r.line, i, invalidOffset(token)}
+		return record{err: err, raw: rec.raw}, nil
+	}
+	return rec, nil
+}
+
+// invalidOffset returns the offset of the first invalid UTF-8 byte in s, or -1
+// if there is none.
+func invalidOffset(s string) int {
+	for i, c := range s {
+		if c == utf8.RuneError {
+			if _, size := utf8.DecodeRuneInString(s[i:]); size == 1 {
+				return i
+			}
+		}
+	}
+	return -1
+}
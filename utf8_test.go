@@ -0,0 +1,58 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUTF8Policy(t *testing.T) {
+	defer SetUTF8Policy(AllowInvalidUTF8)
+	input := "ok é\nab x\xffy\n\xc3\n"
+
+	SetUTF8Policy(AllowInvalidUTF8)
+	got := readRecords(t, newRecordReader(strings.NewReader(input)))
+	expected := [][]string{{"ok", "é"}, {"ab", "x\xffy"}, {"\xc3"}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("allowing: got %q, expected %q", got, expected)
+	}
+
+	SetUTF8Policy(ReplaceInvalidUTF8)
+	got = readRecords(t, newRecordReader(strings.NewReader(input)))
+	expected = [][]string{{"ok", "é"}, {"ab", "x�y"}, {"�"}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("replacing: got %q, expected %q", got, expected)
+	}
+
+	SetUTF8Policy(RejectInvalidUTF8)
+	records := newRecordReader(strings.NewReader(input))
+	var errs []string
+	for i := 0; i < 3; i++ {
+		rec, err := records.next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rec.err != nil {
+			errs = append(errs, rec.err.Error())
+		}
+	}
+	expectedErrs := []string{
+		"invalid UTF-8 in argument 2 at byte 1 (line 2)",
+		"invalid UTF-8 in argument 1 at byte 0 (line 3)",
+	}
+	if !reflect.DeepEqual(errs, expectedErrs) {
+		t.Errorf("rejecting: got errors %q\nexpected %q", errs, expectedErrs)
+	}
+}
+
+func TestInvalidOffset(t *testing.T) {
+	tests := map[string]int{"": -1, "abc": -1, "é�": -1, "é\xff": 2,
+		"a\xe2\x82": 1}
+	for s, expected := range tests {
+		if got := invalidOffset(s); got != expected {
+			t.Errorf("invalidOffset(%q) = %d, expected %d", s, got, expected)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A ConfigError describes mistakes in the way a program has configured parse,
+// such as contradictory argument declarations. Unlike the errors for invalid
+// arguments, it indicates a bug in the program rather than a mistake by the
+// user.
+type ConfigError struct {
+	Problems []string
+}
+
+func (e *ConfigError) Error() string {
+	return "parse: invalid configuration: " + strings.Join(e.Problems, "; ")
+}
+
+// exitConfig is the exit status used when Validate fails. It is EX_SOFTWARE
+// from sysexits.h, which distinguishes bugs in the program from usage errors.
+const exitConfig = 70
+
+// Validate checks the declared arguments for contradictions and returns a
+// ConfigError describing any that it finds. Main calls it before doing anything
+// else and exits with status 70 if it fails. It reports optional arguments
+// that come before required ones, except in repeat mode, in which case their
+// defaults have no effect; defaults and multiple names given with
+// SetEveryParser, and a default for the repeated argument of SetVariadic or
+// SetRepeated, which have no effect either; duplicate argument names; and more
+// names than arguments. It also reports a Reduction or a Histogram without a
+// single numeric parser.
+//
+// Deprecated: Use Program.Validate instead.
+func Validate() error {
+	return std.Validate()
+}
+
+// Validate is like the package-level Validate, but it checks p.
+func (p *Program) Validate() error {
+	var problems []string
+	add := func(format string, a ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, a...))
+	}
+	if p.repeat && p.parsers[p.restIndex].info().hasDefault {
+		if len(p.parsers) == 1 {
+			add("SetEveryParser was given a Parser with a default")
+		} else {
+			add("repeated argument %d has a default", p.restIndex+1)
+		}
+	}
+	for i, min := 0, p.minArgs(); i < min && !p.repeat; i++ {
+		if p.parsers[i].info().hasDefault {
+			add("optional argument %d comes before required argument %d",
+				i+1, min)
+		}
+	}
+	switch {
+	case p.repeat && len(p.parsers) == 1 && len(p.names) > 1:
+		add("SetNames was given %d names with SetEveryParser", len(p.names))
+	case len(p.names) > len(p.parsers):
+		add("SetNames was given %d names for %d arguments", len(p.names),
+			len(p.parsers))
+	}
+	if p.reduction != NoReduction && !p.numeric() {
+		add("SetReduction requires a single numeric parser")
+	}
+	if p.histogramBuckets > 0 && !p.numeric() {
+		add("Histogram requires a single numeric parser")
+	}
+	seen := make(map[string]int)
+	for i, name := range p.names {
+		if j, ok := seen[name]; ok && name != "" {
+			add("arguments %d and %d are both named %q", j+1, i+1, name)
+		}
+		seen[name] = i
+	}
+	if problems != nil {
+		return &ConfigError{problems}
+	}
+	return nil
+}
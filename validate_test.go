@@ -0,0 +1,61 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+package parse
+
+import "testing"
+
+var validateTests = []struct {
+	setup func()
+	msg   string
+}{
+	{func() { SetParsers(Int, Int.Default("1")) }, ""},
+	{func() { SetEveryParser(Int) }, ""},
+	{func() {
+		SetParsers(Int, Int.Default("1"), Float64.Default("2"))
+		SetNames("a", "b", "")
+	}, ""},
+	{func() { SetParsers(Int.Default("1"), Int) },
+		"parse: invalid configuration: optional argument 1 comes before " +
+			"required argument 2"},
+	{func() { SetEveryParser(Int.Default("1")) },
+		"parse: invalid configuration: SetEveryParser was given a Parser " +
+			"with a default"},
+	{func() {
+		SetEveryParser(Int)
+		SetNames("a", "b")
+	}, "parse: invalid configuration: SetNames was given 2 names with " +
+		"SetEveryParser"},
+	{func() {
+		SetParsers(Int, Int, Int)
+		SetNames("x", "y", "x")
+	}, `parse: invalid configuration: arguments 1 and 3 are both named "x"`},
+	{func() {
+		SetParsers(Int)
+		SetNames("x", "y")
+	}, "parse: invalid configuration: SetNames was given 2 names for 1 " +
+		"arguments"},
+	{func() {
+		SetParsers(Int.Default("1"), Int)
+		SetNames("x", "x")
+	}, "parse: invalid configuration: optional argument 1 comes before " +
+		`required argument 2; arguments 1 and 2 are both named "x"`},
+}
+
+func TestValidate(t *testing.T) {
+	defer func() {
+		SetEveryParser(Parser{})
+		SetNames()
+	}()
+	for i, test := range validateTests {
+		SetNames()
+		test.setup()
+		msg := ""
+		if err := Validate(); err != nil {
+			msg = err.Error()
+		}
+		if msg != test.msg {
+			t.Errorf("%d. Validate() returned %q\nexpected %q", i, msg,
+				test.msg)
+		}
+	}
+}
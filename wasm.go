@@ -0,0 +1,105 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+//go:build js && wasm
+
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"syscall/js"
+)
+
+// JSOpener returns an Opener for a program compiled to WebAssembly that runs in
+// a browser or in Node.js. It opens inputs from inputs, a JavaScript object
+// whose properties are input names and whose values are strings, Uint8Arrays,
+// or ReadableStreams. Standard input has the name "-". For example, a browser
+// playground can run a program on the contents of a text area with
+//
+//	parse.SetOpener(parse.JSOpener(js.Global().Get("programInputs")))
+//
+// after setting programInputs to {"-": textArea.value} in JavaScript.
+func JSOpener(inputs js.Value) Opener {
+	return func(name string) (io.ReadCloser, error) {
+		v := inputs.Get(name)
+		if v.IsUndefined() || v.IsNull() {
+			return nil, fmt.Errorf("%s: no such input", name)
+		}
+		r, err := JSReader(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		return io.NopCloser(r), nil
+	}
+}
+
+// JSReader returns a reader for v, which must be a JavaScript string,
+// Uint8Array, or ReadableStream of Uint8Arrays. Reading from a stream waits for
+// each chunk, so it must not happen on the JavaScript event loop's goroutine,
+// which Main never does when it is called from a program's main function.
+func JSReader(v js.Value) (io.Reader, error) {
+	switch {
+	case v.Type() == js.TypeString:
+		return strings.NewReader(v.String()), nil
+	case v.InstanceOf(js.Global().Get("Uint8Array")):
+		data := make([]byte, v.Length())
+		js.CopyBytesToGo(data, v)
+		return strings.NewReader(string(data)), nil
+	case v.Type() == js.TypeObject && v.Get("getReader").Type() ==
+		js.TypeFunction:
+		return &jsStreamReader{reader: v.Call("getReader")}, nil
+	}
+	return nil, errors.New("input is not a string, Uint8Array, or stream")
+}
+
+// A jsStreamReader reads from a ReadableStreamDefaultReader.
+type jsStreamReader struct {
+	reader  js.Value
+	pending []byte // the rest of the last chunk
+	err     error
+}
+
+func (r *jsStreamReader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.pending, r.err = r.next()
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// next waits for the next chunk of the stream.
+func (r *jsStreamReader) next() ([]byte, error) {
+	type result struct {
+		chunk js.Value
+		done  bool
+		err   error
+	}
+	results := make(chan result, 1)
+	then := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		results <- result{args[0].Get("value"), args[0].Get("done").Bool(), nil}
+		return nil
+	})
+	defer then.Release()
+	catch := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		results <- result{err: fmt.Errorf("reading stream: %v", args[0])}
+		return nil
+	})
+	defer catch.Release()
+	r.reader.Call("read").Call("then", then, catch)
+	res := <-results
+	if res.err != nil {
+		return nil, res.err
+	}
+	if res.done {
+		return nil, io.EOF
+	}
+	data := make([]byte, res.chunk.Length())
+	js.CopyBytesToGo(data, res.chunk)
+	return data, nil
+}
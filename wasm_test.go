@@ -0,0 +1,47 @@
+// Copyright 2013 Mitchell Kember. Subject to the MIT License.
+
+//go:build js && wasm
+
+package parse
+
+import (
+	"io"
+	"syscall/js"
+	"testing"
+)
+
+func TestJSOpener(t *testing.T) {
+	inputs := js.Global().Get("Object").New()
+	inputs.Set("-", "1 2\n3\n")
+	bytes := js.Global().Get("Uint8Array").New(2)
+	js.CopyBytesToJS(bytes, []byte("x\n"))
+	inputs.Set("file", bytes)
+	open := JSOpener(inputs)
+	for name, expected := range map[string]string{"-": "1 2\n3\n",
+		"file": "x\n"} {
+		rc, err := open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data, _ := io.ReadAll(rc); string(data) != expected {
+			t.Errorf("input %q has %q, expected %q", name, data, expected)
+		}
+	}
+	if _, err := open("missing"); err == nil {
+		t.Error("opened a missing input")
+	}
+}
+
+func TestJSReaderStream(t *testing.T) {
+	response := js.Global().Get("Response")
+	if response.IsUndefined() {
+		t.Skip("Response is not available")
+	}
+	r, err := JSReader(response.New("a b\nc\n").Get("body"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := io.ReadAll(r); string(data) != "a b\nc\n" || err != nil {
+		t.Errorf("stream has %q, %v", data, err)
+	}
+}